@@ -2,38 +2,68 @@ package scf
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/mooyang-code/go-commlib/trpc-database/timer"
 	"github.com/mooyang-code/scf-framework/config"
 	"github.com/mooyang-code/scf-framework/dnsproxy"
 	"github.com/mooyang-code/scf-framework/gateway"
 	"github.com/mooyang-code/scf-framework/heartbeat"
+	"github.com/mooyang-code/scf-framework/metrics"
 	"github.com/mooyang-code/scf-framework/model"
 	"github.com/mooyang-code/scf-framework/plugin"
 	"github.com/mooyang-code/scf-framework/reporter"
 	"github.com/mooyang-code/scf-framework/storage"
+	"github.com/mooyang-code/scf-framework/tracing"
 	"github.com/mooyang-code/scf-framework/trigger"
 	"trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/log"
+	"trpc.group/trpc-go/trpc-go/server"
 )
 
+// defaultShutdownTimeout 退出流程的默认最长时间（WithGracefulTimeout 可覆盖）
+const defaultShutdownTimeout = 30 * time.Second
+
 // App SCF 框架主应用
 type App struct {
 	opts          *options
 	cfg           *config.FrameworkConfig
 	runtime       *config.RuntimeState
 	taskStore     *config.TaskInstanceStore
+	stateStore    *config.StateStore
 	plugin        plugin.Plugin
 	triggerMgr    *trigger.Manager
+	taskReporter  *reporter.TaskReporter
+	hbReporter    *heartbeat.Reporter
+	oneShot       *trigger.OneShotScheduler
+	server        *server.Server
+	newServer     func(...server.Option) *server.Server // 创建 TRPC Server，测试中可替换
+	shutdownOnce  sync.Once                             // 排空流程只执行一次
+	shutdownErr   error
+	serverClosing atomic.Bool // TRPC Server 已开始关闭（Shutdown 触发或收到退出信号），避免重复 Close
 	gw            *gateway.Gateway
 	dnsResolver   *dnsproxy.Resolver
 	storageWriter *storage.RPCWriter
 	storageReader *storage.Reader
+	metrics       *metrics.Metrics
+	tracer        *tracing.Tracer
+	ready         atomic.Bool // 触发器全部启动后置位，Shutdown 时清除，供 Gateway /ready 使用
+
+	versionMismatch atomic.Pointer[VersionMismatch] // shutdown 处理方式下记录的版本不一致事件，Run 据此返回 ErrVersionMismatch
+
+	reloadMu      sync.Mutex     // 串行化 SIGHUP 热加载与排空，保证排空开始后不再热加载（插件 Close 之后不会收到 OnConfigReload）
+	reloadStopped bool           // 排空已开始，不再热加载
+	hupCh         chan os.Signal // SIGHUP 通知，排空时停止接收并关闭
+
+	mu sync.RWMutex // 保护 Run 中赋值、可被其他 goroutine（如 Health）并发读取的 cfg/runtime/hbReporter/triggerMgr
 }
 
 // New 创建 App 实例
@@ -43,13 +73,17 @@ func New(p plugin.Plugin, opts ...Option) *App {
 		opt(o)
 	}
 	return &App{
-		opts:   o,
-		plugin: p,
+		opts:      o,
+		plugin:    p,
+		newServer: trpc.NewServer,
 	}
 }
 
 // Config 返回框架配置（实现 plugin.Framework 接口）
+// 热加载以副本替换配置而不原地修改，返回的配置可在任意 goroutine 中只读使用
 func (a *App) Config() *config.FrameworkConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.cfg
 }
 
@@ -63,6 +97,16 @@ func (a *App) TaskStore() *config.TaskInstanceStore {
 	return a.taskStore
 }
 
+// DecodePluginConfig 按插件名解析插件配置（实现 plugin.Framework 接口）
+func (a *App) DecodePluginConfig(v interface{}) error {
+	return a.Config().DecodePluginConfig(a.plugin.Name(), v)
+}
+
+// State 返回插件级 key/value 状态存储（实现 plugin.Framework 接口）
+func (a *App) State() *config.StateStore {
+	return a.stateStore
+}
+
 // DNSResolver 返回 DNS 解析器（实现 plugin.Framework 接口，无配置时返回 nil）
 func (a *App) DNSResolver() *dnsproxy.Resolver {
 	return a.dnsResolver
@@ -78,34 +122,121 @@ func (a *App) StorageReader() *storage.Reader {
 	return a.storageReader
 }
 
+// TaskReporter 返回任务状态上报器（实现 plugin.Framework 接口），供需要手动上报的插件使用
+func (a *App) TaskReporter() *reporter.TaskReporter {
+	return a.taskReporter
+}
+
+// ScheduleOnce 在 delay 之后执行一次 fn（实现 plugin.Framework 接口）
+func (a *App) ScheduleOnce(delay time.Duration, fn func(ctx context.Context)) {
+	if a.oneShot == nil {
+		log.Warnf("ScheduleOnce called before app started, task dropped")
+		return
+	}
+	a.oneShot.Schedule(delay, fn)
+}
+
+// TriggerActive 返回指定触发器当前是否活跃（实现 plugin.Framework 接口）
+func (a *App) TriggerActive(name string) bool {
+	a.mu.RLock()
+	triggerMgr := a.triggerMgr
+	a.mu.RUnlock()
+	if triggerMgr == nil {
+		return false
+	}
+	return triggerMgr.TriggerActive(context.Background(), name)
+}
+
+// Triggers 返回所有已注册触发器的运行状态快照（实现 plugin.Framework 接口）
+func (a *App) Triggers() []model.TriggerStatus {
+	a.mu.RLock()
+	triggerMgr := a.triggerMgr
+	a.mu.RUnlock()
+	if triggerMgr == nil {
+		return nil
+	}
+	return triggerMgr.Statuses(context.Background())
+}
+
 // Run 启动应用
 func (a *App) Run(ctx context.Context) error {
 	// 1. 加载配置
 	cfg, err := config.LoadFrameworkConfig(a.opts.configPath)
 	if err != nil {
+		var parseErr *config.ParseError
+		var validationErr *config.ValidationError
+		switch {
+		case errors.Is(err, config.ErrConfigNotFound):
+			return fmt.Errorf("config file %s does not exist, check WithConfigPath: %w", a.opts.configPath, err)
+		case errors.As(err, &parseErr):
+			return fmt.Errorf("config file %s is not valid YAML: %w", a.opts.configPath, err)
+		case errors.As(err, &validationErr):
+			return err
+		}
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	a.mu.Lock()
 	a.cfg = cfg
+	a.mu.Unlock()
 
 	// 2. 创建 TRPC Server
-	s := trpc.NewServer()
+	s := a.newServer()
+	a.setServer(ctx, s)
 
 	// 3. 初始化 RuntimeState
-	a.runtime = config.NewRuntimeState(cfg)
+	rs := config.NewRuntimeState(cfg)
+	a.mu.Lock()
+	a.runtime = rs
+	a.mu.Unlock()
+	if a.opts.nodeID != "" {
+		a.runtime.SetNodeID(a.opts.nodeID)
+	}
 	a.runtime.InitNodeIDFromEnv()
+	a.runtime.InitPlacementFromEnv()
+	for _, labels := range []map[string]string{cfg.System.Labels, a.opts.labels} {
+		if ignored := a.runtime.SetLabels(labels); len(ignored) > 0 {
+			log.WarnContextf(ctx, "labels %v conflict with reserved metadata keys, ignored", ignored)
+		}
+	}
 
-	// 4. 初始化 TaskInstanceStore
-	a.taskStore = config.NewTaskInstanceStore()
+	// 4. 初始化 TaskInstanceStore（配置了 WithTaskStorePath 时从快照恢复上次的任务列表；
+	//    插件实现了 TaskParamsValidator 时，参数非法的任务标记为无效）
+	storeOpts := []config.TaskStoreOption{config.WithPersistPath(a.opts.taskStorePath)}
+	if v, ok := plugin.Lookup[plugin.TaskParamsValidator](a.plugin); ok {
+		storeOpts = append(storeOpts, config.WithParamsValidator(v.ValidateTaskParams))
+	}
+	a.taskStore = config.NewTaskInstanceStore(storeOpts...)
+
+	// 4.1 初始化插件状态存储（配置了 WithStatePath 时持久化到磁盘）
+	a.stateStore = config.NewStateStore(a.opts.statePath)
 
 	// 4.5 初始化 Storage（RPC 方式）
 	storageTarget := a.runtime.GetStorageServerRPC()
 	a.storageWriter = storage.NewRPCWriter(storageTarget, cfg.Storage)
 	a.storageReader = storage.NewReader(storageTarget, cfg.Storage)
 
+	// 4.55 初始化框架指标（Gateway 启用时由 GET /metrics 暴露）
+	a.metrics = metrics.New(a.opts.metricsRegistry)
+	a.tracer = tracing.New(a.opts.tracerProvider)
+
+	// 4.6 初始化一次性延迟任务调度器（插件可在 Init 中使用）
+	a.oneShot = trigger.NewOneShotScheduler(ctx)
+
+	// 4.7 初始化 TaskReporter（框架自动上报 OnTrigger 返回的 TaskResults，插件也可在 Init 中获取后手动上报）
+	a.taskReporter = reporter.NewTaskReporter(a.runtime,
+		reporter.WithStatusCodes(a.opts.taskStatusSuccess, a.opts.taskStatusFailed),
+		reporter.WithReportPath(cfg.Heartbeat.TaskReportPath),
+		reporter.WithBatchReportPath(cfg.Heartbeat.TaskBatchPath),
+		reporter.WithBatching(time.Duration(cfg.Heartbeat.TaskBatchWaitMs)*time.Millisecond, cfg.Heartbeat.TaskBatchSize),
+		reporter.WithClientOptions(a.controlPlaneClientOptions()...),
+		reporter.WithEncoder(a.opts.taskStatusEncoder),
+		reporter.WithMetrics(a.metrics))
+
 	// 5. 调用 plugin.Init
 	if err := a.plugin.Init(ctx, a); err != nil {
 		return fmt.Errorf("failed to init plugin %q: %w", a.plugin.Name(), err)
 	}
+	a.runtime.SetInitialized(true)
 	log.InfoContextf(ctx, "plugin %q initialized", a.plugin.Name())
 
 	// 5.5 初始化 DNS Resolver（如配置了 dns_proxy）
@@ -118,13 +249,59 @@ func (a *App) Run(ctx context.Context) error {
 		log.InfoContextf(ctx, "DNS resolver initialized: domains=%v", cfg.DNSProxy.ScheduledDomains)
 	}
 
+	// 5.6 创建心跳上报器（探测响应需读取其状态）；关闭时不创建
+	heartbeatEnabled := cfg.Heartbeat.IsEnabled()
+	if a.opts.heartbeatEnabled != nil {
+		heartbeatEnabled = *a.opts.heartbeatEnabled
+	}
+	if heartbeatEnabled {
+		hbOpts := append([]heartbeat.ReporterOption{
+			heartbeat.WithReportPath(cfg.Heartbeat.ReportPath),
+			heartbeat.WithClientOptions(a.controlPlaneClientOptions()...),
+			heartbeat.WithMetrics(a.metrics),
+			heartbeat.WithExtraTargets(cfg.Heartbeat.ExtraTargets, cfg.Heartbeat.TargetMode == "all"),
+			heartbeat.WithExtraNamespace(cfg.Heartbeat.ExtraNamespace),
+		}, a.opts.heartbeatOpts...)
+		hb := heartbeat.NewReporter(a.runtime, a.taskStore, a.plugin, a.dnsResolver, hbOpts...)
+		a.mu.Lock()
+		a.hbReporter = hb
+		a.mu.Unlock()
+		a.hbReporter.SetPayloadWarnThreshold(cfg.Heartbeat.PayloadWarnBytes)
+		a.hbReporter.SetVersionMismatchHandler(a.handleVersionMismatch)
+	} else {
+		log.InfoContextf(ctx, "heartbeat disabled, no heartbeat will be reported to control plane")
+	}
+
 	// 6. 注册 HTTP Gateway（如启用）
 	if a.opts.enableGateway {
-		probeHandler := heartbeat.NewProbeHandler(a.runtime, a.plugin, a.storageWriter, a.storageReader)
+		probeHandler := heartbeat.NewProbeHandler(a.runtime, a.taskStore, a.plugin, a.storageWriter, a.storageReader)
+		probeHandler.SetOneShotCounter(a.oneShot.Pending)
+		if a.hbReporter != nil {
+			probeHandler.SetHeartbeatStatus(a.hbReporter.Status)
+		} else {
+			probeHandler.SetHeartbeatDisabled(true)
+		}
+		probeHandler.SetInflightUsage(func() (int, int) {
+			if a.triggerMgr == nil {
+				return 0, 0
+			}
+			return a.triggerMgr.InflightUsage()
+		})
+		probeHandler.SetHeartbeatInterval(cfg.Heartbeat.Interval)
+		if a.opts.probeCacheWindow != nil {
+			probeHandler.SetCacheWindow(*a.opts.probeCacheWindow)
+		}
 		a.gw = gateway.NewGateway(probeHandler)
+		a.gw.SetMetricsHandler(a.metrics.Handler())
+		a.gw.SetTracer(a.tracer)
+		a.gw.SetMetrics(a.metrics)
+		a.gw.SetAccessLog(a.opts.gatewayAccessLog)
+		a.gw.SetBodyReadTimeout(a.opts.gatewayReadTimeout)
+		a.gw.SetHealthCheck(a.pluginReady)
+		a.gw.SetReadyCheck(a.readiness)
 
 		// HTTPPluginAdapter 模式：设置 catch-all 转发
-		if adapter, ok := a.plugin.(*plugin.HTTPPluginAdapter); ok {
+		if adapter, ok := plugin.Lookup[*plugin.HTTPPluginAdapter](a.plugin); ok {
 			u, err := url.Parse(adapter.BaseURL())
 			if err == nil {
 				host := u.Hostname()
@@ -134,20 +311,31 @@ func (a *App) Run(ctx context.Context) error {
 					fmt.Sscanf(port, "%d", &portNum)
 				}
 				if portNum > 0 {
-					a.gw.SetPluginHandler(gateway.NewForwarder(host, portNum))
+					a.gw.SetPluginHandler(gateway.NewForwarder(host, portNum,
+						gateway.WithTracer(a.tracer), gateway.WithBodyReadTimeout(a.opts.gatewayReadTimeout)))
 				}
 			}
 		}
 
+		for _, rt := range a.opts.gatewayRoutes {
+			a.gw.AddRoute(rt.prefix, gateway.NewForwarder(rt.host, rt.port,
+				gateway.WithStripPrefix(rt.prefix), gateway.WithTracer(a.tracer),
+				gateway.WithBodyReadTimeout(a.opts.gatewayReadTimeout)))
+			log.InfoContextf(ctx, "gateway route %s -> %s:%d", rt.prefix, rt.host, rt.port)
+		}
+
 		a.gw.Register(s.Service(a.opts.gatewayServiceName))
 		log.InfoContextf(ctx, "gateway registered on service %q", a.opts.gatewayServiceName)
 	}
 
-	// 7. 注册心跳 TRPC Timer
-	hbReporter := heartbeat.NewReporter(a.runtime, a.taskStore, a.plugin, a.dnsResolver)
+	// 7. 注册心跳 TRPC Timer（关闭时若 trpc_go.yaml 仍声明了心跳 timer service，注册空 handler 避免 "invalid scheduler"）
 	timer.RegisterScheduler("heartbeatSchedule", &timer.DefaultScheduler{})
-	timer.RegisterHandlerService(s.Service(a.opts.heartbeatServiceName), hbReporter.ScheduledHeartbeat)
-	log.InfoContextf(ctx, "heartbeat timer registered on service %q", a.opts.heartbeatServiceName)
+	if a.hbReporter != nil {
+		timer.RegisterHandlerService(s.Service(a.opts.heartbeatServiceName), a.hbReporter.ScheduledHeartbeat)
+		log.InfoContextf(ctx, "heartbeat timer registered on service %q", a.opts.heartbeatServiceName)
+	} else if hbSvc := s.Service(a.opts.heartbeatServiceName); hbSvc != nil {
+		timer.RegisterHandlerService(hbSvc, func(context.Context, string) error { return nil })
+	}
 
 	// 7.5 注册 DNS 刷新 TRPC Timer（同心跳模式）
 	timer.RegisterScheduler("dnsRefreshSchedule", &timer.DefaultScheduler{})
@@ -164,9 +352,22 @@ func (a *App) Run(ctx context.Context) error {
 		log.InfoContextf(ctx, "DNS refresh timer registered on service %q", a.opts.dnsTimerService)
 	}
 
-	// 8. 初始化 TaskReporter 和 TriggerManager
-	taskReporter := reporter.NewTaskReporter(a.runtime)
-	a.triggerMgr = trigger.NewManager(a.plugin, a.taskStore, a.runtime, taskReporter, a.dnsResolver, a.storageWriter, a.storageReader)
+	// 8. 初始化 TriggerManager
+	triggerMgr := trigger.NewManager(a.plugin, a.taskStore, a.runtime, a.taskReporter, a.dnsResolver, a.storageWriter, a.storageReader)
+	a.mu.Lock()
+	a.triggerMgr = triggerMgr
+	a.mu.Unlock()
+	a.triggerMgr.SetEventSink(a.opts.eventSink)
+	a.triggerMgr.SetWarnOnDuplicateNames(a.opts.warnOnDupTriggers)
+	a.triggerMgr.SetMaxInflightMessages(a.opts.maxInflightMessages)
+	a.triggerMgr.SetMetrics(a.metrics)
+	a.triggerMgr.SetTracer(a.tracer)
+	if a.gw != nil {
+		a.triggerMgr.SetHTTPRouter(a.gw)
+	}
+	if a.hbReporter != nil {
+		a.hbReporter.SetTimerUpdater(a.triggerMgr)
+	}
 
 	// 将框架配置中的 triggers 转换为 model.TriggerConfig
 	triggerConfigs := make([]config.TriggerConfig, len(cfg.Triggers))
@@ -210,24 +411,244 @@ func (a *App) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to start triggers: %w", err)
 	}
 
-	// 11. 信号监听
-	go func() {
-		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
-		sig := <-sigCh
-		log.InfoContextf(ctx, "received signal %v, shutting down...", sig)
-		a.triggerMgr.StopAll(ctx)
-	}()
+	// 10.5 调用插件 OnStart（Gateway/触发器已就绪，Serve 之前），失败时停止触发器并中止启动
+	if starter, ok := plugin.Lookup[plugin.Starter](a.plugin); ok {
+		if err := starter.OnStart(ctx); err != nil {
+			if stopErr := a.triggerMgr.StopAll(ctx); stopErr != nil {
+				log.ErrorContextf(ctx, "stop triggers after OnStart failure: %v", stopErr)
+			}
+			return fmt.Errorf("plugin %q OnStart failed: %w", a.plugin.Name(), err)
+		}
+	}
+	a.ready.Store(true)
+
+	// 11. SIGHUP：热加载 triggers 与 plugin 配置节点（SIGTERM/SIGINT 由 TRPC Server 处理，见 shutdown hook）
+	a.watchReloadSignal(ctx)
 
-	// 12. 启动 TRPC Server（阻塞）
+	// 12. 启动 TRPC Server（阻塞），收到退出信号或 Shutdown 关闭 Server 后返回，此时 shutdown hook 已完成排空；
+	// 版本不一致（shutdown 处理方式）触发的退出返回 ErrVersionMismatch，Serve 之前已触发时不再启动
+	if err := a.versionMismatchErr(); err != nil {
+		return err
+	}
 	log.InfoContextf(ctx, "scf-framework started with plugin %q", a.plugin.Name())
 	if err := s.Serve(); err != nil {
 		return fmt.Errorf("server error: %w", err)
 	}
+	return a.versionMismatchErr()
+}
+
+// watchReloadSignal 监听 SIGHUP 并逐个执行热加载，直到排空时 stopReloadSignal 关闭通知通道
+func (a *App) watchReloadSignal(ctx context.Context) {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	if a.reloadStopped {
+		return
+	}
+	a.hupCh = make(chan os.Signal, 1)
+	signal.Notify(a.hupCh, syscall.SIGHUP)
+	go func(hupCh <-chan os.Signal) {
+		for range hupCh {
+			a.reloadConfig(ctx)
+		}
+	}(a.hupCh)
+}
 
+// stopReloadSignal 停止接收 SIGHUP，并等待进行中的热加载完成；此后不再热加载
+func (a *App) stopReloadSignal() {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	a.reloadStopped = true
+	if a.hupCh != nil {
+		signal.Stop(a.hupCh)
+		close(a.hupCh)
+		a.hupCh = nil
+	}
+}
+
+// reloadConfig 重新读取配置文件并热加载可在线生效的部分：triggers 由 TriggerManager 增量更新，
+// plugin 节点通知插件热加载；其他节点（system/heartbeat 等）变化仅告警，需重启生效
+func (a *App) reloadConfig(ctx context.Context) {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	if a.reloadStopped {
+		return
+	}
+
+	newCfg, err := config.LoadFrameworkConfig(a.opts.configPath)
+	if err != nil {
+		log.ErrorContextf(ctx, "SIGHUP reload failed, keep current config: %v", err)
+		return
+	}
+
+	changed := config.DiffSections(a.Config(), newCfg)
+	if len(changed) == 0 {
+		log.InfoContextf(ctx, "SIGHUP reload: config unchanged")
+		return
+	}
+
+	var restart []string
+	for _, section := range changed {
+		switch section {
+		case "triggers":
+			a.reloadTriggers(ctx, newCfg)
+		case "plugin":
+			a.reloadPluginConfig(ctx, newCfg)
+		default:
+			restart = append(restart, section)
+		}
+	}
+	if len(restart) > 0 {
+		log.WarnContextf(ctx, "SIGHUP reload: sections %v changed, requires restart to apply", restart)
+	}
+}
+
+// reloadTriggers 按新配置增量更新触发器；部分失败时已成功的变更保留，失败的触发器下次 SIGHUP 重试
+func (a *App) reloadTriggers(ctx context.Context, newCfg *config.FrameworkConfig) {
+	if err := a.triggerMgr.Reload(ctx, toModelTriggerConfigs(newCfg.Triggers)); err != nil {
+		log.ErrorContextf(ctx, "SIGHUP reload: triggers partially applied: %v", err)
+	} else {
+		log.InfoContextf(ctx, "SIGHUP reload: triggers reloaded")
+	}
+	a.updateConfig(func(next *config.FrameworkConfig) {
+		next.Triggers = newCfg.Triggers
+	})
+}
+
+// reloadPluginConfig 通知插件热加载 plugin 配置节点，插件接受后替换当前配置
+func (a *App) reloadPluginConfig(ctx context.Context, newCfg *config.FrameworkConfig) {
+	reloader, ok := plugin.Lookup[plugin.ConfigReloader](a.plugin)
+	if !ok {
+		log.WarnContextf(ctx, "SIGHUP reload: plugin %q does not support config reload, requires restart", a.plugin.Name())
+		return
+	}
+	if err := reloader.OnConfigReload(ctx, newCfg); err != nil {
+		log.ErrorContextf(ctx, "SIGHUP reload: plugin %q rejected new config: %v", a.plugin.Name(), err)
+		return
+	}
+	a.updateConfig(func(next *config.FrameworkConfig) {
+		next.Plugin = newCfg.Plugin
+		next.Plugins = newCfg.Plugins
+	})
+	log.InfoContextf(ctx, "SIGHUP reload: plugin %q config reloaded", a.plugin.Name())
+}
+
+// updateConfig 复制当前配置、由 apply 修改副本后整体替换；已发布的配置不做原地修改，避免与 Config() 的读取方竞争
+func (a *App) updateConfig(apply func(next *config.FrameworkConfig)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	next := *a.cfg
+	apply(&next)
+	a.cfg = &next
+}
+
+// Shutdown 优雅停止：先上报一次 state=stopping 的心跳使控制面立即停止调度，再停止接收新的触发事件，
+// 等待进行中的 handler 返回，调用插件的 Close（实现了 plugin.Closer 时），最后关闭 TRPC Server（Run 随之返回）。
+// 整个流程以 ctx 与 WithGracefulTimeout（默认 30s）中较早者为截止。多次调用只执行一次，并发调用方等待首次调用完成。
+// 收到 SIGTERM/SIGINT 时由 TRPC Server 的 shutdown hook 执行同一排空流程，无需手动调用
+func (a *App) Shutdown(ctx context.Context) error {
+	err := a.drain(ctx)
+	if a.server != nil && a.serverClosing.CompareAndSwap(false, true) {
+		// Close 会执行 shutdown hook，排空已完成，hook 直接返回
+		if err := a.server.Close(nil); err != nil {
+			log.WarnContextf(ctx, "close server: %v", err)
+		}
+		log.InfoContextf(ctx, "scf-framework shutdown complete")
+	}
+	return err
+}
+
+// setServer 绑定 TRPC Server。退出信号（SIGTERM/SIGINT）由 TRPC Server 统一处理，
+// 关闭 service 之前先在 shutdown hook 中排空，保证排空完成后才停止对外服务
+func (a *App) setServer(ctx context.Context, s *server.Server) {
+	a.server = s
+	s.RegisterOnShutdown(func() {
+		a.serverClosing.Store(true)
+		if err := a.drain(ctx); err != nil {
+			log.ErrorContextf(ctx, "graceful shutdown incomplete: %v", err)
+		}
+	})
+}
+
+// drain 排空流程（不关闭 TRPC Server）：上报 stopping 心跳、停止并排空触发器、关闭插件、等待任务状态上报。
+// 只执行一次，由 Shutdown 与 TRPC Server 的 shutdown hook 共用
+func (a *App) drain(ctx context.Context) error {
+	a.shutdownOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(ctx, a.opts.gracefulTimeout)
+		defer cancel()
+
+		a.ready.Store(false)
+		a.stopReloadSignal()
+		if a.runtime != nil {
+			a.runtime.SetShuttingDown(true)
+		}
+		a.reportShutdownHeartbeat(ctx)
+		if a.triggerMgr != nil {
+			if err := a.triggerMgr.StopAll(ctx); err != nil {
+				a.shutdownErr = fmt.Errorf("failed to drain triggers: %w", err)
+			}
+		}
+		if a.oneShot != nil {
+			if err := a.oneShot.Stop(ctx); err != nil && a.shutdownErr == nil {
+				a.shutdownErr = err
+			}
+		}
+		if err := a.closePlugin(ctx); err != nil && a.shutdownErr == nil {
+			a.shutdownErr = err
+		}
+		if a.taskReporter != nil {
+			if err := a.taskReporter.Wait(ctx); err != nil && a.shutdownErr == nil {
+				a.shutdownErr = err
+			}
+		}
+		log.InfoContextf(ctx, "scf-framework drained")
+	})
+	return a.shutdownErr
+}
+
+// shutdownHeartbeatTimeout 退出前最后一次心跳的最长等待时间
+const shutdownHeartbeatTimeout = 5 * time.Second
+
+// reportShutdownHeartbeat 上报 state=stopping 的最后一次心跳，失败仅记录日志（控制面会在心跳超时后下线节点）
+func (a *App) reportShutdownHeartbeat(ctx context.Context) {
+	if a.hbReporter == nil || !a.runtime.IsInitialized() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, shutdownHeartbeatTimeout)
+	defer cancel()
+	if err := a.hbReporter.ReportShutdown(ctx); err != nil {
+		log.WarnContextf(ctx, "final shutdown heartbeat failed: %v", err)
+		return
+	}
+	log.InfoContextf(ctx, "final shutdown heartbeat reported")
+}
+
+// pluginCloseTimeout 插件 Close 的最长等待时间
+const pluginCloseTimeout = 10 * time.Second
+
+// closePlugin 调用插件的 Close 释放资源，最多等待 pluginCloseTimeout（不超过 ctx 截止时间）
+func (a *App) closePlugin(ctx context.Context) error {
+	closer, ok := plugin.Lookup[plugin.Closer](a.plugin)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, pluginCloseTimeout)
+	defer cancel()
+	if err := closer.Close(ctx); err != nil {
+		log.ErrorContextf(ctx, "close plugin %q: %v", a.plugin.Name(), err)
+		return fmt.Errorf("failed to close plugin %q: %w", a.plugin.Name(), err)
+	}
 	return nil
 }
 
+// controlPlaneClientOptions 根据 heartbeat 配置构造控制面客户端选项（https、Bearer Token），心跳与任务上报共用
+func (a *App) controlPlaneClientOptions() []reporter.ClientOption {
+	hb := a.Config().Heartbeat
+	return []reporter.ClientOption{
+		reporter.WithTLS(hb.TLS),
+		reporter.WithAuthToken(hb.ResolveAuthToken()),
+	}
+}
+
 // toModelTriggerConfigs 将 config.TriggerConfig 转换为 model.TriggerConfig
 func toModelTriggerConfigs(cfgs []config.TriggerConfig) []model.TriggerConfig {
 	result := make([]model.TriggerConfig, len(cfgs))
@@ -236,6 +657,7 @@ func toModelTriggerConfigs(cfgs []config.TriggerConfig) []model.TriggerConfig {
 			Name:     c.Name,
 			Type:     c.Type,
 			Settings: c.Settings,
+			Enabled:  c.Enabled,
 		}
 	}
 	return result
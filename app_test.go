@@ -0,0 +1,541 @@
+package scf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/plugin"
+	"github.com/mooyang-code/scf-framework/trigger"
+	"trpc.group/trpc-go/trpc-go/server"
+)
+
+// stepLog 记录关闭步骤的先后顺序
+type stepLog struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (l *stepLog) add(step string) {
+	l.mu.Lock()
+	l.steps = append(l.steps, step)
+	l.mu.Unlock()
+}
+
+func (l *stepLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.steps...)
+}
+
+// closerPlugin 实现 plugin.Closer 的测试插件
+type closerPlugin struct {
+	testPlugin
+	log *stepLog
+}
+
+func (p *closerPlugin) Close(ctx context.Context) error {
+	p.log.add("plugin closed")
+	return nil
+}
+
+// fakeService 测试用 TRPC service，Serve 开始后关闭 serving
+type fakeService struct {
+	log     *stepLog
+	serving chan struct{}
+}
+
+func (s *fakeService) Register(desc interface{}, impl interface{}) error { return nil }
+func (s *fakeService) Serve() error {
+	close(s.serving)
+	return nil
+}
+func (s *fakeService) Close(ch chan struct{}) error {
+	s.log.add("service closed")
+	ch <- struct{}{}
+	return nil
+}
+
+func TestShutdownDrainsBeforeServerCloses(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(a *App, s *server.Server) error
+	}{
+		{
+			// 收到退出信号时 TRPC Server 关闭 service，排空由 shutdown hook 完成
+			name: "server closed by trpc",
+			stop: func(a *App, s *server.Server) error { return s.Close(nil) },
+		},
+		{
+			name: "App.Shutdown",
+			stop: func(a *App, s *server.Server) error { return a.Shutdown(context.Background()) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := &stepLog{}
+			a := New(&closerPlugin{log: steps})
+			svc := &fakeService{log: steps, serving: make(chan struct{})}
+			s := &server.Server{}
+			s.AddService("fake", svc)
+			a.setServer(context.Background(), s)
+
+			served := make(chan error, 1)
+			go func() { served <- s.Serve() }()
+			<-svc.serving
+
+			if err := tt.stop(a, s); err != nil {
+				t.Fatalf("stop error = %v", err)
+			}
+			select {
+			case err := <-served:
+				if err != nil {
+					t.Fatalf("Serve() error = %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Serve() did not return after shutdown")
+			}
+
+			want := []string{"plugin closed", "service closed"}
+			if got := steps.get(); !reflect.DeepEqual(got, want) {
+				t.Fatalf("shutdown steps = %v, want %v", got, want)
+			}
+			// 重复调用不会再次排空或关闭 Server
+			if err := a.Shutdown(context.Background()); err != nil {
+				t.Fatalf("second Shutdown() error = %v", err)
+			}
+			if got := steps.get(); !reflect.DeepEqual(got, want) {
+				t.Fatalf("shutdown steps after second call = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// runConfig Run 测试用的最小框架配置，含一个每分钟触发的定时触发器 tick
+const runConfig = `
+system:
+  name: "test"
+heartbeat:
+  interval: 10
+triggers:
+  - {name: tick, type: timer, settings: {cron: "* * * * *"}}
+`
+
+// startApp 以 fakeService 代替 TRPC Server、runConfig 为配置在后台运行 Run，返回 Run 的结果通道
+func startApp(t *testing.T, p plugin.Plugin, svc *fakeService, opts ...Option) (*App, <-chan error) {
+	t.Helper()
+	return startAppWithConfig(t, runConfig, p, svc, opts...)
+}
+
+// startAppWithConfig 同 startApp，使用指定的配置内容
+func startAppWithConfig(t *testing.T, cfg string, p plugin.Plugin, svc *fakeService, opts ...Option) (*App, <-chan error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	a := New(p, append([]Option{WithConfigPath(path), WithHeartbeatEnabled(false)}, opts...)...)
+	a.newServer = func(...server.Option) *server.Server {
+		s := &server.Server{}
+		s.AddService("fake", svc)
+		return s
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- a.Run(context.Background()) }()
+	return a, errc
+}
+
+// startupPlugin 记录 Init 与 OnStart 被调用时可观察到的框架状态
+type startupPlugin struct {
+	testPlugin
+	fw       plugin.Framework
+	log      *stepLog
+	serving  chan struct{}
+	startErr error
+}
+
+func (p *startupPlugin) Init(ctx context.Context, fw plugin.Framework) error {
+	p.fw = fw
+	p.log.add(fmt.Sprintf("init: tick active=%v", fw.TriggerActive("tick")))
+	return nil
+}
+
+func (p *startupPlugin) OnStart(ctx context.Context) error {
+	select {
+	case <-p.serving:
+		p.log.add("start: already serving")
+	default:
+		p.log.add(fmt.Sprintf("start: tick active=%v", p.fw.TriggerActive("tick")))
+	}
+	return p.startErr
+}
+
+func TestRunCallsOnStartAfterStartAll(t *testing.T) {
+	errStart := errors.New("catch-up failed")
+	tests := []struct {
+		name     string
+		startErr error
+		want     []string
+	}{
+		{
+			name: "OnStart runs after StartAll and before Serve",
+			want: []string{"init: tick active=false", "start: tick active=true"},
+		},
+		{
+			name:     "OnStart error aborts startup",
+			startErr: errStart,
+			want:     []string{"init: tick active=false", "start: tick active=true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := &stepLog{}
+			svc := &fakeService{log: steps, serving: make(chan struct{})}
+			p := &startupPlugin{log: steps, serving: svc.serving, startErr: tt.startErr}
+			a, errc := startApp(t, p, svc)
+
+			if tt.startErr != nil {
+				select {
+				case err := <-errc:
+					if !errors.Is(err, tt.startErr) {
+						t.Fatalf("Run() error = %v, want %v", err, tt.startErr)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("Run() did not return after OnStart failure")
+				}
+				if a.TriggerActive("tick") {
+					t.Fatal("triggers still active after OnStart failure")
+				}
+				select {
+				case <-svc.serving:
+					t.Fatal("server started serving after OnStart failure")
+				default:
+				}
+			} else {
+				select {
+				case <-svc.serving:
+				case <-time.After(5 * time.Second):
+					t.Fatal("server did not start serving")
+				}
+				if err := a.Shutdown(context.Background()); err != nil {
+					t.Fatalf("Shutdown() error = %v", err)
+				}
+				if err := <-errc; err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+
+			if got := steps.get()[:len(tt.want)]; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("startup steps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// reportingPlugin 在 Init 中记录 TaskReporter 是否可用、指向测试控制面并写入一个每分钟执行的任务，
+// OnTrigger 返回一条任务结果
+type reportingPlugin struct {
+	testPlugin
+	controlPlane string
+	hasReporter  bool
+}
+
+func (p *reportingPlugin) Init(ctx context.Context, fw plugin.Framework) error {
+	p.hasReporter = fw.TaskReporter() != nil
+	fw.Runtime().UpdateMooxServerURL(p.controlPlane)
+	fw.TaskStore().UpdateTaskInstances([]*model.TaskInstance{{TaskID: "task-1", TaskParams: `{"intervals":["1m"]}`}})
+	return nil
+}
+
+func (p *reportingPlugin) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error) {
+	return &model.TriggerResponse{TaskResults: []model.TaskResult{{TaskID: "task-1", Status: model.TaskStatusSuccess}}}, nil
+}
+
+func TestTaskResultsReportedBeforeShutdownReturns(t *testing.T) {
+	tests := []struct {
+		name            string
+		gracefulTimeout time.Duration
+		release         bool // 控制面是否在 Shutdown 期间返回响应
+		wantErr         bool
+	}{
+		{name: "shutdown waits for pending report", gracefulTimeout: 5 * time.Second, release: true},
+		{name: "shutdown gives up at graceful timeout", gracefulTimeout: 100 * time.Millisecond, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan struct{}, 1)
+			release := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received <- struct{}{}
+				<-release
+			}))
+			t.Cleanup(srv.Close)
+			var releaseOnce sync.Once
+			releaseAll := func() { releaseOnce.Do(func() { close(release) }) }
+			t.Cleanup(releaseAll)
+
+			p := &reportingPlugin{controlPlane: srv.URL}
+			svc := &fakeService{log: &stepLog{}, serving: make(chan struct{})}
+			a, errc := startApp(t, p, svc, WithGracefulTimeout(tt.gracefulTimeout))
+			select {
+			case <-svc.serving:
+			case <-time.After(5 * time.Second):
+				t.Fatal("server did not start serving")
+			}
+			if !p.hasReporter {
+				t.Fatal("TaskReporter was nil during plugin Init")
+			}
+
+			if err := a.triggerMgr.Timer().Tick(context.Background(), trigger.GranularityMinute); err != nil {
+				t.Fatalf("Tick() error = %v", err)
+			}
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatal("task result was not reported to the control plane")
+			}
+
+			shutdownErr := make(chan error, 1)
+			go func() { shutdownErr <- a.Shutdown(context.Background()) }()
+			if tt.release {
+				select {
+				case err := <-shutdownErr:
+					t.Fatalf("Shutdown() returned %v while a report was still pending", err)
+				case <-time.After(50 * time.Millisecond):
+				}
+				releaseAll()
+			}
+			select {
+			case err := <-shutdownErr:
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Shutdown() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Shutdown() did not return")
+			}
+			if err := <-errc; err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+		})
+	}
+}
+
+// nodeIDPlugin 记录 Init 时框架解析出的 NodeID
+type nodeIDPlugin struct {
+	testPlugin
+	nodeID string
+}
+
+func (p *nodeIDPlugin) Init(ctx context.Context, fw plugin.Framework) error {
+	p.nodeID = fw.Runtime().GetNodeID()
+	return nil
+}
+
+func TestNodeIDPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		configID   string
+		optionID   string
+		scfEnv     string
+		tencentEnv string
+		want       string
+	}{
+		{name: "nothing set", want: ""},
+		{name: "config only", configID: "cfg-node", want: "cfg-node"},
+		{name: "option over config", configID: "cfg-node", optionID: "opt-node", want: "opt-node"},
+		{name: "option without config", optionID: "opt-node", want: "opt-node"},
+		{name: "SCF_FUNCTIONNAME over option", configID: "cfg-node", optionID: "opt-node", scfEnv: "scf-fn", want: "scf-fn"},
+		{name: "TENCENTCLOUD_FUNCTIONNAME over config", configID: "cfg-node", tencentEnv: "tc-fn", want: "tc-fn"},
+		{name: "SCF_FUNCTIONNAME over TENCENTCLOUD_FUNCTIONNAME", scfEnv: "scf-fn", tencentEnv: "tc-fn", want: "scf-fn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SCF_FUNCTIONNAME", tt.scfEnv)
+			t.Setenv("TENCENTCLOUD_FUNCTIONNAME", tt.tencentEnv)
+			cfg := runConfig
+			if tt.configID != "" {
+				cfg = strings.Replace(cfg, "system:\n", fmt.Sprintf("system:\n  node_id: %q\n", tt.configID), 1)
+			}
+			var opts []Option
+			if tt.optionID != "" {
+				opts = append(opts, WithNodeID(tt.optionID))
+			}
+			svc := &fakeService{log: &stepLog{}, serving: make(chan struct{})}
+			p := &nodeIDPlugin{}
+			a, errc := startAppWithConfig(t, cfg, p, svc, opts...)
+			select {
+			case <-svc.serving:
+			case err := <-errc:
+				t.Fatalf("Run() error = %v", err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("server did not start serving")
+			}
+			if err := a.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+			if err := <-errc; err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if p.nodeID != tt.want {
+				t.Fatalf("NodeID at Init = %q, want %q", p.nodeID, tt.want)
+			}
+			if got := a.runtime.GetNodeID(); got != tt.want {
+				t.Fatalf("NodeID after Run = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// reloaderPlugin 实现 plugin.ConfigReloader 与 plugin.Closer，记录热加载与关闭
+type reloaderPlugin struct {
+	closerPlugin
+}
+
+func (p *reloaderPlugin) OnConfigReload(ctx context.Context, cfg *config.FrameworkConfig) error {
+	var pc struct {
+		Symbol string `yaml:"symbol"`
+	}
+	if err := cfg.DecodePluginConfig(p.Name(), &pc); err != nil {
+		return err
+	}
+	p.log.add("reload: " + pc.Symbol)
+	return nil
+}
+
+func TestConfigReload(t *testing.T) {
+	const base = runConfig + "plugin:\n  symbol: BTC\n"
+	tests := []struct {
+		name        string
+		newConfig   string
+		wantSymbol  string
+		wantTrigger []string
+	}{
+		{name: "plugin section", newConfig: strings.Replace(base, "BTC", "ETH", 1), wantSymbol: "ETH", wantTrigger: []string{"tick"}},
+		{
+			name:        "triggers section",
+			newConfig:   strings.Replace(base, "plugin:", "  - {name: tock, type: timer, settings: {cron: \"*/5 * * * *\"}}\nplugin:", 1),
+			wantSymbol:  "BTC",
+			wantTrigger: []string{"tick", "tock"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := &stepLog{}
+			p := &reloaderPlugin{closerPlugin{log: steps}}
+			svc := &fakeService{log: steps, serving: make(chan struct{})}
+			a, errc := startAppWithConfig(t, base, p, svc)
+			<-svc.serving
+
+			before := a.Config()
+			if err := os.WriteFile(a.opts.configPath, []byte(tt.newConfig), 0o600); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+			a.reloadConfig(context.Background())
+
+			// 已发布的配置不被修改，Config() 返回新副本
+			var got, old struct {
+				Symbol string `yaml:"symbol"`
+			}
+			if err := before.DecodePluginConfig(p.Name(), &old); err != nil || old.Symbol != "BTC" || len(before.Triggers) != 1 {
+				t.Fatalf("previous config mutated: symbol=%q triggers=%d err=%v", old.Symbol, len(before.Triggers), err)
+			}
+			if err := a.DecodePluginConfig(&got); err != nil || got.Symbol != tt.wantSymbol {
+				t.Fatalf("DecodePluginConfig() symbol = %q, err = %v, want %q", got.Symbol, err, tt.wantSymbol)
+			}
+			var names []string
+			for _, tc := range a.Config().Triggers {
+				names = append(names, tc.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantTrigger) {
+				t.Fatalf("Config().Triggers = %v, want %v", names, tt.wantTrigger)
+			}
+
+			if err := a.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+			<-errc
+			// 排空之后的 SIGHUP 不再热加载，插件 Close 之后不会收到 OnConfigReload
+			if err := os.WriteFile(a.opts.configPath, []byte(strings.Replace(base, "BTC", "SOL", 1)), 0o600); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+			a.reloadConfig(context.Background())
+			if a.hupCh != nil {
+				t.Fatal("SIGHUP still watched after shutdown")
+			}
+			wantSteps := []string{"plugin closed", "service closed"}
+			if tt.wantSymbol != "BTC" {
+				wantSteps = append([]string{"reload: " + tt.wantSymbol}, wantSteps...)
+			}
+			if got := steps.get(); !reflect.DeepEqual(got, wantSteps) {
+				t.Fatalf("steps = %v, want %v", got, wantSteps)
+			}
+		})
+	}
+}
+
+// validatingPlugin 实现 plugin.TaskParamsValidator：要求 task_params 含非空 symbol
+type validatingPlugin struct {
+	testPlugin
+}
+
+func (p *validatingPlugin) ValidateTaskParams(task *model.TaskInstance) error {
+	var params struct {
+		Symbol string `json:"symbol"`
+	}
+	if err := task.DecodeParams(&params); err != nil {
+		return err
+	}
+	if params.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	return nil
+}
+
+func TestTaskParamsValidatorWired(t *testing.T) {
+	tests := []struct {
+		name      string
+		plugin    plugin.Plugin
+		wantValid []string
+	}{
+		{name: "plugin validator marks invalid tasks", plugin: &validatingPlugin{}, wantValid: []string{"a"}},
+		{name: "no validator keeps all tasks", plugin: &testPlugin{}, wantValid: []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeService{log: &stepLog{}, serving: make(chan struct{})}
+			a, errc := startApp(t, tt.plugin, svc)
+			<-svc.serving
+			defer func() {
+				a.Shutdown(context.Background())
+				<-errc
+			}()
+
+			a.TaskStore().UpdateTaskInstances([]*model.TaskInstance{
+				{TaskID: "a", NodeID: "n1", TaskParams: `{"symbol":"BTC"}`},
+				{TaskID: "b", NodeID: "n1", TaskParams: `{"symbol":`},
+				{TaskID: "c", NodeID: "n1", TaskParams: `{}`},
+			})
+			var got []string
+			for _, task := range a.TaskStore().GetByNode("n1") {
+				got = append(got, task.TaskID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.wantValid) {
+				t.Fatalf("GetByNode = %v, want %v", got, tt.wantValid)
+			}
+		})
+	}
+}
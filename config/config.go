@@ -1,8 +1,13 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
+	"strconv"
 
 	"github.com/mooyang-code/scf-framework/dnsproxy"
 	"gopkg.in/yaml.v3"
@@ -10,12 +15,14 @@ import (
 
 // FrameworkConfig 框架配置（从 YAML 文件加载）
 type FrameworkConfig struct {
-	System    SystemConfig     `yaml:"system"`
-	Heartbeat HeartbeatConfig  `yaml:"heartbeat"`
-	Triggers  []TriggerConfig  `yaml:"triggers"`
-	DNSProxy  *dnsproxy.Config `yaml:"dns_proxy,omitempty"` // DNS 代理配置，可选
-	Storage   *StorageConfig   `yaml:"storage,omitempty"`   // xData 存储配置，可选
-	Plugin    yaml.Node        `yaml:"plugin"`              // 延迟解析，留给插件
+	System     SystemConfig         `yaml:"system"`
+	Heartbeat  HeartbeatConfig      `yaml:"heartbeat"`
+	Triggers   []TriggerConfig      `yaml:"triggers"`
+	DNSProxy   *dnsproxy.Config     `yaml:"dns_proxy,omitempty"`   // DNS 代理配置，可选
+	Storage    *StorageConfig       `yaml:"storage,omitempty"`     // xData 存储配置，可选
+	StorageURL string               `yaml:"storage_url,omitempty"` // xData 存储服务初始地址，探测报文下发后被覆盖
+	Plugin     yaml.Node            `yaml:"plugin"`                // 延迟解析，留给插件
+	Plugins    map[string]yaml.Node `yaml:"plugins,omitempty"`     // 按插件名（Plugin.Name()）划分的配置节点，优先于 plugin
 }
 
 // StorageConfig xData 存储配置
@@ -35,14 +42,52 @@ type AuthInfoConfig struct {
 
 // SystemConfig 系统配置
 type SystemConfig struct {
-	Name    string `yaml:"name"`
-	Version string `yaml:"version"`
-	Env     string `yaml:"env"`
+	Name    string            `yaml:"name"`
+	Version string            `yaml:"version"`
+	Env     string            `yaml:"env"`
+	NodeID  string            `yaml:"node_id,omitempty"` // 节点 ID，仅在 SCF 环境变量与 scf.WithNodeID 均未提供时使用（本地开发/非 SCF 部署）
+	Labels  map[string]string `yaml:"labels,omitempty"`  // 部署标签（deploy_id、commit、cohort 等），注入心跳/探测 metadata
+	// 节点归属与能力，上报到心跳 metadata 与探测 node_info；region/namespace 未配置时取 SCF 环境变量
+	Region       string   `yaml:"region,omitempty"`
+	Namespace    string   `yaml:"namespace,omitempty"`
+	Capabilities []string `yaml:"capabilities,omitempty"` // 额外能力标签，插件名总会作为第一个能力上报
 }
 
 // HeartbeatConfig 心跳配置
 type HeartbeatConfig struct {
-	Interval int `yaml:"interval"`
+	Enabled           *bool    `yaml:"enabled,omitempty"` // 未配置时默认启用，false 时不注册心跳上报（本地/开发环境）
+	Interval          int      `yaml:"interval"`
+	ServerIP          string   `yaml:"server_ip,omitempty"`           // 控制面初始地址，探测报文下发后被覆盖
+	ServerPort        int      `yaml:"server_port,omitempty"`         // 控制面初始端口，与 server_ip 搭配使用
+	PayloadWarnBytes  int      `yaml:"payload_warn_bytes,omitempty"`  // 心跳负载告警阈值（字节），默认 256KB
+	ReportPath        string   `yaml:"report_path,omitempty"`         // 心跳上报路径，默认 /gateway/cloudnode/ReportHeartbeatInner
+	TaskReportPath    string   `yaml:"task_report_path,omitempty"`    // 任务状态上报路径，默认 /gateway/collectmgr/ReportTaskStatus
+	TaskBatchPath     string   `yaml:"task_batch_path,omitempty"`     // 任务状态批量上报路径，默认 /gateway/collectmgr/ReportTaskStatusBatch
+	TaskBatchWaitMs   int      `yaml:"task_batch_wait_ms,omitempty"`  // 任务状态攒批时间（毫秒），> 0 时启用批量上报，默认不启用
+	TaskBatchSize     int      `yaml:"task_batch_size,omitempty"`     // 单批最大任务数，默认 100
+	TLS               bool     `yaml:"tls,omitempty"`                 // 心跳/任务上报使用 https
+	AuthToken         string   `yaml:"auth_token,omitempty"`          // 控制面 Bearer Token
+	AuthTokenEnv      string   `yaml:"auth_token_env,omitempty"`      // 未配置 auth_token 时从该环境变量读取 Token
+	ExtraTargets      []string `yaml:"extra_targets,omitempty"`       // 额外心跳目标（控制面迁移期间双报）
+	TargetMode        string   `yaml:"target_mode,omitempty"`         // any（默认）：任一目标成功即可；all：全部成功
+	OnVersionMismatch string   `yaml:"on_version_mismatch,omitempty"` // 版本不一致时的处理：shutdown（默认，排空后退出）| warn（仅告警）
+	ExtraNamespace    string   `yaml:"extra_namespace,omitempty"`     // 插件注入的心跳字段收拢到该顶层字段下，默认合并到顶层
+}
+
+// IsEnabled 返回是否启用心跳上报（未配置 enabled 时默认启用）
+func (c HeartbeatConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// ResolveAuthToken 返回控制面鉴权 Token：优先 auth_token，其次 auth_token_env 指向的环境变量
+func (c HeartbeatConfig) ResolveAuthToken() string {
+	if c.AuthToken != "" {
+		return c.AuthToken
+	}
+	if c.AuthTokenEnv != "" {
+		return os.Getenv(c.AuthTokenEnv)
+	}
+	return ""
 }
 
 // TriggerConfig 触发器配置
@@ -50,19 +95,146 @@ type TriggerConfig struct {
 	Name     string                 `yaml:"name" json:"name"`
 	Type     string                 `yaml:"type" json:"type"`
 	Settings map[string]interface{} `yaml:"settings" json:"settings"`
+	Enabled  *bool                  `yaml:"enabled,omitempty" json:"enabled,omitempty"` // 未配置时默认启用，false 时跳过注册
 }
 
-// LoadFrameworkConfig 从 YAML 文件加载框架配置
+// IsEnabled 返回触发器是否启用（未配置 enabled 时默认启用）
+func (c TriggerConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// LoadFrameworkConfig 从 YAML 文件加载框架配置：字符串值中的 ${VAR} / ${VAR:-default} 替换为环境变量，
+// 再按 envOverrides 用环境变量覆盖指定字段，最后做语义校验（见 Validate）
 func LoadFrameworkConfig(path string) (*FrameworkConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, path)
+		}
 		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
 	}
 
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, newParseError(path, err)
+	}
+	expandEnvNode(&doc)
+
 	var cfg FrameworkConfig
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	if doc.Kind != 0 {
+		if err := doc.Decode(&cfg); err != nil {
+			return nil, newParseError(path, err)
+		}
+	}
+	if err := applyEnvOverrides(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to apply env overrides to config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			vErr.Path = path
+		}
+		return nil, err
 	}
 
 	return &cfg, nil
 }
+
+// ErrConfigNotFound 配置文件不存在
+var ErrConfigNotFound = errors.New("config file not found")
+
+// ParseError 配置文件 YAML 格式错误，Line 为出错行号（yaml 未给出时为 0）
+type ParseError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("malformed config file %s at line %d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("malformed config file %s: %v", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// yamlLinePattern 匹配 yaml.v3 错误信息中的 "line N"（语法错误与 TypeError 均使用该格式）
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// newParseError 包装 YAML 解析错误，并尽量提取出错行号
+func newParseError(path string, err error) *ParseError {
+	pe := &ParseError{Path: path, Err: err}
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		pe.Line, _ = strconv.Atoi(m[1])
+	}
+	return pe
+}
+
+// DecodePluginConfig 将插件配置解析到 v：优先使用 plugins 下与 name 同名的节点，
+// 不存在时回退到单插件的 plugin 节点；两者都未配置时不修改 v 并返回 nil
+func (c *FrameworkConfig) DecodePluginConfig(name string, v interface{}) error {
+	node := c.Plugin
+	if n, ok := c.Plugins[name]; ok {
+		node = n
+	}
+	if node.Kind == 0 {
+		return nil
+	}
+	if err := node.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode config for plugin %q: %w", name, err)
+	}
+	return nil
+}
+
+// DiffSections 比较两份配置，返回发生变化的顶层节点名（system、heartbeat、triggers、dns_proxy、storage、plugin）
+func DiffSections(oldCfg, newCfg *FrameworkConfig) []string {
+	var changed []string
+	if !reflect.DeepEqual(oldCfg.System, newCfg.System) {
+		changed = append(changed, "system")
+	}
+	if !reflect.DeepEqual(oldCfg.Heartbeat, newCfg.Heartbeat) {
+		changed = append(changed, "heartbeat")
+	}
+	if !reflect.DeepEqual(oldCfg.Triggers, newCfg.Triggers) {
+		changed = append(changed, "triggers")
+	}
+	if !reflect.DeepEqual(oldCfg.DNSProxy, newCfg.DNSProxy) {
+		changed = append(changed, "dns_proxy")
+	}
+	if !reflect.DeepEqual(oldCfg.Storage, newCfg.Storage) || oldCfg.StorageURL != newCfg.StorageURL {
+		changed = append(changed, "storage")
+	}
+	if !yamlNodeEqual(&oldCfg.Plugin, &newCfg.Plugin) || !yamlNodeMapEqual(oldCfg.Plugins, newCfg.Plugins) {
+		changed = append(changed, "plugin")
+	}
+	return changed
+}
+
+// yamlNodeMapEqual 比较两组按名称划分的 yaml.Node
+func yamlNodeMapEqual(a, b map[string]yaml.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, an := range a {
+		bn, ok := b[k]
+		if !ok || !yamlNodeEqual(&an, &bn) {
+			return false
+		}
+	}
+	return true
+}
+
+// yamlNodeEqual 按序列化结果比较两个 yaml.Node（忽略行列号等位置信息）
+func yamlNodeEqual(a, b *yaml.Node) bool {
+	if a.Kind == 0 || b.Kind == 0 {
+		return a.Kind == b.Kind
+	}
+	ad, errA := yaml.Marshal(a)
+	bd, errB := yaml.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return bytes.Equal(ad, bd)
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envRefPattern 匹配字符串值中的 ${VAR} 或 ${VAR:-default}
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv 替换 s 中的 ${VAR} / ${VAR:-default}；VAR 未设置或为空时使用 default（无 default 时为空串）
+func expandEnv(s string) string {
+	return envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRefPattern.FindStringSubmatch(ref)
+		if v := os.Getenv(m[1]); v != "" {
+			return v
+		}
+		return m[3]
+	})
+}
+
+// expandEnvNode 递归替换 YAML 文档中所有字符串标量里的环境变量引用（含 plugin 节点）。
+// 非引号标量替换后清除 tag，使 "port: ${PORT}" 可按 int 解析
+func expandEnvNode(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode {
+		if !strings.Contains(n.Value, "${") {
+			return
+		}
+		n.Value = expandEnv(n.Value)
+		if n.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			n.Tag = ""
+		}
+		return
+	}
+	for _, c := range n.Content {
+		expandEnvNode(c)
+	}
+}
+
+// envOverride 环境变量覆盖项：变量已设置（非空）时调用 apply 写入配置
+type envOverride struct {
+	env   string
+	apply func(cfg *FrameworkConfig, v string) error
+}
+
+// envOverrides 支持的环境变量覆盖，在 YAML 解析之后应用，优先于配置文件：
+//
+//	SCF_SYSTEM_NAME            system.name
+//	SCF_SYSTEM_VERSION         system.version
+//	SCF_SYSTEM_ENV             system.env
+//	SCF_HEARTBEAT_INTERVAL     heartbeat.interval
+//	SCF_HEARTBEAT_SERVER_IP    heartbeat.server_ip
+//	SCF_HEARTBEAT_SERVER_PORT  heartbeat.server_port
+//	SCF_HEARTBEAT_AUTH_TOKEN   heartbeat.auth_token
+//	SCF_STORAGE_URL            storage_url
+var envOverrides = []envOverride{
+	{"SCF_SYSTEM_NAME", func(c *FrameworkConfig, v string) error { c.System.Name = v; return nil }},
+	{"SCF_SYSTEM_VERSION", func(c *FrameworkConfig, v string) error { c.System.Version = v; return nil }},
+	{"SCF_SYSTEM_ENV", func(c *FrameworkConfig, v string) error { c.System.Env = v; return nil }},
+	{"SCF_HEARTBEAT_INTERVAL", func(c *FrameworkConfig, v string) error { return setInt(&c.Heartbeat.Interval, v) }},
+	{"SCF_HEARTBEAT_SERVER_IP", func(c *FrameworkConfig, v string) error { c.Heartbeat.ServerIP = v; return nil }},
+	{"SCF_HEARTBEAT_SERVER_PORT", func(c *FrameworkConfig, v string) error { return setInt(&c.Heartbeat.ServerPort, v) }},
+	{"SCF_HEARTBEAT_AUTH_TOKEN", func(c *FrameworkConfig, v string) error { c.Heartbeat.AuthToken = v; return nil }},
+	{"SCF_STORAGE_URL", func(c *FrameworkConfig, v string) error { c.StorageURL = v; return nil }},
+}
+
+// applyEnvOverrides 按 envOverrides 用环境变量覆盖配置值
+func applyEnvOverrides(cfg *FrameworkConfig) error {
+	for _, o := range envOverrides {
+		v := os.Getenv(o.env)
+		if v == "" {
+			continue
+		}
+		if err := o.apply(cfg, v); err != nil {
+			return fmt.Errorf("invalid value for env %s: %w", o.env, err)
+		}
+	}
+	return nil
+}
+
+// setInt 解析整数并写入 dst
+func setInt(dst *int, v string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return err
+	}
+	*dst = n
+	return nil
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvOverridesAndInterpolation(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		yaml    string
+		check   func(t *testing.T, cfg *FrameworkConfig)
+		wantErr string
+	}{
+		{
+			name: "storage url and heartbeat address overridden",
+			env: map[string]string{
+				"SCF_STORAGE_URL":           "http://xdata:8080",
+				"SCF_HEARTBEAT_SERVER_IP":   "10.0.0.2",
+				"SCF_HEARTBEAT_SERVER_PORT": " 9000 ",
+			},
+			yaml: validBase + "  server_ip: 127.0.0.1\n  server_port: 80\nstorage_url: http://local\n",
+			check: func(t *testing.T, cfg *FrameworkConfig) {
+				if cfg.StorageURL != "http://xdata:8080" || cfg.Heartbeat.ServerIP != "10.0.0.2" || cfg.Heartbeat.ServerPort != 9000 {
+					t.Fatalf("storage_url/server_ip/server_port = %q/%q/%d", cfg.StorageURL, cfg.Heartbeat.ServerIP, cfg.Heartbeat.ServerPort)
+				}
+			},
+		},
+		{
+			name: "system fields and interval overridden",
+			env:  map[string]string{"SCF_SYSTEM_NAME": "from-env", "SCF_SYSTEM_VERSION": "v9", "SCF_HEARTBEAT_INTERVAL": "30"},
+			yaml: validBase,
+			check: func(t *testing.T, cfg *FrameworkConfig) {
+				if cfg.System.Name != "from-env" || cfg.System.Version != "v9" || cfg.Heartbeat.Interval != 30 {
+					t.Fatalf("system.name/version/interval = %q/%q/%d", cfg.System.Name, cfg.System.Version, cfg.Heartbeat.Interval)
+				}
+			},
+		},
+		{
+			name: "empty env var does not override",
+			env:  map[string]string{"SCF_STORAGE_URL": ""},
+			yaml: validBase + "storage_url: http://local\n",
+			check: func(t *testing.T, cfg *FrameworkConfig) {
+				if cfg.StorageURL != "http://local" {
+					t.Fatalf("storage_url = %q, want http://local", cfg.StorageURL)
+				}
+			},
+		},
+		{
+			name:    "invalid integer override",
+			env:     map[string]string{"SCF_HEARTBEAT_SERVER_PORT": "http"},
+			yaml:    validBase,
+			wantErr: "invalid value for env SCF_HEARTBEAT_SERVER_PORT",
+		},
+		{
+			name: "interpolation with defaults",
+			env:  map[string]string{"TEST_NATS_HOST": "nats.internal", "TEST_PORT": "4222"},
+			yaml: validBase + `  server_port: ${TEST_PORT}
+storage_url: "http://${TEST_XDATA_HOST:-xdata.local}:8080"
+triggers:
+  - name: n
+    type: nats
+    settings:
+      url: "nats://${TEST_NATS_HOST}:${TEST_PORT}"
+      stream: "${TEST_UNSET_STREAM:-KLINE}"
+      subject: "kline${TEST_UNSET_SUFFIX}.>"
+`,
+			check: func(t *testing.T, cfg *FrameworkConfig) {
+				if cfg.Heartbeat.ServerPort != 4222 {
+					t.Fatalf("unquoted ${TEST_PORT} server_port = %d, want 4222", cfg.Heartbeat.ServerPort)
+				}
+				if cfg.StorageURL != "http://xdata.local:8080" {
+					t.Fatalf("storage_url = %q, want default applied", cfg.StorageURL)
+				}
+				s := cfg.Triggers[0].Settings
+				if s["url"] != "nats://nats.internal:4222" || s["stream"] != "KLINE" || s["subject"] != "kline.>" {
+					t.Fatalf("trigger settings = %v", s)
+				}
+			},
+		},
+		{
+			name: "interpolation inside plugin node",
+			env:  map[string]string{"TEST_API_KEY": "secret"},
+			yaml: validBase + "plugin:\n  api_key: ${TEST_API_KEY}\n",
+			check: func(t *testing.T, cfg *FrameworkConfig) {
+				var p struct {
+					APIKey string `yaml:"api_key"`
+				}
+				if err := cfg.Plugin.Decode(&p); err != nil || p.APIKey != "secret" {
+					t.Fatalf("plugin.api_key = %q, err = %v", p.APIKey, err)
+				}
+			},
+		},
+		{
+			name: "override wins over interpolation",
+			env:  map[string]string{"TEST_XDATA": "http://interpolated", "SCF_STORAGE_URL": "http://override"},
+			yaml: validBase + "storage_url: ${TEST_XDATA}\n",
+			check: func(t *testing.T, cfg *FrameworkConfig) {
+				if cfg.StorageURL != "http://override" {
+					t.Fatalf("storage_url = %q, want http://override", cfg.StorageURL)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, o := range envOverrides {
+				t.Setenv(o.env, "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := LoadFrameworkConfig(writeConfig(t, tt.yaml))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFrameworkConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFrameworkConfig() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
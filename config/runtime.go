@@ -1,28 +1,64 @@
 package config
 
 import (
+	"net"
 	"os"
+	"sort"
+	"strconv"
 	"sync"
+
+	"github.com/mooyang-code/scf-framework/model"
 )
 
+// reservedLabelKeys 框架保留的 metadata key，部署标签不可覆盖
+var reservedLabelKeys = map[string]struct{}{
+	"version":           {},
+	"go_version":        {},
+	"os":                {},
+	"arch":              {},
+	"framework_version": {},
+	"framework_commit":  {},
+	"region":            {},
+	"namespace":         {},
+	"capabilities":      {},
+}
+
 // RuntimeState 运行时状态管理
 type RuntimeState struct {
 	mu               sync.RWMutex
 	nodeID           string
 	version          string
-	mooxServerURL    string // Moox Server 网关地址（由探测报文下发）
-	storageServerURL string // xData 存储服务地址（由探测报文下发）
-	storageServerRPC string // xData 存储服务 RPC 地址（由探测报文下发，格式 ip://host:port）
+	mooxServerURL    string            // Moox Server 网关地址（由探测报文下发）
+	storageServerURL string            // xData 存储服务地址（由探测报文下发）
+	storageServerRPC string            // xData 存储服务 RPC 地址（由探测报文下发，格式 ip://host:port）
+	labels           map[string]string // 部署标签（由配置和选项注入）
+	initialized      bool              // plugin.Init 已完成，此前不上报心跳、探测返回 initializing
+	shuttingDown     bool              // 已开始退出，心跳与探测上报 stopping
+	region           string            // 部署地域（system.region 或 TENCENTCLOUD_REGION）
+	namespace        string            // SCF 命名空间（system.namespace 或 SCF_NAMESPACE）
+	capabilities     []string          // 配置的额外能力标签（不含插件名）
 }
 
 // NewRuntimeState 从配置初始化运行时状态
 func NewRuntimeState(cfg *FrameworkConfig) *RuntimeState {
-	return &RuntimeState{
-		version: cfg.System.Version,
+	rs := &RuntimeState{
+		nodeID:           cfg.System.NodeID,
+		version:          cfg.System.Version,
+		storageServerURL: cfg.StorageURL,
+		region:           cfg.System.Region,
+		namespace:        cfg.System.Namespace,
+		capabilities:     append([]string(nil), cfg.System.Capabilities...),
 	}
+	if cfg.Heartbeat.ServerIP != "" {
+		rs.mooxServerURL = cfg.Heartbeat.ServerIP
+		if cfg.Heartbeat.ServerPort > 0 {
+			rs.mooxServerURL = net.JoinHostPort(cfg.Heartbeat.ServerIP, strconv.Itoa(cfg.Heartbeat.ServerPort))
+		}
+	}
+	return rs
 }
 
-// InitNodeIDFromEnv 从 SCF 环境变量读取 NodeID
+// InitNodeIDFromEnv 从 SCF 环境变量读取 NodeID，环境变量存在时覆盖已有值（优先级：环境变量 > scf.WithNodeID > system.node_id）
 func (rs *RuntimeState) InitNodeIDFromEnv() {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
@@ -36,6 +72,62 @@ func (rs *RuntimeState) InitNodeIDFromEnv() {
 	}
 }
 
+// InitPlacementFromEnv 配置未指定 region/namespace 时从 SCF 环境变量读取
+// （TENCENTCLOUD_REGION / SCF_NAMESPACE）
+func (rs *RuntimeState) InitPlacementFromEnv() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.region == "" {
+		rs.region = os.Getenv("TENCENTCLOUD_REGION")
+	}
+	if rs.namespace == "" {
+		rs.namespace = os.Getenv("SCF_NAMESPACE")
+	}
+}
+
+// GetRegion 获取部署地域
+func (rs *RuntimeState) GetRegion() string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.region
+}
+
+// SetRegion 设置部署地域
+func (rs *RuntimeState) SetRegion(region string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.region = region
+}
+
+// GetNamespace 获取 SCF 命名空间
+func (rs *RuntimeState) GetNamespace() string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.namespace
+}
+
+// SetNamespace 设置 SCF 命名空间
+func (rs *RuntimeState) SetNamespace(namespace string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.namespace = namespace
+}
+
+// GetCapabilities 获取配置的额外能力标签副本
+func (rs *RuntimeState) GetCapabilities() []string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return append([]string(nil), rs.capabilities...)
+}
+
+// SetCapabilities 设置额外能力标签
+func (rs *RuntimeState) SetCapabilities(caps []string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.capabilities = append([]string(nil), caps...)
+}
+
 // GetNodeID 获取节点ID
 func (rs *RuntimeState) GetNodeID() string {
 	rs.mu.RLock()
@@ -50,6 +142,49 @@ func (rs *RuntimeState) SetNodeID(id string) {
 	rs.nodeID = id
 }
 
+// SetInitialized 标记插件初始化完成
+func (rs *RuntimeState) SetInitialized(v bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.initialized = v
+}
+
+// IsInitialized 返回插件是否已完成初始化
+func (rs *RuntimeState) IsInitialized() bool {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.initialized
+}
+
+// SetShuttingDown 标记节点开始退出
+func (rs *RuntimeState) SetShuttingDown(v bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.shuttingDown = v
+}
+
+// IsShuttingDown 返回节点是否正在退出
+func (rs *RuntimeState) IsShuttingDown() bool {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.shuttingDown
+}
+
+// State 返回节点生命周期状态：退出中为 model.NodeStateStopping，插件初始化完成前为 model.NodeStateInitializing，
+// 否则为 model.NodeStateRunning
+func (rs *RuntimeState) State() string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	switch {
+	case rs.shuttingDown:
+		return model.NodeStateStopping
+	case !rs.initialized:
+		return model.NodeStateInitializing
+	default:
+		return model.NodeStateRunning
+	}
+}
+
 // GetNodeInfo 获取节点信息
 func (rs *RuntimeState) GetNodeInfo() (nodeID, version string) {
 	rs.mu.RLock()
@@ -112,3 +247,34 @@ func (rs *RuntimeState) UpdateStorageServerRPC(rpcAddr string) {
 		rs.storageServerRPC = rpcAddr
 	}
 }
+
+// SetLabels 合并部署标签（同名 key 覆盖），返回因与保留 key 冲突而被忽略的 key
+func (rs *RuntimeState) SetLabels(labels map[string]string) (ignored []string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	for k, v := range labels {
+		if _, reserved := reservedLabelKeys[k]; reserved {
+			ignored = append(ignored, k)
+			continue
+		}
+		if rs.labels == nil {
+			rs.labels = make(map[string]string)
+		}
+		rs.labels[k] = v
+	}
+	sort.Strings(ignored)
+	return ignored
+}
+
+// GetLabels 获取部署标签副本
+func (rs *RuntimeState) GetLabels() map[string]string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	result := make(map[string]string, len(rs.labels))
+	for k, v := range rs.labels {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// StateStore 插件级 key/value 状态存储，值以 JSON 序列化保存。
+// 仅用于少量状态（游标、小型缓存等，建议总量在 MB 以内）：启用持久化时每次
+// Set/Delete 都会整体重写文件，不适合作为数据库使用。
+type StateStore struct {
+	mu   sync.RWMutex
+	data map[string]json.RawMessage
+	path string // 持久化文件路径，为空表示仅内存
+}
+
+// NewStateStore 创建状态存储；path 非空时从该文件加载已有状态并在变更时写回。
+// 与任务快照一致，状态文件只是缓存：文件不存在视为空状态，读取失败或内容损坏时告警并以空状态启动，
+// 损坏的文件被重命名为 <path>.corrupt-<时间戳> 保留现场，不阻止应用启动
+func NewStateStore(path string) *StateStore {
+	s := &StateStore{
+		data: make(map[string]json.RawMessage),
+		path: path,
+	}
+	if path == "" {
+		return s
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("[StateStore] failed to read state file %s, start empty: %v", path, err)
+		}
+		return s
+	}
+	if len(raw) == 0 {
+		return s
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		s.data = make(map[string]json.RawMessage)
+		aside := path + ".corrupt-" + time.Now().Format("20060102T150405")
+		if renameErr := os.Rename(path, aside); renameErr != nil {
+			log.Warnf("[StateStore] corrupted state file %s, start empty (rename aside failed: %v): %v", path, renameErr, err)
+		} else {
+			log.Warnf("[StateStore] corrupted state file %s moved to %s, start empty: %v", path, aside, err)
+		}
+		return s
+	}
+	log.Infof("[StateStore] restored %d keys from %s", len(s.data), path)
+	return s
+}
+
+// Get 将 key 对应的值反序列化到 v，key 不存在时返回 false
+func (s *StateStore) Get(key string, v interface{}) (bool, error) {
+	s.mu.RLock()
+	raw, ok := s.data[key]
+	s.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("failed to decode state %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// Set 以 JSON 序列化保存 v，启用持久化时同步写盘
+func (s *StateStore) Set(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode state %q: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = raw
+	return s.persistLocked()
+}
+
+// Delete 删除 key，启用持久化时同步写盘
+func (s *StateStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; !ok {
+		return nil
+	}
+	delete(s.data, key)
+	return s.persistLocked()
+}
+
+// Keys 返回当前所有 key
+func (s *StateStore) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// persistLocked 将全部状态原子写回文件；调用方需持有写锁
+func (s *StateStore) persistLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(s.data)
+	if err != nil {
+		return fmt.Errorf("failed to encode state file: %w", err)
+	}
+
+	return writeFileAtomic(s.path, raw)
+}
+
+// writeFileAtomic 先写同目录临时文件再 rename，避免进程中途退出留下半截文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", path, err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
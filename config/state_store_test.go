@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestStateStorePersistence(t *testing.T) {
+	type cursor struct {
+		Offset int `json:"offset"`
+	}
+	tests := []struct {
+		name        string
+		existing    *string // 启动前写入状态文件的内容，nil 表示文件不存在
+		wantKeys    []string
+		wantCorrupt bool // 期望原文件被重命名为 .corrupt-*
+	}{
+		{name: "missing file starts empty"},
+		{name: "empty file starts empty", existing: strPtr("")},
+		{name: "valid file restores keys", existing: strPtr(`{"cursor":{"offset":7},"mode":"\"fast\""}`), wantKeys: []string{"cursor", "mode"}},
+		{name: "corrupt file moved aside", existing: strPtr(`{"cursor":`), wantCorrupt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "state.json")
+			if tt.existing != nil {
+				if err := os.WriteFile(path, []byte(*tt.existing), 0o600); err != nil {
+					t.Fatalf("write state file: %v", err)
+				}
+			}
+
+			s := NewStateStore(path)
+			keys := s.Keys()
+			sort.Strings(keys)
+			if len(keys) != len(tt.wantKeys) || (len(keys) > 0 && !reflect.DeepEqual(keys, tt.wantKeys)) {
+				t.Fatalf("Keys() = %v, want %v", keys, tt.wantKeys)
+			}
+			aside, _ := filepath.Glob(path + ".corrupt-*")
+			if got := len(aside) == 1; got != tt.wantCorrupt {
+				t.Fatalf("corrupt files = %v, want moved aside %v", aside, tt.wantCorrupt)
+			}
+			if tt.wantCorrupt {
+				if raw, err := os.ReadFile(aside[0]); err != nil || string(raw) != *tt.existing {
+					t.Fatalf("corrupt file content = %q, %v, want original %q", raw, err, *tt.existing)
+				}
+			}
+
+			// 写入、删除后重新加载，状态与写入一致
+			if err := s.Set("cursor", cursor{Offset: 42}); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if err := s.Set("tmp", true); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if err := s.Delete("tmp"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			reloaded := NewStateStore(path)
+			var got cursor
+			if ok, err := reloaded.Get("cursor", &got); !ok || err != nil || got.Offset != 42 {
+				t.Fatalf("reloaded Get(cursor) = %+v, %v, %v, want offset 42", got, ok, err)
+			}
+			if ok, _ := reloaded.Get("tmp", new(bool)); ok {
+				t.Fatal("deleted key tmp restored after reload")
+			}
+		})
+	}
+}
+
+func TestStateStoreInMemory(t *testing.T) {
+	s := NewStateStore("")
+	if err := s.Set("k", 1); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	var v int
+	if ok, err := s.Get("k", &v); !ok || err != nil || v != 1 {
+		t.Fatalf("Get(k) = %d, %v, %v, want 1", v, ok, err)
+	}
+	if ok, err := s.Get("missing", &v); ok || err != nil {
+		t.Fatalf("Get(missing) = %v, %v, want not found", ok, err)
+	}
+}
+
+func strPtr(s string) *string { return &s }
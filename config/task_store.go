@@ -3,52 +3,276 @@ package config
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
 	"sort"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/mooyang-code/scf-framework/model"
 	cmap "github.com/orcaman/concurrent-map/v2"
+	"trpc.group/trpc-go/trpc-go/log"
 )
 
 // TaskInstanceStore 任务实例内存缓存
 type TaskInstanceStore struct {
-	store cmap.ConcurrentMap[string, *model.TaskInstance]
+	store cmap.ConcurrentMap[string, *model.TaskInstance] // 受 mu 保护，整体替换
 	md5   string
 	mu    sync.RWMutex
+
+	callbacks []TaskChangeFunc      // 受 mu 保护
+	source    []*model.TaskInstance // 服务端下发的原始列表（校验标记前），受 mu 保护，用于写快照
+
+	persistPath string     // 快照文件路径，为空表示仅内存
+	persistMu   sync.Mutex // 串行化快照写盘，保证文件内容为最后一次更新
+
+	validator     TaskParamsValidateFunc
+	invalidMu     sync.Mutex
+	invalidWarned map[string]string // 已告警的参数非法任务：TaskID -> TaskParams，参数不变时不重复告警
+}
+
+// TaskParamsValidateFunc 任务参数校验函数，返回错误表示 TaskParams 非法
+type TaskParamsValidateFunc func(task *model.TaskInstance) error
+
+// TaskStoreOption TaskInstanceStore 的选项函数
+type TaskStoreOption func(*TaskInstanceStore)
+
+// WithPersistPath 启用任务列表持久化：每次 UpdateTaskInstances 后将快照写入 path，
+// 创建时从该文件恢复上次的任务列表，使冷启动后无需等待首次心跳即可执行任务；空串表示不持久化
+func WithPersistPath(path string) TaskStoreOption {
+	return func(s *TaskInstanceStore) {
+		s.persistPath = path
+	}
+}
+
+// WithParamsValidator 设置任务参数校验：UpdateTaskInstances 时对每个有效任务调用 fn，
+// 校验失败的任务以 Invalid=1 的副本存储（不再进入调度），同一任务参数只告警一次；MD5 仍按原始列表计算
+func WithParamsValidator(fn TaskParamsValidateFunc) TaskStoreOption {
+	return func(s *TaskInstanceStore) {
+		s.validator = fn
+	}
+}
+
+// taskSnapshot 任务列表快照文件内容
+type taskSnapshot struct {
+	SavedAt time.Time             `json:"saved_at"`
+	Tasks   []*model.TaskInstance `json:"tasks"`
 }
 
-// NewTaskInstanceStore 创建新的任务实例存储
-func NewTaskInstanceStore() *TaskInstanceStore {
-	return &TaskInstanceStore{
+// TaskChangeFunc 任务集合变化回调：added 为新出现的任务，removed 为不再下发的任务（按 TaskID 比较）
+type TaskChangeFunc func(added, removed []*model.TaskInstance)
+
+// NewTaskInstanceStore 创建新的任务实例存储；启用持久化时从快照文件恢复任务列表，
+// 文件不存在、读取失败或内容损坏时告警并以空列表启动
+func NewTaskInstanceStore(opts ...TaskStoreOption) *TaskInstanceStore {
+	s := &TaskInstanceStore{
 		store: cmap.New[*model.TaskInstance](),
 		md5:   "empty",
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.loadSnapshot()
+	return s
 }
 
-// UpdateTaskInstances 清空并重新填充任务实例，计算 MD5
+// loadSnapshot 从快照文件恢复任务列表（此时尚无 OnChange 回调）
+func (s *TaskInstanceStore) loadSnapshot() {
+	if s.persistPath == "" {
+		return
+	}
+	raw, err := os.ReadFile(s.persistPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("[TaskStore] failed to read snapshot %s, start empty: %v", s.persistPath, err)
+		}
+		return
+	}
+	var snap taskSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		log.Warnf("[TaskStore] corrupted snapshot %s, start empty: %v", s.persistPath, err)
+		return
+	}
+	s.source = snap.Tasks
+	s.store = newTaskMap(s.validated(snap.Tasks))
+	s.md5 = calculateMD5(snap.Tasks)
+	log.Infof("[TaskStore] restored %d tasks from snapshot %s (saved at %s), MD5: %s",
+		s.store.Count(), s.persistPath, snap.SavedAt.Format(time.RFC3339), s.md5)
+}
+
+// saveSnapshot 将当前任务列表写入快照文件，失败仅告警（不影响内存中的任务列表）
+func (s *TaskInstanceStore) saveSnapshot() {
+	if s.persistPath == "" {
+		return
+	}
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+
+	s.mu.RLock()
+	tasks := s.source
+	s.mu.RUnlock()
+
+	raw, err := json.Marshal(taskSnapshot{SavedAt: time.Now(), Tasks: tasks})
+	if err != nil {
+		log.Warnf("[TaskStore] failed to encode snapshot: %v", err)
+		return
+	}
+	if err := writeFileAtomic(s.persistPath, raw); err != nil {
+		log.Warnf("[TaskStore] failed to save snapshot: %v", err)
+	}
+}
+
+// OnChange 注册任务集合变化回调。回调在 UpdateTaskInstances 的调用方 goroutine 中同步执行（不持有锁），
+// 应尽快返回；耗时操作请自行异步处理
+func (s *TaskInstanceStore) OnChange(fn TaskChangeFunc) {
+	if fn == nil {
+		return
+	}
+	s.mu.Lock()
+	s.callbacks = append(s.callbacks, fn)
+	s.mu.Unlock()
+}
+
+// UpdateTaskInstances 以新列表整体替换任务实例：先校验任务参数、在新 map 中构建内容并计算 MD5，
+// 再在写锁内同时替换 map 与 MD5，保证两者始终一致；启用持久化时写入快照，任务集合有增删时通知 OnChange 回调
 func (s *TaskInstanceStore) UpdateTaskInstances(tasks []*model.TaskInstance) {
+	source := tasks
+	tasks = s.validated(tasks)
+	next := newTaskMap(tasks)
+	nextMD5 := calculateMD5(source)
+
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	previous := s.store
+	s.store = next
+	s.md5 = nextMD5
+	s.source = source
+	callbacks := s.callbacks
+	s.mu.Unlock()
+
+	s.saveSnapshot()
+
+	if len(callbacks) == 0 {
+		return
+	}
+
+	var added, removed []*model.TaskInstance
+	for _, task := range tasks {
+		if task != nil && task.TaskID != "" && !previous.Has(task.TaskID) {
+			added = append(added, task)
+		}
+	}
+	eachTask(previous, func(task *model.TaskInstance) {
+		if !next.Has(task.TaskID) {
+			removed = append(removed, task)
+		}
+	})
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].TaskID < removed[j].TaskID })
+
+	for _, fn := range callbacks {
+		notifyChange(fn, added, removed)
+	}
+}
+
+// validated 校验任务参数（见 validate），并告警新出现或参数变化的非法任务
+func (s *TaskInstanceStore) validated(tasks []*model.TaskInstance) []*model.TaskInstance {
+	tasks, invalid := s.validate(tasks)
+	for _, iv := range invalid {
+		log.Warnf("[TaskStore] task %s has invalid task_params, marked invalid: %v", iv.taskID, iv.err)
+	}
+	return tasks
+}
+
+// invalidTask 需要告警的参数非法任务
+type invalidTask struct {
+	taskID string
+	err    error
+}
+
+// validate 对有效任务执行参数校验，返回的列表中校验失败的任务替换为 Invalid=1 的副本（不修改调用方的对象）；
+// invalid 为需要告警的任务：新出现或参数变化的非法任务只返回一次
+func (s *TaskInstanceStore) validate(tasks []*model.TaskInstance) (result []*model.TaskInstance, invalid []invalidTask) {
+	if s.validator == nil {
+		return tasks, nil
+	}
+
+	s.invalidMu.Lock()
+	defer s.invalidMu.Unlock()
+
+	warned := make(map[string]string)
+	result = make([]*model.TaskInstance, 0, len(tasks))
+	for _, task := range tasks {
+		if task == nil || task.Invalid != 0 {
+			result = append(result, task)
+			continue
+		}
+		err := s.validateTask(task)
+		if err == nil {
+			result = append(result, task)
+			continue
+		}
+		if params, ok := s.invalidWarned[task.TaskID]; !ok || params != task.TaskParams {
+			invalid = append(invalid, invalidTask{taskID: task.TaskID, err: err})
+		}
+		warned[task.TaskID] = task.TaskParams
+		marked := *task
+		marked.Invalid = 1
+		result = append(result, &marked)
+	}
+	s.invalidWarned = warned
+	return result, invalid
+}
 
-	s.store.Clear()
+// validateTask 调用单个任务的参数校验，校验函数 panic 视为校验失败
+func (s *TaskInstanceStore) validateTask(task *model.TaskInstance) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("validator panic: %v", r)
+		}
+	}()
+	return s.validator(task)
+}
+
+// newTaskMap 按 TaskID 构建任务 map，忽略 nil 与 TaskID 为空的任务
+func newTaskMap(tasks []*model.TaskInstance) cmap.ConcurrentMap[string, *model.TaskInstance] {
+	m := cmap.New[*model.TaskInstance]()
 	for _, task := range tasks {
 		if task != nil && task.TaskID != "" {
-			s.store.Set(task.TaskID, task)
+			m.Set(task.TaskID, task)
 		}
 	}
+	return m
+}
 
-	s.md5 = calculateMD5(tasks)
+// notifyChange 调用单个回调，回调 panic 不影响已提交的任务列表与其他回调
+func notifyChange(fn TaskChangeFunc, added, removed []*model.TaskInstance) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("[TaskStore] change callback panic: %v", r)
+		}
+	}()
+	fn(added, removed)
+}
+
+// current 返回当前的任务 map（UpdateTaskInstances 会整体替换）
+func (s *TaskInstanceStore) current() cmap.ConcurrentMap[string, *model.TaskInstance] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.store
 }
 
 // GetByNode 根据节点ID获取任务实例列表
 func (s *TaskInstanceStore) GetByNode(nodeID string) []*model.TaskInstance {
-	if nodeID == "" {
+	store := s.current()
+	if nodeID == "" || store.Count() == 0 {
 		return nil
 	}
 
 	var result []*model.TaskInstance
-	s.store.IterCb(func(_ string, task *model.TaskInstance) {
+	eachTask(store, func(task *model.TaskInstance) {
 		if task.NodeID == nodeID && task.Invalid == 0 {
 			result = append(result, task)
 		}
@@ -56,15 +280,58 @@ func (s *TaskInstanceStore) GetByNode(nodeID string) []*model.TaskInstance {
 	return result
 }
 
+// GetByRule 根据规则ID获取有效（Invalid==0）的任务实例列表
+func (s *TaskInstanceStore) GetByRule(ruleID string) []*model.TaskInstance {
+	store := s.current()
+	if ruleID == "" || store.Count() == 0 {
+		return nil
+	}
+
+	var result []*model.TaskInstance
+	eachTask(store, func(task *model.TaskInstance) {
+		if task.RuleID == ruleID && task.Invalid == 0 {
+			result = append(result, task)
+		}
+	})
+	return result
+}
+
+// GetByID 根据任务ID获取任务实例；按 ID 精确查找，不过滤 Invalid，调用方可自行判断
+func (s *TaskInstanceStore) GetByID(taskID string) (*model.TaskInstance, bool) {
+	if taskID == "" {
+		return nil, false
+	}
+	task, ok := s.current().Get(taskID)
+	if !ok || task == nil {
+		return nil, false
+	}
+	return task, true
+}
+
 // GetAll 获取所有任务实例
 func (s *TaskInstanceStore) GetAll() []*model.TaskInstance {
-	var result []*model.TaskInstance
-	s.store.IterCb(func(_ string, task *model.TaskInstance) {
+	store := s.current()
+	if store.Count() == 0 {
+		return nil
+	}
+
+	result := make([]*model.TaskInstance, 0, store.Count())
+	eachTask(store, func(task *model.TaskInstance) {
 		result = append(result, task)
 	})
 	return result
 }
 
+// eachTask 先复制 key 列表，再逐个 Get 取值并回调，回调期间不持有 shard 锁。
+// 相比 IterCb 以极小的一致性窗口（迭代中被删除的 key 会被跳过）换取不阻塞写入方。
+func eachTask(store cmap.ConcurrentMap[string, *model.TaskInstance], fn func(task *model.TaskInstance)) {
+	for _, key := range store.Keys() {
+		if task, ok := store.Get(key); ok && task != nil {
+			fn(task)
+		}
+	}
+}
+
 // GetCurrentMD5 获取当前任务列表的 MD5 值
 func (s *TaskInstanceStore) GetCurrentMD5() string {
 	s.mu.RLock()
@@ -72,25 +339,35 @@ func (s *TaskInstanceStore) GetCurrentMD5() string {
 	return s.md5
 }
 
-// calculateMD5 计算任务列表的 MD5 值
+// calculateMD5 计算任务列表的 MD5 值：按 TaskID 排序后对 TaskID、Invalid、TaskParams 一并哈希，
+// 任务参数或有效性变化都会改变 MD5；无有效任务时返回 "empty"
 func calculateMD5(tasks []*model.TaskInstance) string {
-	if len(tasks) == 0 {
+	// 快速路径：先计数有效任务，无有效任务时不分配切片
+	valid := 0
+	for _, task := range tasks {
+		if task != nil && task.Invalid == 0 {
+			valid++
+		}
+	}
+	if valid == 0 {
 		return "empty"
 	}
 
-	taskIDs := make([]string, 0, len(tasks))
+	type hashEntry struct {
+		TaskID     string `json:"task_id"`
+		Invalid    int    `json:"invalid"`
+		TaskParams string `json:"task_params"`
+	}
+	entries := make([]hashEntry, 0, len(tasks))
 	for _, task := range tasks {
-		if task.Invalid == 0 {
-			taskIDs = append(taskIDs, task.TaskID)
+		if task != nil {
+			entries = append(entries, hashEntry{TaskID: task.TaskID, Invalid: task.Invalid, TaskParams: task.TaskParams})
 		}
 	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].TaskID < entries[j].TaskID })
 
-	if len(taskIDs) == 0 {
-		return "empty"
-	}
-
-	sort.Strings(taskIDs)
-	combined := strings.Join(taskIDs, ",")
-	hash := md5.Sum([]byte(combined))
+	// JSON 编码保证字段边界无歧义（TaskParams 本身可能包含任意分隔符）
+	data, _ := json.Marshal(entries)
+	hash := md5.Sum(data)
 	return hex.EncodeToString(hash[:])
 }
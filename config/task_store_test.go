@@ -0,0 +1,519 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// makeTasks 生成 n 个任务，依次分配给 nodes 中的节点
+func makeTasks(n int, nodes ...string) []*model.TaskInstance {
+	tasks := make([]*model.TaskInstance, 0, n)
+	for i := 0; i < n; i++ {
+		tasks = append(tasks, &model.TaskInstance{
+			TaskID:     fmt.Sprintf("task-%05d", i),
+			RuleID:     fmt.Sprintf("rule-%d", i%10),
+			NodeID:     nodes[i%len(nodes)],
+			TaskParams: fmt.Sprintf(`{"i":%d}`, i),
+		})
+	}
+	return tasks
+}
+
+func TestEmptyStoreFastPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		tasks     []*model.TaskInstance
+		node      string
+		wantMD5   string
+		wantNode  int
+		wantTotal int
+	}{
+		{name: "nil list", tasks: nil, node: "n1", wantMD5: "empty"},
+		{name: "only nil tasks", tasks: []*model.TaskInstance{nil, nil}, node: "n1", wantMD5: "empty"},
+		{name: "only invalid tasks", tasks: []*model.TaskInstance{{TaskID: "a", NodeID: "n1", Invalid: 1}},
+			node: "n1", wantMD5: "empty", wantTotal: 1},
+		{name: "node owns nothing", tasks: makeTasks(5, "n2"), node: "n1", wantTotal: 5},
+		{name: "empty node id", tasks: makeTasks(5, "n1"), node: "", wantTotal: 5},
+		{name: "node owns all", tasks: makeTasks(5, "n1"), node: "n1", wantNode: 5, wantTotal: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewTaskInstanceStore()
+			s.UpdateTaskInstances(tt.tasks)
+
+			if tt.wantMD5 != "" && s.GetCurrentMD5() != tt.wantMD5 {
+				t.Fatalf("GetCurrentMD5() = %q, want %q", s.GetCurrentMD5(), tt.wantMD5)
+			}
+			if tt.wantMD5 == "" && s.GetCurrentMD5() == "empty" {
+				t.Fatalf("GetCurrentMD5() = empty for a non-empty task list")
+			}
+			if got := len(s.GetByNode(tt.node)); got != tt.wantNode {
+				t.Fatalf("len(GetByNode(%q)) = %d, want %d", tt.node, got, tt.wantNode)
+			}
+			if got := len(s.GetAll()); got != tt.wantTotal {
+				t.Fatalf("len(GetAll()) = %d, want %d", got, tt.wantTotal)
+			}
+		})
+	}
+}
+
+// BenchmarkGetByNode 对比空 store 的快速路径与 10k 任务 store 中不拥有任何任务的节点
+func BenchmarkGetByNode(b *testing.B) {
+	benchmarks := []struct {
+		name  string
+		tasks []*model.TaskInstance
+	}{
+		{name: "empty store", tasks: nil},
+		{name: "10k tasks, node owns zero", tasks: makeTasks(10000, "other-1", "other-2")},
+		{name: "10k tasks, node owns half", tasks: makeTasks(10000, "node-1", "other-1")},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			s := NewTaskInstanceStore()
+			s.UpdateTaskInstances(bm.tasks)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.GetByNode("node-1")
+			}
+		})
+	}
+}
+
+// BenchmarkCalculateMD5 空列表走快速路径不分配
+func BenchmarkCalculateMD5(b *testing.B) {
+	benchmarks := []struct {
+		name  string
+		tasks []*model.TaskInstance
+	}{
+		{name: "empty", tasks: nil},
+		{name: "10k all invalid", tasks: func() []*model.TaskInstance {
+			tasks := makeTasks(10000, "n1")
+			for _, task := range tasks {
+				task.Invalid = 1
+			}
+			return tasks
+		}()},
+		{name: "10k", tasks: makeTasks(10000, "n1")},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				calculateMD5(bm.tasks)
+			}
+		})
+	}
+}
+
+func TestEachTaskDoesNotHoldShardLocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []*model.TaskInstance
+	}{
+		{name: "empty", tasks: nil},
+		{name: "single", tasks: makeTasks(1, "n1")},
+		{name: "many", tasks: makeTasks(500, "n1", "n2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTaskMap(tt.tasks)
+			seen := 0
+			eachTask(store, func(task *model.TaskInstance) {
+				// 回调中写同一个 map：IterCb 持有 shard 读锁时会死锁
+				store.Set(task.TaskID, task)
+				seen++
+			})
+			if seen != len(tt.tasks) {
+				t.Fatalf("eachTask visited %d tasks, want %d", seen, len(tt.tasks))
+			}
+		})
+	}
+
+	// 迭代期间被删除的 key 被跳过
+	store := newTaskMap(makeTasks(10, "n1"))
+	seen := 0
+	eachTask(store, func(task *model.TaskInstance) {
+		if seen == 0 {
+			for _, key := range store.Keys() {
+				if key != task.TaskID {
+					store.Remove(key)
+				}
+			}
+		}
+		seen++
+	})
+	if seen != 1 {
+		t.Fatalf("eachTask visited %d tasks after concurrent removal, want 1", seen)
+	}
+}
+
+// BenchmarkGetAllConcurrentUpdate 在并发 UpdateTaskInstances 下读取全部任务
+func BenchmarkGetAllConcurrentUpdate(b *testing.B) {
+	s := NewTaskInstanceStore()
+	lists := [][]*model.TaskInstance{makeTasks(5000, "n1", "n2"), makeTasks(5000, "n2", "n1")}
+	s.UpdateTaskInstances(lists[0])
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				s.UpdateTaskInstances(lists[i%2])
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.GetAll()
+			s.GetByNode("n1")
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	wg.Wait()
+}
+
+// taskIDs 返回任务 ID 列表
+func taskIDs(tasks []*model.TaskInstance) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.TaskID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// tasksWithIDs 按 ID 构造任务
+func tasksWithIDs(ids ...string) []*model.TaskInstance {
+	tasks := make([]*model.TaskInstance, 0, len(ids))
+	for _, id := range ids {
+		tasks = append(tasks, &model.TaskInstance{TaskID: id, NodeID: "n1"})
+	}
+	return tasks
+}
+
+func TestOnChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		initial     []string
+		next        []string
+		wantCalled  bool
+		wantAdded   []string
+		wantRemoved []string
+	}{
+		{name: "add only", initial: []string{"a"}, next: []string{"a", "b", "c"}, wantCalled: true,
+			wantAdded: []string{"b", "c"}, wantRemoved: []string{}},
+		{name: "remove only", initial: []string{"a", "b", "c"}, next: []string{"b"}, wantCalled: true,
+			wantAdded: []string{}, wantRemoved: []string{"a", "c"}},
+		{name: "mixed", initial: []string{"a", "b"}, next: []string{"b", "c"}, wantCalled: true,
+			wantAdded: []string{"c"}, wantRemoved: []string{"a"}},
+		{name: "from empty", next: []string{"a"}, wantCalled: true,
+			wantAdded: []string{"a"}, wantRemoved: []string{}},
+		{name: "to empty", initial: []string{"a"}, wantCalled: true,
+			wantAdded: []string{}, wantRemoved: []string{"a"}},
+		{name: "unchanged set not notified", initial: []string{"a", "b"}, next: []string{"b", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewTaskInstanceStore()
+			s.UpdateTaskInstances(tasksWithIDs(tt.initial...))
+
+			var calls int
+			var added, removed []string
+			s.OnChange(func(a, r []*model.TaskInstance) {
+				calls++
+				added, removed = taskIDs(a), taskIDs(r)
+			})
+			second := 0
+			s.OnChange(func(a, r []*model.TaskInstance) { second++ })
+
+			s.UpdateTaskInstances(tasksWithIDs(tt.next...))
+			if (calls == 1) != tt.wantCalled || calls > 1 {
+				t.Fatalf("callback called %d times, wantCalled %v", calls, tt.wantCalled)
+			}
+			if second != calls {
+				t.Fatalf("second callback called %d times, want %d", second, calls)
+			}
+			if !tt.wantCalled {
+				return
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) || !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Fatalf("added/removed = %v/%v, want %v/%v", added, removed, tt.wantAdded, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestUpdateAtomicWithCallbackPanic(t *testing.T) {
+	tests := []struct {
+		name      string
+		callbacks []TaskChangeFunc
+		wantRuns  int // 不 panic 的回调执行次数
+	}{
+		{name: "single panicking callback", callbacks: []TaskChangeFunc{
+			func(a, r []*model.TaskInstance) { panic("boom") },
+		}},
+		{name: "panic does not skip later callbacks", callbacks: []TaskChangeFunc{
+			func(a, r []*model.TaskInstance) { panic("boom") },
+			nil, // 计数回调
+		}, wantRuns: 1},
+		{name: "panic with error value", callbacks: []TaskChangeFunc{
+			nil,
+			func(a, r []*model.TaskInstance) { panic(fmt.Errorf("wrapped")) },
+		}, wantRuns: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewTaskInstanceStore()
+			s.UpdateTaskInstances(tasksWithIDs("a", "b"))
+
+			runs := 0
+			for _, fn := range tt.callbacks {
+				if fn == nil {
+					fn = func(a, r []*model.TaskInstance) { runs++ }
+				}
+				s.OnChange(fn)
+			}
+
+			next := tasksWithIDs("b", "c")
+			s.UpdateTaskInstances(next)
+
+			if runs != tt.wantRuns {
+				t.Fatalf("non-panicking callbacks ran %d times, want %d", runs, tt.wantRuns)
+			}
+			if got, want := s.GetCurrentMD5(), calculateMD5(next); got != want {
+				t.Fatalf("GetCurrentMD5() = %s, want %s (md5 of the new list)", got, want)
+			}
+			if got := taskIDs(s.GetAll()); !reflect.DeepEqual(got, []string{"b", "c"}) {
+				t.Fatalf("stored tasks = %v, want [b c]", got)
+			}
+			// 之后的更新不受上一次 panic 影响
+			s.UpdateTaskInstances(nil)
+			if s.GetCurrentMD5() != "empty" || len(s.GetAll()) != 0 {
+				t.Fatalf("after clearing: md5 = %s, tasks = %d", s.GetCurrentMD5(), len(s.GetAll()))
+			}
+		})
+	}
+}
+
+func TestGetByRuleAndID(t *testing.T) {
+	tasks := []*model.TaskInstance{
+		{TaskID: "t1", RuleID: "r1", NodeID: "n1"},
+		{TaskID: "t2", RuleID: "r1", NodeID: "n2"},
+		{TaskID: "t3", RuleID: "r1", NodeID: "n1", Invalid: 1},
+		{TaskID: "t4", RuleID: "r2", NodeID: "n1"},
+	}
+	s := NewTaskInstanceStore()
+	s.UpdateTaskInstances(tasks)
+
+	rules := []struct {
+		name   string
+		ruleID string
+		want   []string
+	}{
+		{name: "tasks sharing a rule skip invalid", ruleID: "r1", want: []string{"t1", "t2"}},
+		{name: "single task", ruleID: "r2", want: []string{"t4"}},
+		{name: "unknown rule", ruleID: "r9", want: []string{}},
+		{name: "empty rule id", ruleID: "", want: []string{}},
+	}
+	for _, tt := range rules {
+		t.Run("GetByRule/"+tt.name, func(t *testing.T) {
+			if got := taskIDs(s.GetByRule(tt.ruleID)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("GetByRule(%q) = %v, want %v", tt.ruleID, got, tt.want)
+			}
+		})
+	}
+
+	ids := []struct {
+		name        string
+		taskID      string
+		wantOK      bool
+		wantInvalid int
+	}{
+		{name: "found", taskID: "t1", wantOK: true},
+		{name: "invalid task still returned", taskID: "t3", wantOK: true, wantInvalid: 1},
+		{name: "not found", taskID: "missing"},
+		{name: "empty id", taskID: ""},
+	}
+	for _, tt := range ids {
+		t.Run("GetByID/"+tt.name, func(t *testing.T) {
+			task, ok := s.GetByID(tt.taskID)
+			if ok != tt.wantOK {
+				t.Fatalf("GetByID(%q) ok = %v, want %v", tt.taskID, ok, tt.wantOK)
+			}
+			if !ok {
+				if task != nil {
+					t.Fatalf("GetByID(%q) = %+v, want nil", tt.taskID, task)
+				}
+				return
+			}
+			if task.TaskID != tt.taskID || task.Invalid != tt.wantInvalid {
+				t.Fatalf("GetByID(%q) = %+v", tt.taskID, task)
+			}
+		})
+	}
+
+	empty := NewTaskInstanceStore()
+	if got := empty.GetByRule("r1"); got != nil {
+		t.Fatalf("GetByRule on empty store = %v, want nil", got)
+	}
+	if _, ok := empty.GetByID("t1"); ok {
+		t.Fatalf("GetByID on empty store found a task")
+	}
+}
+
+func TestMD5ReflectsParamsAndValidity(t *testing.T) {
+	base := func() []*model.TaskInstance {
+		return []*model.TaskInstance{
+			{TaskID: "a", NodeID: "n1", TaskParams: `{"symbol":"BTC"}`},
+			{TaskID: "b", NodeID: "n1", TaskParams: `{"symbol":"ETH"}`},
+		}
+	}
+	tests := []struct {
+		name        string
+		mutate      func(tasks []*model.TaskInstance) []*model.TaskInstance
+		wantChanged bool
+	}{
+		{name: "identical list", mutate: func(ts []*model.TaskInstance) []*model.TaskInstance { return ts }},
+		{name: "reordered list", mutate: func(ts []*model.TaskInstance) []*model.TaskInstance {
+			return []*model.TaskInstance{ts[1], ts[0]}
+		}},
+		{name: "node change only", mutate: func(ts []*model.TaskInstance) []*model.TaskInstance {
+			ts[0].NodeID = "n2"
+			return ts
+		}},
+		{name: "task params changed", wantChanged: true, mutate: func(ts []*model.TaskInstance) []*model.TaskInstance {
+			ts[0].TaskParams = `{"symbol":"SOL"}`
+			return ts
+		}},
+		{name: "task marked invalid", wantChanged: true, mutate: func(ts []*model.TaskInstance) []*model.TaskInstance {
+			ts[1].Invalid = 1
+			return ts
+		}},
+		{name: "params shifted across field boundary", wantChanged: true, mutate: func(ts []*model.TaskInstance) []*model.TaskInstance {
+			ts[0].TaskID, ts[0].TaskParams = "a{", `"symbol":"BTC"}`
+			return ts
+		}},
+		{name: "task added", wantChanged: true, mutate: func(ts []*model.TaskInstance) []*model.TaskInstance {
+			return append(ts, &model.TaskInstance{TaskID: "c"})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewTaskInstanceStore()
+			s.UpdateTaskInstances(base())
+			before := s.GetCurrentMD5()
+
+			s.UpdateTaskInstances(tt.mutate(base()))
+			if changed := s.GetCurrentMD5() != before; changed != tt.wantChanged {
+				t.Fatalf("md5 changed = %v, want %v (%s -> %s)", changed, tt.wantChanged, before, s.GetCurrentMD5())
+			}
+		})
+	}
+}
+
+// symbolParams 校验测试使用的任务参数
+type symbolParams struct {
+	Symbol string `json:"symbol"`
+}
+
+// requireSymbol 以 DecodeParams 解析参数并要求 symbol 非空；symbol 为 "panic" 时模拟校验函数 panic
+func requireSymbol(task *model.TaskInstance) error {
+	var p symbolParams
+	if err := task.DecodeParams(&p); err != nil {
+		return err
+	}
+	switch p.Symbol {
+	case "":
+		return fmt.Errorf("symbol is required")
+	case "panic":
+		panic("validator bug")
+	}
+	return nil
+}
+
+func TestParamsValidator(t *testing.T) {
+	task := func(id, params string) *model.TaskInstance {
+		return &model.TaskInstance{TaskID: id, NodeID: "n1", TaskParams: params}
+	}
+	// 依次执行的任务列表更新，每步校验告警的任务与 GetByNode 结果
+	steps := []struct {
+		name       string
+		tasks      []*model.TaskInstance
+		wantWarned []string
+		wantValid  []string
+	}{
+		{
+			name:       "malformed and invalid params marked and warned",
+			tasks:      []*model.TaskInstance{task("a", `{"symbol":"BTC"}`), task("b", `{"symbol":`), task("c", `{}`)},
+			wantWarned: []string{"b", "c"},
+			wantValid:  []string{"a"},
+		},
+		{
+			name:      "unchanged params not warned again",
+			tasks:     []*model.TaskInstance{task("a", `{"symbol":"BTC"}`), task("b", `{"symbol":`), task("c", `{}`)},
+			wantValid: []string{"a"},
+		},
+		{
+			name:       "changed params warned again, fixed task becomes valid",
+			tasks:      []*model.TaskInstance{task("a", `{"symbol":"BTC"}`), task("b", `{"symbol":"ETH"}`), task("c", `[]`)},
+			wantWarned: []string{"c"},
+			wantValid:  []string{"a", "b"},
+		},
+		{
+			name:       "validator panic treated as invalid",
+			tasks:      []*model.TaskInstance{task("a", `{"symbol":"BTC"}`), task("d", `{"symbol":"panic"}`)},
+			wantWarned: []string{"d"},
+			wantValid:  []string{"a"},
+		},
+		{
+			name:       "task reappearing with same params warned again",
+			tasks:      []*model.TaskInstance{task("a", `{"symbol":"BTC"}`), task("c", `[]`)},
+			wantWarned: []string{"c"},
+			wantValid:  []string{"a"},
+		},
+	}
+
+	s := NewTaskInstanceStore(WithParamsValidator(requireSymbol))
+	for _, step := range steps {
+		// validate 的告警结果即 UpdateTaskInstances 记录的告警；重复校验相同列表不会再次告警
+		_, invalid := s.validate(step.tasks)
+		var warned []string
+		for _, iv := range invalid {
+			warned = append(warned, iv.taskID)
+		}
+		if !reflect.DeepEqual(warned, step.wantWarned) {
+			t.Fatalf("%s: warned = %v, want %v", step.name, warned, step.wantWarned)
+		}
+
+		s.UpdateTaskInstances(step.tasks)
+		if got := taskIDs(s.GetByNode("n1")); !reflect.DeepEqual(got, step.wantValid) {
+			t.Fatalf("%s: GetByNode = %v, want %v", step.name, got, step.wantValid)
+		}
+		for _, task := range step.tasks {
+			if task.Invalid != 0 {
+				t.Fatalf("%s: caller's task %s marked invalid in place", step.name, task.TaskID)
+			}
+			stored, ok := s.GetByID(task.TaskID)
+			wantInvalid := !slices.Contains(step.wantValid, task.TaskID)
+			if !ok || (stored.Invalid == 1) != wantInvalid {
+				t.Fatalf("%s: stored task %s = %+v, want Invalid=1 %v", step.name, task.TaskID, stored, wantInvalid)
+			}
+		}
+	}
+}
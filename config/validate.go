@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// ValidationError 配置语义校验失败，Problems 列出全部问题
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("invalid config: %s", strings.Join(e.Problems, "; "))
+	}
+	return fmt.Sprintf("invalid config file %s: %s", e.Path, strings.Join(e.Problems, "; "))
+}
+
+// Validate 校验必填字段与各类型触发器所需的 settings，一次性返回所有问题；
+// 未启用（enabled: false）的触发器只校验 name/type
+func (c *FrameworkConfig) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.System.Name == "" {
+		addf("system.name is required")
+	}
+	if c.Heartbeat.IsEnabled() && c.Heartbeat.Interval <= 0 {
+		addf("heartbeat.interval must be > 0, got %d", c.Heartbeat.Interval)
+	}
+	switch c.Heartbeat.TargetMode {
+	case "", "any", "all":
+	default:
+		addf("heartbeat.target_mode must be any or all, got %q", c.Heartbeat.TargetMode)
+	}
+	switch c.Heartbeat.OnVersionMismatch {
+	case "", "shutdown", "warn":
+	default:
+		addf("heartbeat.on_version_mismatch must be shutdown or warn, got %q", c.Heartbeat.OnVersionMismatch)
+	}
+	if slices.Contains(model.ReservedHeartbeatKeys, c.Heartbeat.ExtraNamespace) {
+		addf("heartbeat.extra_namespace %q is reserved by framework", c.Heartbeat.ExtraNamespace)
+	}
+	if c.Heartbeat.TaskBatchWaitMs < 0 {
+		addf("heartbeat.task_batch_wait_ms must be >= 0, got %d", c.Heartbeat.TaskBatchWaitMs)
+	}
+	if c.Heartbeat.TaskBatchSize < 0 {
+		addf("heartbeat.task_batch_size must be >= 0, got %d", c.Heartbeat.TaskBatchSize)
+	}
+	if c.Storage != nil {
+		switch c.Storage.WriteMode {
+		case "", "set_data", "upsert_object":
+		default:
+			addf("storage.write_mode must be set_data or upsert_object, got %q", c.Storage.WriteMode)
+		}
+	}
+
+	for i, t := range c.Triggers {
+		where := fmt.Sprintf("triggers[%d]", i)
+		if t.Name == "" {
+			addf("%s.name is required", where)
+		} else {
+			where = fmt.Sprintf("triggers[%d](%s)", i, t.Name)
+		}
+
+		required, known := triggerRequiredSettings[t.Type]
+		switch {
+		case t.Type == "":
+			addf("%s.type is required", where)
+			continue
+		case !known:
+			addf("%s.type %q is unknown", where, t.Type)
+			continue
+		case !t.IsEnabled():
+			continue
+		}
+		for _, key := range required {
+			if !hasSetting(t.Settings, key) {
+				addf("%s.settings.%s is required for %s trigger", where, key, t.Type)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// triggerRequiredSettings 各类型触发器必填的 settings
+var triggerRequiredSettings = map[string][]string{
+	"timer":     {"cron"},
+	"nats":      {"url", "stream", "subject"},
+	"kafka":     {"brokers", "topic", "group_id"},
+	"http":      {"path"},
+	"websocket": {"url"},
+}
+
+// hasSetting 判断 settings 中 key 存在且非空（字符串非空、列表非空）
+func hasSetting(s map[string]interface{}, key string) bool {
+	switch v := s[key].(type) {
+	case nil:
+		return false
+	case string:
+		return strings.TrimSpace(v) != ""
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig 将 YAML 写入临时配置文件并返回路径
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+// validBase 通过校验的最小配置，各用例在其后追加或替换字段
+const validBase = `
+system:
+  name: "collector"
+heartbeat:
+  interval: 10
+`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		yaml         string
+		wantProblems []string // 为空表示校验通过
+	}{
+		{name: "valid minimal", yaml: validBase},
+		{
+			name: "valid triggers",
+			yaml: validBase + `
+triggers:
+  - {name: t, type: timer, settings: {cron: "* * * * *"}}
+  - {name: n, type: nats, settings: {url: "nats://x:4222", stream: S, subject: s.>}}
+  - {name: k, type: kafka, settings: {brokers: ["b:9092"], topic: t, group_id: g}}
+  - {name: h, type: http, settings: {path: /hook}}
+  - {name: w, type: websocket, settings: {url: "wss://x"}}
+`,
+		},
+		{name: "missing system.name", yaml: "heartbeat:\n  interval: 10\n", wantProblems: []string{"system.name is required"}},
+		{name: "zero heartbeat interval", yaml: "system:\n  name: c\n", wantProblems: []string{"heartbeat.interval must be > 0"}},
+		{name: "heartbeat disabled skips interval", yaml: "system:\n  name: c\nheartbeat:\n  enabled: false\n"},
+		{name: "invalid target_mode", yaml: validBase + "  target_mode: most\n",
+			wantProblems: []string{`heartbeat.target_mode must be any or all, got "most"`}},
+		{name: "invalid on_version_mismatch", yaml: validBase + "  on_version_mismatch: exit\n",
+			wantProblems: []string{`heartbeat.on_version_mismatch must be shutdown or warn, got "exit"`}},
+		{name: "reserved extra_namespace", yaml: validBase + "  extra_namespace: metrics\n",
+			wantProblems: []string{`heartbeat.extra_namespace "metrics" is reserved`}},
+		{name: "negative task batch settings", yaml: validBase + "  task_batch_wait_ms: -1\n  task_batch_size: -2\n",
+			wantProblems: []string{"task_batch_wait_ms must be >= 0", "task_batch_size must be >= 0"}},
+		{name: "invalid storage write_mode", yaml: validBase + "storage:\n  write_mode: append\n",
+			wantProblems: []string{`storage.write_mode must be set_data or upsert_object, got "append"`}},
+		{name: "timer without cron", yaml: validBase + "triggers:\n  - {name: t, type: timer}\n",
+			wantProblems: []string{"triggers[0](t).settings.cron is required for timer trigger"}},
+		{name: "nats missing settings", yaml: validBase + "triggers:\n  - {name: n, type: nats, settings: {url: \" \"}}\n",
+			wantProblems: []string{"settings.url is required", "settings.stream is required", "settings.subject is required"}},
+		{name: "kafka with empty brokers", yaml: validBase + "triggers:\n  - {name: k, type: kafka, settings: {brokers: [], topic: t, group_id: g}}\n",
+			wantProblems: []string{"triggers[0](k).settings.brokers is required for kafka trigger"}},
+		{name: "trigger without name and type", yaml: validBase + "triggers:\n  - {settings: {}}\n",
+			wantProblems: []string{"triggers[0].name is required", "triggers[0].type is required"}},
+		{name: "unknown trigger type", yaml: validBase + "triggers:\n  - {name: x, type: grpc}\n",
+			wantProblems: []string{`triggers[0](x).type "grpc" is unknown`}},
+		{name: "disabled trigger skips settings", yaml: validBase + "triggers:\n  - {name: t, type: timer, enabled: false}\n"},
+		{
+			name: "all problems aggregated",
+			yaml: "triggers:\n  - {name: t, type: timer}\n  - {name: n, type: nats, settings: {url: nats://x}}\n",
+			wantProblems: []string{
+				"system.name is required",
+				"heartbeat.interval must be > 0",
+				"triggers[0](t).settings.cron",
+				"triggers[1](n).settings.stream",
+				"triggers[1](n).settings.subject",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.yaml)
+			cfg, err := LoadFrameworkConfig(path)
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Fatalf("LoadFrameworkConfig() error = %v", err)
+				}
+				if cfg == nil {
+					t.Fatalf("LoadFrameworkConfig() returned nil config")
+				}
+				return
+			}
+
+			var vErr *ValidationError
+			if !errors.As(err, &vErr) {
+				t.Fatalf("LoadFrameworkConfig() error = %v, want *ValidationError", err)
+			}
+			if vErr.Path != path {
+				t.Fatalf("ValidationError.Path = %q, want %q", vErr.Path, path)
+			}
+			if len(vErr.Problems) != len(tt.wantProblems) {
+				t.Fatalf("problems = %q, want %d problems", vErr.Problems, len(tt.wantProblems))
+			}
+			for _, want := range tt.wantProblems {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
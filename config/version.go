@@ -0,0 +1,55 @@
+package config
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// frameworkModulePath 框架模块路径，用于从 BuildInfo 中查找依赖版本
+const frameworkModulePath = "github.com/mooyang-code/scf-framework"
+
+// 构建时注入的框架版本信息，例如：
+// -ldflags "-X github.com/mooyang-code/scf-framework/config.FrameworkVersion=v1.2.3"
+var (
+	FrameworkVersion string
+	FrameworkCommit  string
+)
+
+var (
+	buildInfoOnce    sync.Once
+	frameworkVersion string
+	frameworkCommit  string
+)
+
+// GetFrameworkVersion 返回框架版本和提交号：优先使用构建时注入值，否则从 debug.ReadBuildInfo 读取
+func GetFrameworkVersion() (version, commit string) {
+	buildInfoOnce.Do(func() {
+		frameworkVersion, frameworkCommit = FrameworkVersion, FrameworkCommit
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if frameworkVersion == "" {
+			if info.Main.Path == frameworkModulePath {
+				frameworkVersion = info.Main.Version
+			}
+			for _, dep := range info.Deps {
+				if dep.Path == frameworkModulePath {
+					frameworkVersion = dep.Version
+					break
+				}
+			}
+		}
+		// 框架作为主模块构建时可读取 VCS 提交号
+		if frameworkCommit == "" && info.Main.Path == frameworkModulePath {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					frameworkCommit = setting.Value
+					break
+				}
+			}
+		}
+	})
+	return frameworkVersion, frameworkCommit
+}
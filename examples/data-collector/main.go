@@ -74,6 +74,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 
 	scf "github.com/mooyang-code/scf-framework"
 	"github.com/mooyang-code/scf-framework/model"
@@ -88,7 +89,8 @@ func main() {
 	)
 
 	if err := app.Run(context.Background()); err != nil {
-		log.Fatalf("data-collector exited: %v", err)
+		log.Printf("data-collector exited: %v", err)
+		os.Exit(scf.ExitCode(err))
 	}
 }
 
@@ -127,6 +129,18 @@ func (p *DataCollectorPlugin) HeartbeatExtra() map[string]interface{} {
 	}
 }
 
+// ValidateTaskParams 任务列表更新时校验参数，非法任务由框架标记为无效，不再进入调度
+func (p *DataCollectorPlugin) ValidateTaskParams(task *model.TaskInstance) error {
+	var params collectTaskParams
+	if err := task.DecodeParams(&params); err != nil {
+		return err
+	}
+	if params.Symbol == "" {
+		return fmt.Errorf("task %s: symbol is required", task.TaskID)
+	}
+	return nil
+}
+
 // ============================================================================
 // 业务逻辑
 // ============================================================================
@@ -160,17 +174,21 @@ func (p *DataCollectorPlugin) executeScheduledCollect(ctx context.Context, event
 
 	for _, job := range payload.Jobs {
 		var params collectTaskParams
-		if err := json.Unmarshal([]byte(job.Task.TaskParams), &params); err != nil {
+		if err := job.Task.DecodeParams(&params); err != nil {
 			fmt.Printf("[DataCollector] 解析任务参数失败: taskID=%s, err=%v\n", job.Task.TaskID, err)
+			p.fw.TaskReporter().ReportAsync(ctx, job.Task.TaskID, model.TaskStatusFailed,
+				fmt.Sprintf("invalid task params: %v", err))
 			continue
 		}
 
 		// 实际业务中：
 		// 1. 调用 Binance API 获取 K线数据
 		// 2. 写入 xData 存储: POST {storageURL}/xData/SetData
-		// 3. 上报任务执行状态
 		fmt.Printf("[DataCollector] 采集: source=%s, type=%s, symbol=%s, interval=%s\n",
 			params.DataSource, params.InstType, params.Symbol, job.Interval)
+
+		// 3. 上报任务执行状态（未获取到服务端地址时 TaskReporter 自动跳过）
+		p.fw.TaskReporter().ReportAsync(ctx, job.Task.TaskID, model.TaskStatusSuccess, "")
 	}
 
 	return nil
@@ -179,3 +197,4 @@ func (p *DataCollectorPlugin) executeScheduledCollect(ctx context.Context, event
 // 编译期检查接口实现
 var _ plugin.Plugin = (*DataCollectorPlugin)(nil)
 var _ plugin.HeartbeatContributor = (*DataCollectorPlugin)(nil)
+var _ plugin.TaskParamsValidator = (*DataCollectorPlugin)(nil)
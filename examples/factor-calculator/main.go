@@ -61,12 +61,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	scf "github.com/mooyang-code/scf-framework"
 	"github.com/mooyang-code/scf-framework/model"
 	"github.com/mooyang-code/scf-framework/plugin"
-	"gopkg.in/yaml.v3"
 )
 
 func main() {
@@ -87,7 +87,8 @@ func main() {
 	)
 
 	if err := app.Run(context.Background()); err != nil {
-		log.Fatalf("factor-calculator exited: %v", err)
+		log.Printf("factor-calculator exited: %v", err)
+		os.Exit(scf.ExitCode(err))
 	}
 }
 
@@ -107,12 +108,12 @@ func (p *FactorPlugin) Name() string { return "factor-calculator" }
 func (p *FactorPlugin) Init(_ context.Context, fw plugin.Framework) error {
 	p.fw = fw
 
-	// 从 framework config 的 plugin 节点解析业务配置
+	// 从 framework config 的插件配置节点解析业务配置（plugins.factor-calculator 或 plugin）
 	var pluginCfg struct {
 		EngineURL     string `yaml:"engine_url"`
 		EngineTimeout int    `yaml:"engine_timeout"`
 	}
-	if err := fw.Config().Plugin.Decode(&pluginCfg); err != nil && fw.Config().Plugin.Kind != 0 {
+	if err := fw.DecodePluginConfig(&pluginCfg); err != nil {
 		return fmt.Errorf("decode plugin config: %w", err)
 	}
 	if pluginCfg.EngineURL != "" {
@@ -190,9 +191,10 @@ func runWithNativePlugin() {
 		scf.WithGatewayService("trpc.factor.gateway.stdhttp"),
 	)
 	if err := app.Run(context.Background()); err != nil {
-		log.Fatalf("factor-calculator exited: %v", err)
+		log.Printf("factor-calculator exited: %v", err)
+		os.Exit(scf.ExitCode(err))
 	}
 }
 
-// 确保 yaml 包被使用（Plugin.Decode 需要）
-var _ = yaml.Node{}
+// 编译期检查接口实现
+var _ plugin.Plugin = (*FactorPlugin)(nil)
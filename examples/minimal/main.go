@@ -44,6 +44,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
 	scf "github.com/mooyang-code/scf-framework"
 	"github.com/mooyang-code/scf-framework/model"
@@ -58,7 +59,8 @@ func main() {
 	)
 
 	if err := app.Run(context.Background()); err != nil {
-		log.Fatalf("service exited: %v", err)
+		log.Printf("service exited: %v", err)
+		os.Exit(scf.ExitCode(err))
 	}
 }
 
@@ -89,3 +91,6 @@ func (p *MyPlugin) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*m
 
 	return nil, nil
 }
+
+// 编译期检查接口实现
+var _ plugin.Plugin = (*MyPlugin)(nil)
@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBodyReadTimeout 读取请求 body 的默认超时
+const DefaultBodyReadTimeout = 30 * time.Second
+
+// errBodyReadTimeout 读取请求 body 超时（慢速客户端）
+var errBodyReadTimeout = errors.New("request body read timeout")
+
+// readBody 在 timeout 内读完请求 body，<= 0 表示不限时。
+// 优先通过 http.ResponseController 设置连接读超时，底层不支持时退化为计时读取；超时返回 errBodyReadTimeout
+func readBody(w http.ResponseWriter, r *http.Request, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		return io.ReadAll(r.Body)
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Now().Add(timeout)); err == nil {
+		defer rc.SetReadDeadline(time.Time{})
+		body, err := io.ReadAll(r.Body)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil, errBodyReadTimeout
+		}
+		return body, err
+	}
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		body, err := io.ReadAll(r.Body)
+		done <- result{body, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.body, res.err
+	case <-timer.C:
+		// 关闭 body 使阻塞中的读取尽快返回
+		r.Body.Close()
+		return nil, errBodyReadTimeout
+	case <-r.Context().Done():
+		return nil, r.Context().Err()
+	}
+}
+
+// timedBody 流式读取请求 body 时施加读超时：优先设置连接读超时，不支持时每次 Read 前检查截止时间。
+// 读到 EOF 或出错时立即清除连接读超时，避免影响 net/http 随后用于检测客户端断开的后台读。
+// Transport 在独立 goroutine 中读取 body，状态字段使用原子变量
+type timedBody struct {
+	body     io.ReadCloser
+	rc       *http.ResponseController
+	deadline time.Time // 仅在连接不支持读超时时使用
+	cleared  atomic.Bool
+	timedOut atomic.Bool
+}
+
+// newTimedBody 包装 r.Body，timeout <= 0 时不限时
+func newTimedBody(w http.ResponseWriter, r *http.Request, timeout time.Duration) *timedBody {
+	b := &timedBody{body: r.Body}
+	if timeout <= 0 {
+		b.cleared.Store(true)
+		return b
+	}
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Now().Add(timeout)); err == nil {
+		b.rc = rc
+	} else {
+		b.deadline = time.Now().Add(timeout)
+	}
+	return b
+}
+
+// Read 实现 io.Reader，超时返回 errBodyReadTimeout
+func (b *timedBody) Read(p []byte) (int, error) {
+	if !b.deadline.IsZero() && !b.cleared.Load() && time.Now().After(b.deadline) {
+		b.timedOut.Store(true)
+		return 0, errBodyReadTimeout
+	}
+	n, err := b.body.Read(p)
+	if err != nil {
+		b.clear()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			b.timedOut.Store(true)
+			err = errBodyReadTimeout
+		}
+	}
+	return n, err
+}
+
+// Close 关闭 body 并清除读超时
+func (b *timedBody) Close() error {
+	b.clear()
+	return b.body.Close()
+}
+
+// clear 清除连接读超时（仅一次）
+func (b *timedBody) clear() {
+	if !b.cleared.CompareAndSwap(false, true) {
+		return
+	}
+	if b.rc != nil {
+		b.rc.SetReadDeadline(time.Time{})
+	}
+}
+
+// bodyReadStatus 将读取 body 的错误映射为状态码：超时 408，其他 400
+func bodyReadStatus(err error) int {
+	if errors.Is(err, errBodyReadTimeout) {
+		return http.StatusRequestTimeout
+	}
+	return http.StatusBadRequest
+}
@@ -2,51 +2,212 @@ package gateway
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/avast/retry-go"
+	"github.com/mooyang-code/scf-framework/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"trpc.group/trpc-go/trpc-go/log"
 )
 
 // Forwarder HTTP 请求转发器
 type Forwarder struct {
-	targetHost string
-	targetPort int
-	client     *http.Client
+	targetHost  string
+	targetPort  int
+	client      *http.Client
+	stripPrefix string // 转发前从路径中去除的前缀，如 "/calc"
+	tracer      *tracing.Tracer
+	readTimeout time.Duration // 读取请求 body 的超时，<= 0 不限时
+
+	timeout         time.Duration // 单次转发总超时（含读取响应 body），<= 0 不限时
+	maxIdlePerHost  int           // 每个后端保留的空闲连接数
+	responseTimeout time.Duration // 等待后端响应头的超时，<= 0 不限时
+
+	retryAttempts uint          // 幂等请求连接失败时的最多尝试次数，<= 1 不重试
+	retryDelay    time.Duration // 重试基础退避间隔（指数退避）
+}
+
+// 转发连接池默认参数
+const (
+	DefaultMaxIdleConnsPerHost   = 64
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultResponseHeaderTimeout = 30 * time.Second
+)
+
+// 幂等请求重试默认参数：后端重启（滚动更新）期间的连接失败最多尝试 3 次
+const (
+	DefaultRetryAttempts = 3
+	DefaultRetryDelay    = 200 * time.Millisecond
+)
+
+// sharedTransport 使用默认连接池参数的 Forwarder 共享同一个 Transport，复用到后端的长连接
+var sharedTransport = sync.OnceValue(func() *http.Transport {
+	return newTransport(DefaultMaxIdleConnsPerHost, DefaultResponseHeaderTimeout)
+})
+
+// newTransport 基于 http.DefaultTransport 创建连接池参数调优后的 Transport
+func newTransport(maxIdlePerHost int, responseTimeout time.Duration) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = 0 // 不限总数，由 MaxIdleConnsPerHost 约束
+	t.MaxIdleConnsPerHost = maxIdlePerHost
+	t.IdleConnTimeout = DefaultIdleConnTimeout
+	if responseTimeout > 0 {
+		t.ResponseHeaderTimeout = responseTimeout
+	}
+	return t
+}
+
+// ForwarderOption Forwarder 的选项函数
+type ForwarderOption func(*Forwarder)
+
+// WithStripPrefix 转发前去除路径前缀（如 "/calc/x?a=1" -> "/x?a=1"），保留 query
+func WithStripPrefix(prefix string) ForwarderOption {
+	return func(f *Forwarder) {
+		f.stripPrefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithTracer 为每次转发创建 client span，并以 traceparent 请求头传递给后端
+func WithTracer(t *tracing.Tracer) ForwarderOption {
+	return func(f *Forwarder) {
+		f.tracer = t
+	}
+}
+
+// WithBodyReadTimeout 设置读取请求 body 的超时（默认 DefaultBodyReadTimeout），超时返回 408；<= 0 不限时
+func WithBodyReadTimeout(d time.Duration) ForwarderOption {
+	return func(f *Forwarder) {
+		f.readTimeout = d
+	}
+}
+
+// WithTimeout 设置单次转发的总超时（含读取响应 body，默认不限时，避免截断流式响应）
+func WithTimeout(d time.Duration) ForwarderOption {
+	return func(f *Forwarder) {
+		f.timeout = d
+	}
+}
+
+// WithMaxIdleConnsPerHost 设置到后端的空闲连接数上限（默认 DefaultMaxIdleConnsPerHost），<= 0 保持默认
+func WithMaxIdleConnsPerHost(n int) ForwarderOption {
+	return func(f *Forwarder) {
+		if n > 0 {
+			f.maxIdlePerHost = n
+		}
+	}
+}
+
+// WithResponseHeaderTimeout 设置等待后端响应头的超时（默认 DefaultResponseHeaderTimeout），
+// 后端卡死时返回 502 而不是无限等待；<= 0 不限时
+func WithResponseHeaderTimeout(d time.Duration) ForwarderOption {
+	return func(f *Forwarder) {
+		f.responseTimeout = d
+	}
+}
+
+// WithRetry 设置幂等请求（GET/HEAD/OPTIONS 或携带 Idempotency-Key 头）在连接失败时的重试：
+// 最多尝试 attempts 次，以 delay 为基础指数退避（默认 DefaultRetryAttempts/DefaultRetryDelay）；
+// attempts <= 1 关闭重试。后端返回的任何状态码都不重试，非幂等请求从不重试
+func WithRetry(attempts uint, delay time.Duration) ForwarderOption {
+	return func(f *Forwarder) {
+		f.retryAttempts = attempts
+		f.retryDelay = delay
+	}
+}
+
+// NewForwarder 创建请求转发器。默认连接池参数的 Forwarder 共享同一个 Transport；
+// 通过 WithMaxIdleConnsPerHost/WithResponseHeaderTimeout 调整后使用独立的 Transport
+func NewForwarder(host string, port int, opts ...ForwarderOption) *Forwarder {
+	f := &Forwarder{
+		targetHost:      host,
+		targetPort:      port,
+		readTimeout:     DefaultBodyReadTimeout,
+		maxIdlePerHost:  DefaultMaxIdleConnsPerHost,
+		responseTimeout: DefaultResponseHeaderTimeout,
+		retryAttempts:   DefaultRetryAttempts,
+		retryDelay:      DefaultRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	transport := sharedTransport()
+	if f.maxIdlePerHost != DefaultMaxIdleConnsPerHost || f.responseTimeout != DefaultResponseHeaderTimeout {
+		transport = newTransport(f.maxIdlePerHost, f.responseTimeout)
+	}
+	f.client = &http.Client{Transport: transport, Timeout: f.timeout}
+	return f
 }
 
-// NewForwarder 创建请求转发器
-func NewForwarder(host string, port int) *Forwarder {
-	return &Forwarder{
-		targetHost: host,
-		targetPort: port,
-		client:     &http.Client{},
+// targetURI 计算转发的 RequestURI：按路径段边界去除 stripPrefix（"/calc" 不会匹配 "/calculator"）
+func (f *Forwarder) targetURI(u *url.URL) string {
+	if f.stripPrefix == "" {
+		return u.RequestURI()
 	}
+	p := u.Path
+	if p != f.stripPrefix && !strings.HasPrefix(p, f.stripPrefix+"/") {
+		return u.RequestURI()
+	}
+	p = strings.TrimPrefix(p, f.stripPrefix)
+	if p == "" {
+		p = "/"
+	}
+	return (&url.URL{Path: p, RawQuery: u.RawQuery}).RequestURI()
 }
 
 // ServeHTTP 实现 http.Handler 接口，转发请求到目标地址
 func (f *Forwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.ErrorContextf(ctx, "读取请求body失败: %v", err)
-		http.Error(w, "读取请求失败", http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
+	targetURL := fmt.Sprintf("http://%s:%d%s", f.targetHost, f.targetPort, f.targetURI(r.URL))
 
-	targetURL := fmt.Sprintf("http://%s:%d%s", f.targetHost, f.targetPort, r.URL.RequestURI())
+	ctx, span := f.tracer.Start(ctx, "forward "+r.Method, trace.SpanKindClient,
+		attribute.String("http.method", r.Method),
+		attribute.String("http.url", targetURL),
+	)
+	defer span.End()
 
 	log.InfoContextf(ctx, "转发请求: %s %s -> %s", r.Method, r.URL.RequestURI(), targetURL)
 
-	forwardReq, err := http.NewRequestWithContext(ctx, r.Method, targetURL, bytes.NewReader(body))
+	// 请求 body 默认直接流式转发给后端，不在内存中缓冲；保留原始 Content-Length（未知时以 chunked 发送）。
+	// 可重试的幂等请求需要重放 body，先完整读取
+	retryable := f.retryAttempts > 1 && isIdempotent(r)
+	var body io.Reader = http.NoBody
+	var timed *timedBody
+	switch {
+	case r.ContentLength == 0:
+	case retryable:
+		data, err := readBody(w, r, f.readTimeout)
+		if err != nil {
+			log.ErrorContextf(ctx, "读取请求body失败: %v", err)
+			http.Error(w, "读取请求失败", bodyReadStatus(err))
+			return
+		}
+		body = bytes.NewReader(data)
+	default:
+		timed = newTimedBody(w, r, f.readTimeout)
+		defer timed.Close()
+		body = timed
+	}
+
+	forwardReq, err := http.NewRequestWithContext(ctx, r.Method, targetURL, body)
 	if err != nil {
 		log.ErrorContextf(ctx, "创建转发请求失败: %v", err)
 		http.Error(w, "创建转发请求失败", http.StatusInternalServerError)
 		return
 	}
+	if timed != nil {
+		forwardReq.ContentLength = r.ContentLength
+	}
 
 	// 复制请求头（排除 Host、Content-Length 等特殊头）
 	for key, values := range r.Header {
@@ -58,23 +219,25 @@ func (f *Forwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	forwardReq.Header.Add("gateway-tag", "forward")
+	setForwardedHeaders(forwardReq.Header, r)
+	// 覆盖调用方的 traceparent，使后端成为转发 span 的子 span
+	tracing.Inject(ctx, forwardReq.Header)
 
-	resp, err := f.client.Do(forwardReq)
+	resp, err := f.do(ctx, forwardReq, retryable)
 	if err != nil {
+		if timed != nil && timed.timedOut.Load() {
+			log.ErrorContextf(ctx, "读取请求body超时: %v", err)
+			http.Error(w, "读取请求超时", http.StatusRequestTimeout)
+			return
+		}
 		log.ErrorContextf(ctx, "转发请求失败: %v", err)
 		http.Error(w, fmt.Sprintf("转发请求失败: %v", err), http.StatusBadGateway)
 		return
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.ErrorContextf(ctx, "读取响应body失败: %v", err)
-		http.Error(w, "读取响应失败", http.StatusInternalServerError)
-		return
-	}
-
-	log.InfoContextf(ctx, "收到后端响应: status=%d, body_size=%d", resp.StatusCode, len(respBody))
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	log.InfoContextf(ctx, "收到后端响应: status=%d", resp.StatusCode)
 
 	// 复制响应头
 	for key, values := range resp.Header {
@@ -82,7 +245,99 @@ func (f *Forwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			w.Header().Add(key, value)
 		}
 	}
-
 	w.WriteHeader(resp.StatusCode)
-	w.Write(respBody)
+
+	// 流式复制响应 body：客户端断开时 ctx 取消，后端请求随之中断，不再等待慢速后端
+	n, err := io.Copy(flushWriter{w}, resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			log.WarnContextf(ctx, "客户端已断开，取消转发: copied=%d, err=%v", n, ctx.Err())
+			return
+		}
+		log.ErrorContextf(ctx, "复制响应body失败: copied=%d, err=%v", n, err)
+		return
+	}
+	log.InfoContextf(ctx, "转发完成: body_size=%d", n)
+}
+
+// do 发送转发请求；retryable 时仅在连接层失败（未拿到后端响应）时按退避重试，每次重试重放 body
+func (f *Forwarder) do(ctx context.Context, req *http.Request, retryable bool) (*http.Response, error) {
+	if !retryable {
+		return f.client.Do(req)
+	}
+
+	var resp *http.Response
+	attempt := 0
+	err := retry.Do(
+		func() error {
+			attemptReq := req
+			if attempt++; attempt > 1 && req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return retry.Unrecoverable(err)
+				}
+				attemptReq = req.Clone(ctx)
+				attemptReq.Body = body
+			}
+			r, err := f.client.Do(attemptReq)
+			if err != nil {
+				return err
+			}
+			resp = r
+			return nil
+		},
+		retry.Attempts(f.retryAttempts),
+		retry.Delay(f.retryDelay),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.Context(ctx),
+		retry.OnRetry(func(n uint, err error) {
+			log.WarnContextf(ctx, "转发请求失败，准备重试: attempt=%d, err=%v", n+1, err)
+		}),
+	)
+	return resp, err
+}
+
+// isIdempotent 判断请求是否可安全重试：GET/HEAD/OPTIONS，或调用方携带了 Idempotency-Key
+func isIdempotent(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return r.Header.Get("Idempotency-Key") != ""
+}
+
+// setForwardedHeaders 设置标准代理头，使后端可获知原始客户端信息：
+// X-Forwarded-For 追加客户端 IP；X-Forwarded-Host/X-Forwarded-Proto 已由前置代理设置时保留，否则取本次请求的 Host 与协议
+func setForwardedHeaders(h http.Header, r *http.Request) {
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if prior := h.Values("X-Forwarded-For"); len(prior) > 0 {
+			ip = strings.Join(prior, ", ") + ", " + ip
+		}
+		h.Set("X-Forwarded-For", ip)
+	}
+	if h.Get("X-Forwarded-Host") == "" && r.Host != "" {
+		h.Set("X-Forwarded-Host", r.Host)
+	}
+	if h.Get("X-Forwarded-Proto") == "" {
+		proto := "http"
+		if r.TLS != nil {
+			proto = "https"
+		}
+		h.Set("X-Forwarded-Proto", proto)
+	}
+}
+
+// flushWriter 每次写入后 Flush，使流式响应及时送达客户端
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+// Write 写入并 Flush
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
 }
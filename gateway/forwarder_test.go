@@ -0,0 +1,348 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestForwarder 创建转发到 upstream 的 Forwarder 及其前置测试服务
+func newTestForwarder(t *testing.T, upstream *httptest.Server, opts ...ForwarderOption) *httptest.Server {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(strings.TrimPrefix(upstream.URL, "http://"))
+	if err != nil {
+		t.Fatalf("split upstream address: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	front := httptest.NewServer(NewForwarder(host, port, opts...))
+	t.Cleanup(front.Close)
+	return front
+}
+
+func TestForwarderClientCancel(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		headersSent bool // 后端在阻塞前已写出响应头与首段 body
+	}{
+		{name: "cancel while waiting for headers", method: http.MethodGet},
+		{name: "cancel mid-body", method: http.MethodGet, headersSent: true},
+		{name: "cancel streamed post mid-body", method: http.MethodPost, body: "payload", headersSent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			cancelled := make(chan struct{})
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(io.Discard, r.Body)
+				if tt.headersSent {
+					w.Write([]byte("first chunk"))
+					w.(http.Flusher).Flush()
+				}
+				close(started)
+				select {
+				case <-r.Context().Done():
+					close(cancelled)
+				case <-time.After(10 * time.Second):
+				}
+			}))
+			defer upstream.Close()
+			front := newTestForwarder(t, upstream)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, tt.method, front.URL+"/slow", strings.NewReader(tt.body))
+			errc := make(chan error, 1)
+			go func() {
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					errc <- err
+					return
+				}
+				defer resp.Body.Close()
+				buf := make([]byte, len("first chunk"))
+				_, err = io.ReadFull(resp.Body, buf)
+				errc <- err
+				// 保持连接直到取消，断开只由 ctx 取消触发
+				<-ctx.Done()
+			}()
+
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("upstream did not receive the forwarded request")
+			}
+			if tt.headersSent {
+				if err := <-errc; err != nil {
+					t.Fatalf("read first chunk: %v", err)
+				}
+			}
+			cancel()
+
+			select {
+			case <-cancelled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("client disconnect was not propagated to the upstream request")
+			}
+		})
+	}
+}
+
+func TestForwarderStreamsRequestBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentLength bool // 客户端是否声明 Content-Length
+	}{
+		{name: "content-length body keeps length", contentLength: true},
+		{name: "chunked body streamed through"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firstPart := make(chan string, 1)
+			type seen struct {
+				length  int64
+				chunked bool
+				body    string
+			}
+			got := make(chan seen, 1)
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				buf := make([]byte, len("part1"))
+				io.ReadFull(r.Body, buf)
+				firstPart <- string(buf)
+				rest, _ := io.ReadAll(r.Body)
+				got <- seen{length: r.ContentLength, chunked: len(r.TransferEncoding) > 0, body: string(buf) + string(rest)}
+			}))
+			defer upstream.Close()
+			front := newTestForwarder(t, upstream)
+
+			pr, pw := io.Pipe()
+			req, _ := http.NewRequest(http.MethodPost, front.URL+"/upload", pr)
+			if tt.contentLength {
+				req.ContentLength = int64(len("part1part2"))
+			}
+			respc := make(chan error, 1)
+			go func() {
+				resp, err := http.DefaultClient.Do(req)
+				if err == nil {
+					resp.Body.Close()
+				}
+				respc <- err
+			}()
+
+			// 第二段只在后端收到第一段后写出：若转发器先缓冲完整 body，这里会超时
+			pw.Write([]byte("part1"))
+			select {
+			case p := <-firstPart:
+				if p != "part1" {
+					t.Fatalf("first part = %q, want part1", p)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("upstream did not receive the first part before the body was complete")
+			}
+			pw.Write([]byte("part2"))
+			pw.Close()
+
+			if err := <-respc; err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			s := <-got
+			if s.body != "part1part2" {
+				t.Fatalf("upstream body = %q, want part1part2", s.body)
+			}
+			if tt.contentLength && (s.length != 10 || s.chunked) {
+				t.Fatalf("upstream content-length/chunked = %d/%v, want 10/false", s.length, s.chunked)
+			}
+			if !tt.contentLength && (s.length != -1 || !s.chunked) {
+				t.Fatalf("upstream content-length/chunked = %d/%v, want -1/true", s.length, s.chunked)
+			}
+		})
+	}
+}
+
+func TestForwarderStreamsChunkedResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		chunks      []string
+	}{
+		{name: "chunked response", contentType: "application/octet-stream", chunks: []string{"alpha", "beta", "gamma"}},
+		{name: "server-sent events", contentType: "text/event-stream", chunks: []string{"data: 1\n\n", "data: 2\n\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := make(chan struct{})
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				for i, c := range tt.chunks {
+					if i > 0 {
+						// 等客户端读到上一段再写下一段：若转发器缓冲完整响应，客户端永远读不到首段
+						select {
+						case <-next:
+						case <-time.After(5 * time.Second):
+							return
+						}
+					}
+					w.Write([]byte(c))
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer upstream.Close()
+			front := newTestForwarder(t, upstream)
+
+			resp, err := http.Get(front.URL + "/stream")
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.Header.Get("Content-Type") != tt.contentType || resp.ContentLength != -1 {
+				t.Fatalf("content-type/length = %q/%d, want %q/-1", resp.Header.Get("Content-Type"), resp.ContentLength, tt.contentType)
+			}
+			for i, c := range tt.chunks {
+				buf := make([]byte, len(c))
+				readc := make(chan error, 1)
+				go func() {
+					_, err := io.ReadFull(resp.Body, buf)
+					readc <- err
+				}()
+				select {
+				case err := <-readc:
+					if err != nil || string(buf) != c {
+						t.Fatalf("chunk %d = %q, err = %v, want %q", i, buf, err, c)
+					}
+				case <-time.After(3 * time.Second):
+					t.Fatalf("chunk %d was not delivered incrementally", i)
+				}
+				if i < len(tt.chunks)-1 {
+					next <- struct{}{}
+				}
+			}
+		})
+	}
+}
+
+func TestForwarderSetsForwardedHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    map[string]string
+		host      string
+		wantFor   string // 追加在客户端 IP 之前的部分
+		wantHost  string
+		wantProto string
+	}{
+		{name: "direct client", host: "api.example.com", wantHost: "api.example.com", wantProto: "http"},
+		{
+			name:      "behind another proxy",
+			header:    map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Forwarded-Host": "public.example.com", "X-Forwarded-Proto": "https"},
+			host:      "internal:8080",
+			wantFor:   "203.0.113.7, ",
+			wantHost:  "public.example.com",
+			wantProto: "https",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(chan http.Header, 1)
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got <- r.Header.Clone()
+			}))
+			defer upstream.Close()
+			front := newTestForwarder(t, upstream)
+
+			req, _ := http.NewRequest(http.MethodGet, front.URL+"/x", nil)
+			req.Host = tt.host
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			resp.Body.Close()
+
+			h := <-got
+			if want := tt.wantFor + "127.0.0.1"; h.Get("X-Forwarded-For") != want {
+				t.Fatalf("X-Forwarded-For = %q, want %q", h.Get("X-Forwarded-For"), want)
+			}
+			if h.Get("X-Forwarded-Host") != tt.wantHost || h.Get("X-Forwarded-Proto") != tt.wantProto {
+				t.Fatalf("X-Forwarded-Host/Proto = %q/%q, want %q/%q",
+					h.Get("X-Forwarded-Host"), h.Get("X-Forwarded-Proto"), tt.wantHost, tt.wantProto)
+			}
+			if h.Get("gateway-tag") != "forward" {
+				t.Fatalf("gateway-tag = %q, want forward", h.Get("gateway-tag"))
+			}
+		})
+	}
+}
+
+func TestForwarderRetriesIdempotentRequests(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		idempotency  string
+		body         string
+		opts         []ForwarderOption
+		failures     int // 后端前 failures 次请求直接断开连接，不返回响应
+		wantStatus   int
+		wantAttempts int
+	}{
+		{name: "GET retried after connection failure", method: http.MethodGet, failures: 2, wantStatus: http.StatusOK, wantAttempts: 3},
+		{name: "GET gives up after attempts", method: http.MethodGet, failures: 5, wantStatus: http.StatusBadGateway, wantAttempts: 3},
+		{name: "POST with Idempotency-Key replays body", method: http.MethodPost, idempotency: "order-1", body: `{"qty":1}`, failures: 1, wantStatus: http.StatusOK, wantAttempts: 2},
+		{name: "POST without Idempotency-Key never retried", method: http.MethodPost, body: `{"qty":1}`, failures: 1, wantStatus: http.StatusBadGateway, wantAttempts: 1},
+		{name: "retry disabled", method: http.MethodGet, opts: []ForwarderOption{WithRetry(1, 0)}, failures: 1, wantStatus: http.StatusBadGateway, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var bodies []string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				mu.Lock()
+				bodies = append(bodies, string(data))
+				n := len(bodies)
+				mu.Unlock()
+				if n <= tt.failures {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer upstream.Close()
+			opts := append([]ForwarderOption{WithRetry(3, time.Millisecond)}, tt.opts...)
+			front := newTestForwarder(t, upstream, opts...)
+
+			req, _ := http.NewRequest(tt.method, front.URL+"/orders", strings.NewReader(tt.body))
+			if tt.idempotency != "" {
+				req.Header.Set("Idempotency-Key", tt.idempotency)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(bodies) != tt.wantAttempts {
+				t.Fatalf("upstream attempts = %d, want %d", len(bodies), tt.wantAttempts)
+			}
+			for i, b := range bodies {
+				if b != tt.body {
+					t.Fatalf("attempt %d body = %q, want %q replayed", i+1, b, tt.body)
+				}
+			}
+		})
+	}
+}
@@ -1,13 +1,19 @@
 package gateway
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/mooyang-code/scf-framework/heartbeat"
+	"github.com/mooyang-code/scf-framework/metrics"
 	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	thttp "trpc.group/trpc-go/trpc-go/http"
 	"trpc.group/trpc-go/trpc-go/log"
 	"trpc.group/trpc-go/trpc-go/server"
@@ -15,9 +21,15 @@ import (
 
 // Gateway HTTP 网关
 type Gateway struct {
-	mux          *http.ServeMux
-	probeHandler *heartbeat.ProbeHandler
+	mux           *http.ServeMux
+	probeHandler  *heartbeat.ProbeHandler
 	pluginHandler http.Handler
+	tracer        *tracing.Tracer
+	metrics       *metrics.Metrics
+	accessLog     bool                            // 逐请求记录 method/path/status/bytes/耗时
+	readTimeout   time.Duration                   // 读取请求 body 的超时，<= 0 不限时
+	healthCheck   func(ctx context.Context) error // /health 就绪检查，nil 时始终健康
+	readyCheck    func(ctx context.Context) error // /ready 就绪检查，nil 时始终就绪
 }
 
 // NewGateway 创建 HTTP Gateway
@@ -25,6 +37,8 @@ func NewGateway(probeHandler *heartbeat.ProbeHandler) *Gateway {
 	g := &Gateway{
 		mux:          http.NewServeMux(),
 		probeHandler: probeHandler,
+		accessLog:    true,
+		readTimeout:  DefaultBodyReadTimeout,
 	}
 	g.registerRoutes()
 	return g
@@ -33,6 +47,7 @@ func NewGateway(probeHandler *heartbeat.ProbeHandler) *Gateway {
 // registerRoutes 注册内置路由
 func (g *Gateway) registerRoutes() {
 	g.mux.HandleFunc("GET /health", g.handleHealth)
+	g.mux.HandleFunc("GET /ready", g.handleReady)
 	g.mux.HandleFunc("POST /probe", g.handleProbe)
 	// catch-all 转发（必须放最后）
 	g.mux.HandleFunc("/", g.handleCatchAll)
@@ -43,26 +58,170 @@ func (g *Gateway) SetPluginHandler(h http.Handler) {
 	g.pluginHandler = h
 }
 
+// AddRoute 将 prefix 下的请求交给 h 处理（优先于 catch-all），如挂载多个后端：
+//
+//	g.AddRoute("/calc", gateway.NewForwarder(host, port, gateway.WithStripPrefix("/calc")))
+func (g *Gateway) AddRoute(prefix string, h http.Handler) {
+	prefix = "/" + strings.Trim(prefix, "/")
+	g.mux.Handle(prefix, h)
+	g.mux.Handle(prefix+"/", h)
+}
+
+// Handle 注册自定义路由（如 HTTP 触发器），pattern 语法同 http.ServeMux
+func (g *Gateway) Handle(pattern string, h http.Handler) {
+	g.mux.Handle(pattern, h)
+}
+
+// SetMetricsHandler 注册 GET /metrics 指标路由（Prometheus 抓取）
+func (g *Gateway) SetMetricsHandler(h http.Handler) {
+	g.mux.Handle("GET /metrics", h)
+}
+
+// SetTracer 设置链路追踪：每个请求创建 server span，并延续调用方 traceparent 请求头中的链路
+func (g *Gateway) SetTracer(t *tracing.Tracer) {
+	g.tracer = t
+}
+
+// SetMetrics 设置请求数与耗时的指标收集（scf_gateway_requests_total 等）
+func (g *Gateway) SetMetrics(m *metrics.Metrics) {
+	g.metrics = m
+}
+
+// SetAccessLog 开关逐请求的访问日志（默认开启），高 QPS 场景可关闭；指标不受影响
+func (g *Gateway) SetAccessLog(enabled bool) {
+	g.accessLog = enabled
+}
+
+// SetHealthCheck 设置 /health 的就绪检查，返回错误时 /health 响应 503 及原因
+func (g *Gateway) SetHealthCheck(fn func(ctx context.Context) error) {
+	g.healthCheck = fn
+}
+
+// SetReadyCheck 设置 /ready 的就绪检查，返回错误时 /ready 响应 503 及原因
+func (g *Gateway) SetReadyCheck(fn func(ctx context.Context) error) {
+	g.readyCheck = fn
+}
+
+// SetBodyReadTimeout 设置内置路由读取请求 body 的超时（默认 DefaultBodyReadTimeout），超时返回 408；<= 0 不限时
+func (g *Gateway) SetBodyReadTimeout(d time.Duration) {
+	g.readTimeout = d
+}
+
 // Register 注册到 TRPC Server 的指定 service
 func (g *Gateway) Register(svc server.Service) {
-	thttp.RegisterNoProtocolServiceMux(svc, g.mux)
+	thttp.RegisterNoProtocolServiceMux(svc, http.HandlerFunc(g.serveTraced))
 }
 
-// handleHealth 健康检查
-func (g *Gateway) handleHealth(w http.ResponseWriter, _ *http.Request) {
+// serveTraced 提取 traceparent 并创建 server span 后交给 mux 处理
+func (g *Gateway) serveTraced(w http.ResponseWriter, r *http.Request) {
+	ctx := tracing.Extract(r.Context(), r.Header)
+	ctx, span := g.tracer.Start(ctx, r.Method, trace.SpanKindServer,
+		attribute.String("http.method", r.Method),
+		attribute.String("http.target", r.URL.RequestURI()),
+	)
+	defer span.End()
+	g.serveLogged(w, r.WithContext(ctx))
+}
+
+// serveLogged 记录请求的状态码、响应大小与耗时（访问日志 + 指标）后交给 mux 处理
+func (g *Gateway) serveLogged(w http.ResponseWriter, r *http.Request) {
+	if !g.accessLog && g.metrics == nil {
+		g.mux.ServeHTTP(w, r)
+		return
+	}
+
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	g.mux.ServeHTTP(rec, r)
+	cost := time.Since(start)
+
+	g.metrics.ObserveHTTPRequest(r.Method, rec.status, cost)
+	if g.accessLog {
+		log.InfoContextf(r.Context(), "请求完成: method=%s, path=%s, status=%d, bytes=%d, duration=%v",
+			r.Method, r.URL.Path, rec.status, rec.bytes, cost)
+	}
+}
+
+// responseRecorder 记录状态码与写出字节数，保留 Flush 能力供流式转发使用
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// WriteHeader 记录状态码
+func (r *responseRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write 累计写出字节数
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush 透传到底层 ResponseWriter
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// handleHealth 健康检查：就绪检查失败时返回 503 及原因，供 k8s/SCF 探针摘除实例
+func (g *Gateway) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if g.healthCheck != nil {
+		if err := g.healthCheck(r.Context()); err != nil {
+			log.WarnContextf(r.Context(), "健康检查未通过: %v", err)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"status": "unhealthy",
+				"reason": err.Error(),
+			})
+			return
+		}
+	}
 	writeJSON(w, http.StatusOK, map[string]string{
 		"status": "healthy",
 	})
 }
 
-// handleProbe 探测请求处理
+// handleReady 就绪检查：启动完成前、停止中或触发器长时间断连时返回 503 及原因
+func (g *Gateway) handleReady(w http.ResponseWriter, r *http.Request) {
+	if g.readyCheck != nil {
+		if err := g.readyCheck(r.Context()); err != nil {
+			log.WarnContextf(r.Context(), "就绪检查未通过: %v", err)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"status": "not_ready",
+				"reason": err.Error(),
+			})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "ready",
+	})
+}
+
+// handleProbe 探测请求处理。状态码：200 处理成功（Success=true）；400 请求体无法读取或解析；408 读取请求体超时；
+// 500 处理失败（Success=false，Message 为原因）
 func (g *Gateway) handleProbe(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	body, err := io.ReadAll(r.Body)
+	body, err := readBody(w, r, g.readTimeout)
 	if err != nil {
 		log.ErrorContextf(ctx, "读取探测请求body失败: %v", err)
-		writeJSON(w, http.StatusBadRequest, &model.Response{
+		writeJSON(w, bodyReadStatus(err), &model.Response{
 			Success: false,
 			Message: fmt.Sprintf("读取请求失败: %v", err),
 		})
@@ -93,7 +252,13 @@ func (g *Gateway) handleProbe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	// 已处理但失败（如 NodeID 尚未就绪）时返回 500，body 仍为结构化响应
+	status := http.StatusOK
+	if resp == nil || !resp.Success {
+		status = http.StatusInternalServerError
+		log.WarnContextf(ctx, "探测处理未成功: %+v", resp)
+	}
+	writeJSON(w, status, resp)
 }
 
 // handleCatchAll 转发到插件处理器或返回 404
@@ -0,0 +1,103 @@
+package scf
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/plugin"
+)
+
+// heartbeatUnhealthyThreshold 心跳连续失败达到该次数视为不健康
+const heartbeatUnhealthyThreshold = 3
+
+// readinessCheckTimeout Gateway /health 检查插件就绪的超时
+const readinessCheckTimeout = 3 * time.Second
+
+// Health 汇总就绪、插件、触发器、心跳的健康状态，供嵌入方通过自有机制暴露。
+// ok 与 Gateway /ready 一致（同一 readiness 判定：启动完成未在停止中、plugin.Init 完成且插件健康、
+// 非定时器触发器在宽限期内连接正常），另外心跳连续失败达到阈值时也视为不健康；report 中按组件给出详情。
+func (a *App) Health(ctx context.Context) (bool, map[string]interface{}) {
+	report := make(map[string]interface{})
+
+	a.mu.RLock()
+	runtime, triggerMgr, hbReporter := a.runtime, a.triggerMgr, a.hbReporter
+	a.mu.RUnlock()
+	if runtime == nil {
+		report["app"] = "not started"
+		return false, report
+	}
+
+	ok := true
+	readyStatus := "ok"
+	if err := a.readiness(ctx); err != nil {
+		ok = false
+		readyStatus = err.Error()
+	}
+	report["ready"] = readyStatus
+
+	// 插件健康（与 Gateway /health 相同：Init 完成且 HealthChecker 无错误）
+	pluginStatus := "ok"
+	if err := a.pluginReady(ctx); err != nil {
+		ok = false
+		pluginStatus = err.Error()
+	}
+	report["plugin"] = pluginStatus
+
+	// 触发器连接详情（是否健康以 readiness 中带宽限期的 CheckReady 为准，此处仅展示即时状态）
+	if triggerMgr != nil {
+		triggers := make(map[string]string)
+		for name, err := range triggerMgr.CheckHealth(ctx) {
+			if err != nil {
+				triggers[name] = err.Error()
+				continue
+			}
+			triggers[name] = "ok"
+		}
+		report["triggers"] = triggers
+	}
+
+	// 心跳状态
+	if hbReporter != nil {
+		status := hbReporter.Status()
+		if status.ConsecutiveErrors >= heartbeatUnhealthyThreshold {
+			ok = false
+		}
+		report["heartbeat"] = status
+	}
+
+	return ok, report
+}
+
+// readiness 供 Gateway /ready 使用：配置加载、plugin.Init 与触发器启动均完成且未在停止中，
+// 且非定时器触发器（如 NATS）连接正常（短暂断连在宽限期内不算）
+func (a *App) readiness(ctx context.Context) error {
+	if !a.ready.Load() {
+		return errors.New("app is starting or shutting down")
+	}
+	a.mu.RLock()
+	triggerMgr := a.triggerMgr
+	a.mu.RUnlock()
+	if triggerMgr == nil {
+		return nil
+	}
+	return triggerMgr.CheckReady(ctx)
+}
+
+// pluginReady 供 Gateway /health 使用：plugin.Init 未完成或插件 HealthChecker 报错时返回原因
+// （HTTPPluginAdapter 即探测外部引擎的 /health）。不包含触发器与心跳，避免控制面故障导致实例被重启
+func (a *App) pluginReady(ctx context.Context) error {
+	a.mu.RLock()
+	runtime := a.runtime
+	a.mu.RUnlock()
+	if runtime == nil || !runtime.IsInitialized() {
+		return errors.New("plugin is initializing")
+	}
+	hc, ok := plugin.Lookup[plugin.HealthChecker](a.plugin)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+	return hc.CheckHealth(ctx)
+}
@@ -0,0 +1,77 @@
+package scf
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/plugin"
+)
+
+// testPlugin 测试用插件，healthErr 非 nil 时实现的 CheckHealth 返回该错误
+type testPlugin struct {
+	healthErr error
+}
+
+func (p *testPlugin) Name() string                                        { return "test" }
+func (p *testPlugin) Init(ctx context.Context, fw plugin.Framework) error { return nil }
+func (p *testPlugin) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error) {
+	return nil, nil
+}
+func (p *testPlugin) CheckHealth(ctx context.Context) error { return p.healthErr }
+
+func TestAppHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		started     bool
+		initialized bool
+		ready       bool
+		healthErr   error
+		wantOK      bool
+		wantReady   string
+		wantPlugin  string
+	}{
+		{name: "not started", wantOK: false},
+		{name: "initializing", started: true, wantOK: false,
+			wantReady: "app is starting or shutting down", wantPlugin: "plugin is initializing"},
+		{name: "initialized but triggers not started", started: true, initialized: true, wantOK: false,
+			wantReady: "app is starting or shutting down", wantPlugin: "ok"},
+		{name: "ready", started: true, initialized: true, ready: true, wantOK: true,
+			wantReady: "ok", wantPlugin: "ok"},
+		{name: "ready but plugin unhealthy", started: true, initialized: true, ready: true,
+			healthErr: errors.New("engine down"), wantOK: false, wantReady: "ok", wantPlugin: "engine down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(&testPlugin{healthErr: tt.healthErr})
+			if tt.started {
+				a.runtime = config.NewRuntimeState(&config.FrameworkConfig{})
+				a.runtime.SetInitialized(tt.initialized)
+			}
+			a.ready.Store(tt.ready)
+
+			ok, report := a.Health(context.Background())
+			if ok != tt.wantOK {
+				t.Fatalf("Health() ok = %v, want %v, report = %v", ok, tt.wantOK, report)
+			}
+			if !tt.started {
+				if report["app"] != "not started" {
+					t.Fatalf("report[app] = %v, want not started", report["app"])
+				}
+				return
+			}
+			if report["ready"] != tt.wantReady {
+				t.Fatalf("report[ready] = %v, want %q", report["ready"], tt.wantReady)
+			}
+			if report["plugin"] != tt.wantPlugin {
+				t.Fatalf("report[plugin] = %v, want %q", report["plugin"], tt.wantPlugin)
+			}
+			// 与 /ready 使用同一判定
+			if readyErr := a.readiness(context.Background()); (readyErr == nil) != (tt.wantReady == "ok") {
+				t.Fatalf("readiness() = %v, inconsistent with report[ready] = %v", readyErr, report["ready"])
+			}
+		})
+	}
+}
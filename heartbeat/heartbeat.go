@@ -1,20 +1,21 @@
 package heartbeat
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"runtime"
+	"slices"
+	"sync"
 	"time"
 
-	"github.com/avast/retry-go"
 	"github.com/mooyang-code/scf-framework/config"
 	"github.com/mooyang-code/scf-framework/dnsproxy"
+	"github.com/mooyang-code/scf-framework/metrics"
 	"github.com/mooyang-code/scf-framework/model"
 	"github.com/mooyang-code/scf-framework/plugin"
+	"github.com/mooyang-code/scf-framework/reporter"
 	"trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/log"
 )
@@ -24,19 +25,160 @@ type Reporter struct {
 	runtime     *config.RuntimeState
 	taskStore   *config.TaskInstanceStore
 	plugin      plugin.Plugin
-	client      *http.Client
+	transport   Transport
 	dnsResolver *dnsproxy.Resolver
+
+	statusMu          sync.RWMutex
+	status            Status
+	payloadWarnBytes  int           // 心跳负载超过该大小时告警
+	timeout           time.Duration // 心跳请求超时
+	clientOpts        []reporter.ClientOption
+	attempts          uint          // 心跳请求最大尝试次数
+	baseDelay         time.Duration // 重试退避基础间隔
+	reportPath        string        // 心跳上报接口路径
+	extraTargets      []string      // 额外的心跳目标（控制面迁移期间双报）
+	requireAllTargets bool          // true 时所有目标都成功才算成功
+
+	onVersionMismatch func(ctx context.Context, local, remote string) // 版本不一致处理，nil 时仅告警
+
+	timersMu      sync.Mutex
+	timerUpdater  TimerUpdater
+	dynamicTimers map[string]string // 已生效的控制面下发定时器：name -> cron
+
+	mismatchMu       sync.Mutex
+	mismatchNotified string // 已通知过的服务端版本，同一版本只通知一次
+
+	extraNamespace  string   // 非空时插件注入的心跳字段收拢到该顶层字段下
+	collisionWarned sync.Map // 已告警过的与框架保留字段冲突的插件心跳字段
+
+	metrics *metrics.Metrics // 可为 nil
+}
+
+// ReporterOption Reporter 的选项函数
+type ReporterOption func(*Reporter)
+
+// WithRetryPolicy 设置心跳 HTTP 超时、最大尝试次数与退避基础间隔（默认 5s / 5 次 / 1s），零值保持默认
+func WithRetryPolicy(timeout time.Duration, attempts uint, baseDelay time.Duration) ReporterOption {
+	return func(r *Reporter) {
+		if timeout > 0 {
+			r.timeout = timeout
+		}
+		if attempts > 0 {
+			r.attempts = attempts
+		}
+		if baseDelay > 0 {
+			r.baseDelay = baseDelay
+		}
+	}
+}
+
+// defaultPayloadWarnBytes 心跳负载默认告警阈值
+const defaultPayloadWarnBytes = 256 * 1024
+
+// Status 心跳上报状态快照
+type Status struct {
+	LastReport        time.Time `json:"last_report"`
+	LastError         string    `json:"last_error,omitempty"`
+	ReportCount       int64     `json:"report_count"`
+	ErrorCount        int64     `json:"error_count"`
+	ConsecutiveErrors int64     `json:"consecutive_errors"`
+	LastPayloadBytes  int       `json:"last_payload_bytes"` // 最近一次心跳负载大小
+	MaxPayloadBytes   int       `json:"max_payload_bytes"`  // 启动以来心跳负载最大值
+}
+
+// WithReportPath 设置心跳上报接口路径（默认 reporter.DefaultHeartbeatPath），空串保持默认
+func WithReportPath(path string) ReporterOption {
+	return func(r *Reporter) {
+		if path != "" {
+			r.reportPath = path
+		}
+	}
+}
+
+// WithClientOptions 设置控制面 HTTP 客户端选项（TLS、鉴权等）
+func WithClientOptions(opts ...reporter.ClientOption) ReporterOption {
+	return func(r *Reporter) {
+		r.clientOpts = append(r.clientOpts, opts...)
+	}
+}
+
+// WithTransport 替换心跳传输层（默认 HTTP POST 到控制面），如经消息总线上报；
+// 设置后 WithReportPath/WithClientOptions/WithExtraTargets/WithRetryPolicy 不再生效
+func WithTransport(t Transport) ReporterOption {
+	return func(r *Reporter) {
+		if t != nil {
+			r.transport = t
+		}
+	}
+}
+
+// WithExtraNamespace 将插件（HeartbeatContributor/DynamicHeartbeatContributor）注入的心跳字段收拢到
+// 顶层字段 key 下，而不是与框架字段合并；空串或与 model.ReservedHeartbeatKeys 冲突时保持默认（合并到顶层）
+func WithExtraNamespace(key string) ReporterOption {
+	return func(r *Reporter) {
+		if key != "" && !slices.Contains(model.ReservedHeartbeatKeys, key) {
+			r.extraNamespace = key
+		}
+	}
+}
+
+// WithMetrics 设置心跳上报结果的指标收集
+func WithMetrics(m *metrics.Metrics) ReporterOption {
+	return func(r *Reporter) {
+		r.metrics = m
+	}
+}
+
+// WithExtraTargets 设置额外的心跳目标（与 probe 下发的 Moox Server 并行上报）；
+// requireAll 为 true 时所有目标成功才算上报成功，否则任一成功即可
+func WithExtraTargets(targets []string, requireAll bool) ReporterOption {
+	return func(r *Reporter) {
+		r.extraTargets = append(r.extraTargets, targets...)
+		r.requireAllTargets = requireAll
+	}
 }
 
 // NewReporter 创建心跳上报器
-func NewReporter(rs *config.RuntimeState, ts *config.TaskInstanceStore, p plugin.Plugin, dr *dnsproxy.Resolver) *Reporter {
-	return &Reporter{
+func NewReporter(rs *config.RuntimeState, ts *config.TaskInstanceStore, p plugin.Plugin, dr *dnsproxy.Resolver,
+	opts ...ReporterOption) *Reporter {
+	r := &Reporter{
 		runtime:     rs,
 		taskStore:   ts,
 		plugin:      p,
-		client:      &http.Client{Timeout: 5 * time.Second},
 		dnsResolver: dr,
+
+		payloadWarnBytes: defaultPayloadWarnBytes,
+		timeout:          5 * time.Second,
+		attempts:         5,
+		baseDelay:        1 * time.Second,
+		reportPath:       reporter.DefaultHeartbeatPath,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.transport == nil {
+		r.transport = &httpTransport{
+			runtime:    r.runtime,
+			client:     reporter.NewClient(r.timeout, r.clientOpts...),
+			reportPath: r.reportPath,
+			attempts:   r.attempts,
+			baseDelay:  r.baseDelay,
+			extra:      r.extraTargets,
+			requireAll: r.requireAllTargets,
+			onData:     r.applyServerData,
+		}
+	}
+	return r
+}
+
+// SetPayloadWarnThreshold 设置心跳负载告警阈值（字节），<= 0 时使用默认值 256KB
+func (r *Reporter) SetPayloadWarnThreshold(n int) {
+	if n <= 0 {
+		n = defaultPayloadWarnBytes
 	}
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	r.payloadWarnBytes = n
 }
 
 // ScheduledHeartbeat TRPC Timer 入口函数
@@ -46,6 +188,16 @@ func (r *Reporter) ScheduledHeartbeat(c context.Context, _ string) error {
 	log.WithContextFields(ctx, "func", "ScheduledHeartbeat", "version", version, "nodeID", nodeID)
 
 	log.DebugContextf(ctx, "ScheduledHeartbeat Enter")
+	// 插件初始化未完成时不上报，避免控制面将任务调度到仍在预热的节点
+	if !r.runtime.IsInitialized() {
+		log.InfoContextf(ctx, "ScheduledHeartbeat skipped: plugin is initializing")
+		return nil
+	}
+	// 退出中：最后一次心跳已由 ReportShutdown 发送，不再处理任务同步与版本校验
+	if r.runtime.IsShuttingDown() {
+		log.InfoContextf(ctx, "ScheduledHeartbeat skipped: node is shutting down")
+		return nil
+	}
 	if err := r.Report(ctx); err != nil {
 		log.ErrorContextf(ctx, "scheduled heartbeat failed: %v", err)
 		return err
@@ -54,36 +206,122 @@ func (r *Reporter) ScheduledHeartbeat(c context.Context, _ string) error {
 	return nil
 }
 
-// Report 执行心跳上报
+// Report 执行心跳上报，经 Transport 发送（默认 HTTP：除 probe 下发的 Moox Server 外，还会并行上报到配置的额外目标，
+// any 模式下任一目标成功即视为成功，all 模式下需全部成功），并校验服务端返回的包版本
 func (r *Reporter) Report(ctx context.Context) error {
-	mooxServerURL := r.runtime.GetMooxServerURL()
+	packageVersion, sent, err := r.send(ctx)
+	if err != nil || !sent {
+		return err
+	}
+	_, localVersion := r.runtime.GetNodeInfo()
+
+	// 检查版本一致性
+	if packageVersion != "" && packageVersion != localVersion {
+		r.notifyVersionMismatch(ctx, localVersion, packageVersion)
+	}
+	return nil
+}
+
+// ReportShutdown 退出前上报最后一次心跳（state 为 stopping，需先调用 RuntimeState.SetShuttingDown），
+// 使控制面立即停止调度而无需等待心跳超时；仅发送，不处理响应（不同步任务、不做版本校验）
+func (r *Reporter) ReportShutdown(ctx context.Context) error {
+	_, _, err := r.send(ctx)
+	return err
+}
+
+// send 构造心跳负载并经 Transport 发送，返回服务端期望的包版本；NodeID 缺失或 Transport 无可用目标时跳过（sent 为 false）
+func (r *Reporter) send(ctx context.Context) (packageVersion string, sent bool, err error) {
 	nodeID, localVersion := r.runtime.GetNodeInfo()
 
-	log.DebugContextf(ctx, "ReportHeartbeat: mooxServerURL=%s, nodeID=%s, version=%s",
-		mooxServerURL, nodeID, localVersion)
+	log.DebugContextf(ctx, "ReportHeartbeat: nodeID=%s, version=%s", nodeID, localVersion)
 
 	if nodeID == "" {
 		log.WarnContextf(ctx, "NodeID 为空，跳过心跳上报")
-		return nil
+		return "", false, nil
 	}
-	if mooxServerURL == "" {
-		log.WarnContextf(ctx, "Moox Server URL 未配置，跳过心跳上报")
-		return nil
+
+	data, err := json.Marshal(r.buildPayload())
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal heartbeat payload: %w", err)
 	}
+	r.recordPayloadSize(ctx, len(data))
 
-	payload := r.buildPayload()
-	packageVersion, err := r.sendToServer(ctx, payload, mooxServerURL)
+	packageVersion, err = r.transport.Send(ctx, data)
+	if errors.Is(err, ErrNoTarget) {
+		log.WarnContextf(ctx, "心跳目标未就绪，跳过心跳上报: %v", err)
+		return "", false, nil
+	}
+	r.recordResult(err)
+	r.metrics.ObserveHeartbeat(err)
 	if err != nil {
 		log.ErrorContextf(ctx, "failed to send heartbeat: %v", err)
-		return fmt.Errorf("failed to send heartbeat: %w", err)
+		return "", false, fmt.Errorf("failed to send heartbeat: %w", err)
 	}
+	return packageVersion, true, nil
+}
 
-	// 检查版本一致性
-	if packageVersion != "" && packageVersion != localVersion {
-		log.FatalContextf(ctx, "版本不一致，终止服务 - 本地版本: %s, 服务端版本: %s",
-			localVersion, packageVersion)
+// Status 返回心跳上报状态快照
+func (r *Reporter) Status() Status {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+	return r.status
+}
+
+// recordResult 记录一次心跳上报结果
+func (r *Reporter) recordResult(err error) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+
+	r.status.ReportCount++
+	if err != nil {
+		r.status.ErrorCount++
+		r.status.ConsecutiveErrors++
+		r.status.LastError = err.Error()
+		return
+	}
+	r.status.LastReport = time.Now()
+	r.status.ConsecutiveErrors = 0
+	r.status.LastError = ""
+}
+
+// SetVersionMismatchHandler 设置版本不一致时的处理函数（同一服务端版本只调用一次，在独立 goroutine 中执行），
+// 未设置时仅记录告警日志
+func (r *Reporter) SetVersionMismatchHandler(fn func(ctx context.Context, local, remote string)) {
+	r.onVersionMismatch = fn
+}
+
+// notifyVersionMismatch 记录版本不一致指标；服务端版本首次出现时告警并调用处理函数
+func (r *Reporter) notifyVersionMismatch(ctx context.Context, local, remote string) {
+	r.metrics.ObserveVersionMismatch()
+
+	r.mismatchMu.Lock()
+	notified := r.mismatchNotified == remote
+	r.mismatchNotified = remote
+	r.mismatchMu.Unlock()
+	if notified {
+		return
+	}
+
+	log.WarnContextf(ctx, "版本不一致 - 本地版本: %s, 服务端版本: %s", local, remote)
+	if r.onVersionMismatch != nil {
+		go r.onVersionMismatch(trpc.CloneContext(ctx), local, remote)
+	}
+}
+
+// recordPayloadSize 记录心跳负载大小；首次超过阈值或超过阈值后创新高时告警，避免每次心跳刷屏
+func (r *Reporter) recordPayloadSize(ctx context.Context, size int) {
+	r.statusMu.Lock()
+	prevLast, prevMax, threshold := r.status.LastPayloadBytes, r.status.MaxPayloadBytes, r.payloadWarnBytes
+	r.status.LastPayloadBytes = size
+	if size > prevMax {
+		r.status.MaxPayloadBytes = size
+	}
+	r.statusMu.Unlock()
+
+	if size > threshold && (prevLast <= threshold || size > prevMax) {
+		log.WarnContextf(ctx, "heartbeat payload size %d bytes exceeds threshold %d bytes (previous %d), check HeartbeatExtra/running tasks",
+			size, threshold, prevLast)
 	}
-	return nil
 }
 
 // buildPayload 构建心跳负载
@@ -91,36 +329,58 @@ func (r *Reporter) buildPayload() map[string]interface{} {
 	nodeID, version := r.runtime.GetNodeInfo()
 	tasksMD5 := r.taskStore.GetCurrentMD5()
 
+	metadata := map[string]interface{}{
+		"version":    version,
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+	}
+	if fwVersion, fwCommit := config.GetFrameworkVersion(); fwVersion != "" || fwCommit != "" {
+		metadata["framework_version"] = fwVersion
+		metadata["framework_commit"] = fwCommit
+	}
+	if region := r.runtime.GetRegion(); region != "" {
+		metadata["region"] = region
+	}
+	if namespace := r.runtime.GetNamespace(); namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	metadata["capabilities"] = nodeCapabilities(r.plugin.Name(), r.runtime.GetCapabilities())
+	// 部署标签（保留 key 已在 RuntimeState.SetLabels 中过滤）
+	for k, v := range r.runtime.GetLabels() {
+		metadata[k] = v
+	}
+
 	payload := map[string]interface{}{
-		"node_id":          nodeID,
-		"node_type":        "scf",
-		"running_version":  version,
-		"metadata": map[string]interface{}{
-			"version":    version,
-			"go_version": runtime.Version(),
-			"os":         runtime.GOOS,
-			"arch":       runtime.GOARCH,
-		},
-		"tasks_md5": tasksMD5,
+		"node_id":         nodeID,
+		"node_type":       "scf",
+		"state":           r.runtime.State(),
+		"running_version": version,
+		"metadata":        metadata,
+		"tasks_md5":       tasksMD5,
+	}
+
+	// 插件注入的字段：配置了 extraNamespace 时收拢到该字段下，否则合并到顶层（跳过保留字段）
+	extra := payload
+	if r.extraNamespace != "" {
+		extra = make(map[string]interface{})
 	}
 
 	// 检查插件是否实现了 HeartbeatContributor 接口
-	if contributor, ok := r.plugin.(plugin.HeartbeatContributor); ok {
-		extra := contributor.HeartbeatExtra()
-		for k, v := range extra {
-			payload[k] = v
-		}
+	if contributor, ok := plugin.Lookup[plugin.HeartbeatContributor](r.plugin); ok {
+		r.mergeExtra(extra, contributor.HeartbeatExtra())
 	}
 
 	// 检查插件是否实现了 DynamicHeartbeatContributor 接口
-	if dynContributor, ok := r.plugin.(plugin.DynamicHeartbeatContributor); ok {
+	if dynContributor, ok := plugin.Lookup[plugin.DynamicHeartbeatContributor](r.plugin); ok {
 		fn := dynContributor.HeartbeatExtraFunc()
 		if fn != nil {
-			for k, v := range fn() {
-				payload[k] = v
-			}
+			r.mergeExtra(extra, fn())
 		}
 	}
+	if r.extraNamespace != "" && len(extra) > 0 {
+		payload[r.extraNamespace] = extra
+	}
 
 	// 注入本地 DNS 解析记录
 	if r.dnsResolver != nil {
@@ -130,105 +390,137 @@ func (r *Reporter) buildPayload() map[string]interface{} {
 		}
 	}
 
+	payload["metrics"] = nodeMetrics()
+	payload["schema_version"] = model.HeartbeatSchemaVersion
+
 	return payload
 }
 
-// sendToServer POST 心跳数据到服务端，retry-go 5 次 BackOff
-func (r *Reporter) sendToServer(ctx context.Context, payload map[string]interface{}, mooxServerURL string) (string, error) {
-	if mooxServerURL == "" {
-		return "", fmt.Errorf("moox server URL is empty")
+// mergeExtra 合并插件注入的心跳字段到 dst；直接合并到顶层时丢弃与 model.ReservedHeartbeatKeys 同名的字段，
+// 每个字段只告警一次，避免插件覆盖 node_id、tasks_md5 等框架字段
+func (r *Reporter) mergeExtra(dst, extra map[string]interface{}) {
+	for k, v := range extra {
+		if r.extraNamespace == "" && slices.Contains(model.ReservedHeartbeatKeys, k) {
+			if _, warned := r.collisionWarned.LoadOrStore(k, struct{}{}); !warned {
+				log.Warnf("[Heartbeat] plugin %q heartbeat extra key %q is reserved by framework, dropped",
+					r.plugin.Name(), k)
+			}
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// nodeMetrics 采集节点运行指标（goroutine 数、堆内存、CPU 核数），心跳负载与探测响应共用
+func nodeMetrics() *model.NodeMetrics {
+	return &model.NodeMetrics{
+		MemoryUsage:  defaultMemSampler.heapAllocMB(),
+		NumGoroutine: runtime.NumGoroutine(),
+		NumCPU:       runtime.NumCPU(),
+		Timestamp:    time.Now(),
 	}
+}
 
-	url := mooxServerURL + "/gateway/cloudnode/ReportHeartbeatInner"
+// memStatsCacheTTL 堆内存读数的缓存时间。ReadMemStats 会短暂 stop-the-world，
+// 高频探测或心跳间隔很短时在窗口内复用上次读数
+const memStatsCacheTTL = 5 * time.Second
 
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal heartbeat payload: %w", err)
-	}
+// defaultMemSampler nodeMetrics 使用的进程级堆内存采样器
+var defaultMemSampler = &memSampler{ttl: memStatsCacheTTL, read: runtime.ReadMemStats, now: time.Now}
 
-	var packageVersion string
+// memSampler 按 ttl 缓存 runtime.ReadMemStats 的堆内存读数
+type memSampler struct {
+	ttl  time.Duration
+	read func(*runtime.MemStats) // 读取内存统计，测试中可替换
+	now  func() time.Time        // 时钟，测试中可替换
 
-	err = retry.Do(
-		func() error {
-			req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
-			if err != nil {
-				return fmt.Errorf("failed to create heartbeat request: %w", err)
-			}
-			req.Header.Set("Content-Type", "application/json")
+	mu     sync.Mutex
+	value  float64 // 上次读到的堆内存已分配量（MB）
+	readAt time.Time
+}
 
-			resp, err := r.client.Do(req)
-			if err != nil {
-				return err
-			}
-			defer resp.Body.Close()
+// heapAllocMB 返回堆内存已分配量（MB），距上次读取不足 ttl 时返回缓存值
+func (s *memSampler) heapAllocMB() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if !s.readAt.IsZero() && now.Sub(s.readAt) < s.ttl {
+		return s.value
+	}
+	var memStats runtime.MemStats
+	s.read(&memStats)
+	s.value = float64(memStats.Alloc) / 1024 / 1024
+	s.readAt = now
+	return s.value
+}
 
-			if resp.StatusCode != http.StatusOK {
-				respData, _ := io.ReadAll(resp.Body)
-				return fmt.Errorf("heartbeat request failed with status: %d, response: %s", resp.StatusCode, string(respData))
-			}
+// applyServerData 同步心跳响应中的任务实例与动态定时器；退出中（最后一次心跳）不处理
+func (r *Reporter) applyServerData(ctx context.Context, dataMap map[string]interface{}) {
+	if r.runtime.IsShuttingDown() {
+		return
+	}
+	r.processTaskInstances(ctx, dataMap)
+	r.processTimers(ctx, dataMap)
+}
 
-			respData, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return fmt.Errorf("failed to read response body: %w", err)
-			}
+// TimerUpdater 动态定时器增删接口（由 trigger.Manager 实现）
+type TimerUpdater interface {
+	AddTimer(ctx context.Context, name, cronExpr string) error
+	RemoveTimer(ctx context.Context, name string) bool
+}
 
-			version, parseErr := r.parseServerResponse(ctx, respData)
-			if parseErr != nil {
-				log.WarnContextf(ctx, "failed to parse server response: %v", parseErr)
-				return nil
-			}
-			packageVersion = version
-			return nil
-		},
-		retry.Attempts(5),
-		retry.Delay(1*time.Second),
-		retry.DelayType(retry.BackOffDelay),
-		retry.LastErrorOnly(true),
-		retry.OnRetry(func(n uint, err error) {
-			log.WarnContextf(ctx, "retrying heartbeat request, attempt: %d, error: %v", n+1, err)
-		}),
-		retry.Context(ctx),
-	)
-	return packageVersion, err
+// SetTimerUpdater 设置动态定时器更新器，心跳响应中的 timers 字段将同步到该更新器
+func (r *Reporter) SetTimerUpdater(u TimerUpdater) {
+	r.timersMu.Lock()
+	defer r.timersMu.Unlock()
+	r.timerUpdater = u
 }
 
-// parseServerResponse 解析服务端响应，提取 package_version 和 task_instances
-func (r *Reporter) parseServerResponse(ctx context.Context, respData []byte) (string, error) {
-	var serverResp model.ServerResponse
-	if err := json.Unmarshal(respData, &serverResp); err != nil {
-		return "", fmt.Errorf("failed to parse server response: %w", err)
+// processTimers 同步控制面下发的动态定时器：timers 为期望的完整集合，新增/变更的添加，
+// 不再下发的移除；响应中没有 timers 字段时保持现状
+func (r *Reporter) processTimers(ctx context.Context, dataMap map[string]interface{}) {
+	raw, exists := dataMap["timers"]
+	if !exists || raw == nil {
+		return
 	}
 
-	if serverResp.Code != 200 {
-		return "", fmt.Errorf("server returned error code: %d, message: %s", serverResp.Code, serverResp.Message)
+	r.timersMu.Lock()
+	defer r.timersMu.Unlock()
+	if r.timerUpdater == nil {
+		return
 	}
 
-	if len(serverResp.Data) == 0 {
-		return "", nil
+	timersJSON, err := json.Marshal(raw)
+	if err != nil {
+		log.WarnContextf(ctx, "[Heartbeat] failed to marshal timers: %v", err)
+		return
 	}
-
-	dataMap, ok := serverResp.Data[0].(map[string]interface{})
-	if !ok {
-		return "", nil
+	var schedules []model.TimerSchedule
+	if err := json.Unmarshal(timersJSON, &schedules); err != nil {
+		log.WarnContextf(ctx, "[Heartbeat] failed to unmarshal timers: %v", err)
+		return
 	}
 
-	packageVersion := extractPackageVersion(dataMap)
-	r.processTaskInstances(ctx, dataMap)
-
-	return packageVersion, nil
-}
-
-// extractPackageVersion 从响应数据中提取 package_version
-func extractPackageVersion(dataMap map[string]interface{}) string {
-	pv, exists := dataMap["package_version"]
-	if !exists {
-		return ""
+	desired := make(map[string]string, len(schedules))
+	for _, sch := range schedules {
+		if sch.Name == "" || sch.Cron == "" {
+			continue
+		}
+		desired[sch.Name] = sch.Cron
+		if r.dynamicTimers[sch.Name] == sch.Cron {
+			continue
+		}
+		if err := r.timerUpdater.AddTimer(ctx, sch.Name, sch.Cron); err != nil {
+			log.WarnContextf(ctx, "[Heartbeat] failed to apply timer %q: %v", sch.Name, err)
+			delete(desired, sch.Name)
+		}
 	}
-	versionStr, ok := pv.(string)
-	if !ok {
-		return ""
+	for name := range r.dynamicTimers {
+		if _, ok := desired[name]; !ok {
+			r.timerUpdater.RemoveTimer(ctx, name)
+		}
 	}
-	return versionStr
+	r.dynamicTimers = desired
 }
 
 // processTaskInstances 解析并更新任务实例
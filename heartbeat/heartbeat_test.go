@@ -0,0 +1,190 @@
+package heartbeat
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// extraPlugin 额外实现 plugin.HeartbeatContributor，返回固定的心跳字段
+type extraPlugin struct {
+	probePlugin
+	extra map[string]interface{}
+}
+
+func (p *extraPlugin) HeartbeatExtra() map[string]interface{} { return p.extra }
+
+// newTestReporter 创建 NodeID 为 node-1 的心跳上报器（不发送心跳，仅用于构造负载）
+func newTestReporter(p *extraPlugin, opts ...ReporterOption) *Reporter {
+	rs := config.NewRuntimeState(&config.FrameworkConfig{System: config.SystemConfig{NodeID: "node-1"}})
+	return NewReporter(rs, config.NewTaskInstanceStore(), p, nil, opts...)
+}
+
+func TestHeartbeatPayloadMetrics(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra map[string]interface{}
+	}{
+		{name: "no plugin fields"},
+		{name: "plugin metrics field is dropped", extra: map[string]interface{}{"metrics": "fake", "queue_depth": 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReporter(&extraPlugin{extra: tt.extra})
+			payload := r.buildPayload()
+
+			m, ok := payload["metrics"].(*model.NodeMetrics)
+			if !ok {
+				t.Fatalf("payload metrics = %#v, want *model.NodeMetrics", payload["metrics"])
+			}
+			if m.NumCPU != runtime.NumCPU() || m.NumGoroutine <= 0 || m.MemoryUsage <= 0 || m.Timestamp.IsZero() {
+				t.Fatalf("metrics = %+v, want populated runtime metrics", m)
+			}
+			if v, ok := tt.extra["queue_depth"]; ok && payload["queue_depth"] != v {
+				t.Fatalf("payload queue_depth = %v, want %v", payload["queue_depth"], v)
+			}
+			if _, ok := tt.extra["metrics"]; ok {
+				if _, warned := r.collisionWarned.Load("metrics"); !warned {
+					t.Fatal("metrics collision not recorded for warning")
+				}
+			}
+		})
+	}
+}
+
+func TestMemSamplerCachesReadMemStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		calls     []time.Duration // 各次调用相对首次调用的时间
+		wantReads int
+	}{
+		{name: "single call", calls: []time.Duration{0}, wantReads: 1},
+		{name: "calls within ttl reuse reading", calls: []time.Duration{0, time.Second, 4900 * time.Millisecond}, wantReads: 1},
+		{name: "call at ttl reads again", calls: []time.Duration{0, 5 * time.Second}, wantReads: 2},
+		{name: "ttl restarts after each read", calls: []time.Duration{0, 6 * time.Second, 10 * time.Second, 11 * time.Second}, wantReads: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			now := start
+			reads := 0
+			s := &memSampler{
+				ttl: memStatsCacheTTL,
+				read: func(ms *runtime.MemStats) {
+					reads++
+					ms.Alloc = uint64(reads) << 20 // 每次读取返回不同的值：reads MB
+				},
+				now: func() time.Time { return now },
+			}
+			for _, offset := range tt.calls {
+				now = start.Add(offset)
+				if got := s.heapAllocMB(); got != float64(reads) {
+					t.Fatalf("heapAllocMB() at +%s = %v, want latest reading %v", offset, got, float64(reads))
+				}
+			}
+			if reads != tt.wantReads {
+				t.Fatalf("ReadMemStats called %d times, want %d", reads, tt.wantReads)
+			}
+		})
+	}
+}
+
+// dynamicExtraPlugin 同时实现 HeartbeatContributor 与 DynamicHeartbeatContributor
+type dynamicExtraPlugin struct {
+	extraPlugin
+	dynamic map[string]interface{}
+}
+
+func (p *dynamicExtraPlugin) HeartbeatExtraFunc() func() map[string]interface{} {
+	return func() map[string]interface{} { return p.dynamic }
+}
+
+func TestHeartbeatReservedKeys(t *testing.T) {
+	colliding := map[string]interface{}{
+		"node_id":        "evil",
+		"tasks_md5":      "forged",
+		"schema_version": 99,
+		"queue_depth":    3,
+	}
+	tests := []struct {
+		name        string
+		namespace   string
+		static      map[string]interface{}
+		dynamic     map[string]interface{}
+		wantTop     map[string]interface{} // 期望出现在顶层的插件字段
+		wantNested  map[string]interface{} // 期望出现在 namespace 下的插件字段，nil 表示无该字段
+		wantWarning []string
+	}{
+		{
+			name:        "static contributor cannot clobber framework fields",
+			static:      colliding,
+			wantTop:     map[string]interface{}{"queue_depth": 3},
+			wantWarning: []string{"node_id", "tasks_md5", "schema_version"},
+		},
+		{
+			name:        "dynamic contributor cannot clobber framework fields",
+			dynamic:     map[string]interface{}{"node_id": "evil", "state": "hacked", "lag": 7},
+			wantTop:     map[string]interface{}{"lag": 7},
+			wantWarning: []string{"node_id", "state"},
+		},
+		{
+			name:       "namespace keeps all contributor fields nested",
+			namespace:  "plugin",
+			static:     colliding,
+			dynamic:    map[string]interface{}{"lag": 7},
+			wantNested: map[string]interface{}{"node_id": "evil", "tasks_md5": "forged", "schema_version": 99, "queue_depth": 3, "lag": 7},
+		},
+		{
+			name:        "reserved namespace falls back to top-level merge",
+			namespace:   "node_id",
+			static:      colliding,
+			wantTop:     map[string]interface{}{"queue_depth": 3},
+			wantWarning: []string{"node_id", "tasks_md5", "schema_version"},
+		},
+		{name: "namespace omitted without contributor fields", namespace: "plugin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &dynamicExtraPlugin{extraPlugin: extraPlugin{extra: tt.static}, dynamic: tt.dynamic}
+			rs := config.NewRuntimeState(&config.FrameworkConfig{System: config.SystemConfig{NodeID: "node-1"}})
+			ts := config.NewTaskInstanceStore()
+			r := NewReporter(rs, ts, p, nil, WithExtraNamespace(tt.namespace))
+			payload := r.buildPayload()
+
+			if payload["node_id"] != "node-1" || payload["tasks_md5"] != ts.GetCurrentMD5() ||
+				payload["schema_version"] != model.HeartbeatSchemaVersion || payload["state"] != rs.State() {
+				t.Fatalf("framework fields clobbered: node_id=%v tasks_md5=%v schema_version=%v state=%v",
+					payload["node_id"], payload["tasks_md5"], payload["schema_version"], payload["state"])
+			}
+			for k, v := range tt.wantTop {
+				if payload[k] != v {
+					t.Fatalf("payload[%q] = %v, want %v", k, payload[k], v)
+				}
+			}
+			nested, hasNested := payload["plugin"].(map[string]interface{})
+			if hasNested != (tt.wantNested != nil) {
+				t.Fatalf("payload[plugin] = %#v, want nested %v", payload["plugin"], tt.wantNested)
+			}
+			if tt.wantNested != nil && !reflect.DeepEqual(nested, tt.wantNested) {
+				t.Fatalf("payload[plugin] = %v, want %v", nested, tt.wantNested)
+			}
+			if tt.wantNested != nil {
+				for k := range tt.wantNested {
+					if _, top := payload[k]; top && !slices.Contains(model.ReservedHeartbeatKeys, k) {
+						t.Fatalf("namespaced field %q leaked to top level", k)
+					}
+				}
+			}
+			for _, k := range tt.wantWarning {
+				if _, warned := r.collisionWarned.Load(k); !warned {
+					t.Fatalf("collision on %q not recorded for warning", k)
+				}
+			}
+		})
+	}
+}
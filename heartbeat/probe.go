@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/mooyang-code/scf-framework/config"
@@ -17,18 +18,72 @@ import (
 // ProbeHandler 探测请求处理器
 type ProbeHandler struct {
 	runtime       *config.RuntimeState
+	taskStore     *config.TaskInstanceStore // 用于统计任务数，可为 nil
 	plugin        plugin.Plugin
 	storageWriter *storage.RPCWriter
 	storageReader *storage.Reader
+	oneShotFn     func() int                // 返回待执行的一次性任务数量，可为 nil
+	hbStatusFn    func() Status             // 返回心跳上报状态，可为 nil
+	hbInterval    string                    // 心跳间隔展示值
+	hbDisabled    bool                      // 心跳上报已关闭
+	inflightFn    func() (inUse, limit int) // 返回 NATS 在途消息额度使用情况，可为 nil
+
+	updateMu sync.Mutex // 串行化探测引起的运行时状态更新
+
+	cacheMu     sync.Mutex // 并发探测共享同一份构建结果
+	cacheWindow time.Duration
+	cached      *model.ProbeResponse
+	cachedAt    time.Time
 }
 
+// defaultProbeCacheWindow 探测响应默认缓存窗口
+const defaultProbeCacheWindow = 1 * time.Second
+
 // NewProbeHandler 创建探测处理器
-func NewProbeHandler(rs *config.RuntimeState, p plugin.Plugin, sw *storage.RPCWriter, sr *storage.Reader) *ProbeHandler {
+func NewProbeHandler(rs *config.RuntimeState, ts *config.TaskInstanceStore, p plugin.Plugin,
+	sw *storage.RPCWriter, sr *storage.Reader) *ProbeHandler {
 	return &ProbeHandler{
 		runtime:       rs,
+		taskStore:     ts,
 		plugin:        p,
 		storageWriter: sw,
 		storageReader: sr,
+		cacheWindow:   defaultProbeCacheWindow,
+		hbInterval:    "30s",
+	}
+}
+
+// SetCacheWindow 设置探测响应缓存窗口：窗口内的并发探测复用同一份响应（避免反复 ReadMemStats）；<= 0 表示不缓存
+func (h *ProbeHandler) SetCacheWindow(d time.Duration) {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+	h.cacheWindow = d
+}
+
+// SetOneShotCounter 设置一次性延迟任务计数函数，用于在探测响应中展示
+func (h *ProbeHandler) SetOneShotCounter(fn func() int) {
+	h.oneShotFn = fn
+}
+
+// SetHeartbeatStatus 设置心跳状态获取函数，用于在探测响应中展示上报次数与负载大小
+func (h *ProbeHandler) SetHeartbeatStatus(fn func() Status) {
+	h.hbStatusFn = fn
+}
+
+// SetHeartbeatDisabled 标记心跳上报已关闭，探测响应中展示 disabled
+func (h *ProbeHandler) SetHeartbeatDisabled(disabled bool) {
+	h.hbDisabled = disabled
+}
+
+// SetInflightUsage 设置在途消息额度获取函数，用于在探测响应中展示
+func (h *ProbeHandler) SetInflightUsage(fn func() (inUse, limit int)) {
+	h.inflightFn = fn
+}
+
+// SetHeartbeatInterval 设置探测响应中展示的心跳间隔（秒），<= 0 时保持默认 "30s"
+func (h *ProbeHandler) SetHeartbeatInterval(seconds int) {
+	if seconds > 0 {
+		h.hbInterval = fmt.Sprintf("%ds", seconds)
 	}
 }
 
@@ -46,28 +101,61 @@ func (h *ProbeHandler) ProcessProbe(ctx context.Context, event model.CloudFuncti
 	log.DebugContextf(ctx, "[ProcessProbe] functionName=%s, currentNodeID=%s, version=%s",
 		functionName, currentNodeID, currentVersion)
 
+	if h.applyProbeUpdates(ctx, event, functionName, currentVersion) {
+		h.invalidateCache()
+	}
+
+	// 构建探测响应（窗口内复用）
+	probeResponse, err := h.cachedProbeResponse()
+	if err != nil {
+		return &model.Response{
+			Success:   false,
+			Message:   fmt.Sprintf("failed to build response: %v", err),
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	return &model.Response{
+		Success:   true,
+		Message:   "probe handled successfully",
+		Data:      probeResponse,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// applyProbeUpdates 串行地将探测报文中的节点/服务端信息写入运行时状态，仅在值变化时写入，返回是否有变化
+func (h *ProbeHandler) applyProbeUpdates(ctx context.Context, event model.CloudFunctionEvent,
+	functionName, currentVersion string) (changed bool) {
+	h.updateMu.Lock()
+	defer h.updateMu.Unlock()
+
 	// 更新 NodeID
-	if functionName != "" {
+	if functionName != "" && functionName != h.runtime.GetNodeID() {
 		h.runtime.UpdateNodeInfo(functionName, currentVersion)
 		log.DebugContextf(ctx, "[ProcessProbe] NodeID 已更新为 %s", functionName)
+		changed = true
 	}
 
 	// 更新服务端连接信息
 	if event.MooxServerURL != "" {
-		log.DebugContextf(ctx, "[ProcessProbe] 更新 Moox Server 地址 %s", event.MooxServerURL)
-		h.runtime.UpdateMooxServerURL(event.MooxServerURL)
+		if event.MooxServerURL != h.runtime.GetMooxServerURL() {
+			log.DebugContextf(ctx, "[ProcessProbe] 更新 Moox Server 地址 %s", event.MooxServerURL)
+			h.runtime.UpdateMooxServerURL(event.MooxServerURL)
+			changed = true
+		}
 	} else {
 		log.WarnContextf(ctx, "[ProcessProbe] Moox Server 地址信息缺失")
 	}
 
 	// 更新存储服务地址
-	if event.StorageServerURL != "" {
+	if event.StorageServerURL != "" && event.StorageServerURL != h.runtime.GetStorageServerURL() {
 		log.DebugContextf(ctx, "[ProcessProbe] 更新存储服务地址 %s", event.StorageServerURL)
 		h.runtime.UpdateStorageServerURL(event.StorageServerURL)
+		changed = true
 	}
 
 	// 更新存储服务 RPC 地址，并动态刷新 storageWriter/storageReader 的 target
-	if event.StorageServerRPC != "" {
+	if event.StorageServerRPC != "" && event.StorageServerRPC != h.runtime.GetStorageServerRPC() {
 		log.DebugContextf(ctx, "[ProcessProbe] 更新存储服务 RPC 地址 %s", event.StorageServerRPC)
 		h.runtime.UpdateStorageServerRPC(event.StorageServerRPC)
 		if h.storageWriter != nil {
@@ -76,23 +164,34 @@ func (h *ProbeHandler) ProcessProbe(ctx context.Context, event model.CloudFuncti
 		if h.storageReader != nil {
 			h.storageReader.UpdateURL(event.StorageServerRPC)
 		}
+		changed = true
 	}
+	return changed
+}
+
+// cachedProbeResponse 在缓存窗口内复用上次构建的响应；持锁构建，使并发探测只构建一次
+func (h *ProbeHandler) cachedProbeResponse() (*model.ProbeResponse, error) {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
 
-	// 构建探测响应
-	probeResponse, err := h.buildProbeResponse()
+	if h.cached != nil && h.cacheWindow > 0 && time.Since(h.cachedAt) < h.cacheWindow {
+		return h.cached, nil
+	}
+
+	resp, err := h.buildProbeResponse()
 	if err != nil {
-		return &model.Response{
-			Success: false,
-			Message: fmt.Sprintf("failed to build response: %v", err),
-		}, nil
+		return nil, err
 	}
+	h.cached = resp
+	h.cachedAt = time.Now()
+	return resp, nil
+}
 
-	return &model.Response{
-		Success:   true,
-		Message:   "probe handled successfully",
-		Data:      probeResponse,
-		Timestamp: time.Now(),
-	}, nil
+// invalidateCache 运行时状态变化后丢弃缓存的响应
+func (h *ProbeHandler) invalidateCache() {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+	h.cached = nil
 }
 
 // buildProbeResponse 构建探测响应
@@ -104,44 +203,157 @@ func (h *ProbeHandler) buildProbeResponse() (*model.ProbeResponse, error) {
 
 	serverURL := h.runtime.GetMooxServerURL()
 
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
+	metadata := map[string]string{
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+	}
+	if fwVersion, fwCommit := config.GetFrameworkVersion(); fwVersion != "" || fwCommit != "" {
+		metadata["framework_version"] = fwVersion
+		metadata["framework_commit"] = fwCommit
+	}
+	for k, v := range h.runtime.GetLabels() {
+		metadata[k] = v
+	}
+
+	oneShotTasks := 0
+	if h.oneShotFn != nil {
+		oneShotTasks = h.oneShotFn()
+	}
+
+	var inflight *model.InflightInfo
+	if h.inflightFn != nil {
+		if inUse, limit := h.inflightFn(); limit > 0 {
+			inflight = &model.InflightInfo{InUse: inUse, Limit: limit}
+		}
+	}
+
+	hbInfo := model.HeartbeatInfo{
+		LastReport:    time.Now(),
+		Interval:      h.hbInterval,
+		MooxServerURL: serverURL,
+		Disabled:      h.hbDisabled,
+	}
+	if h.hbStatusFn != nil {
+		st := h.hbStatusFn()
+		hbInfo.LastReport = st.LastReport
+		hbInfo.ReportCount = st.ReportCount
+		hbInfo.ErrorCount = st.ErrorCount
+		hbInfo.PayloadBytes = st.LastPayloadBytes
+		hbInfo.MaxPayload = st.MaxPayloadBytes
+	}
+
+	state := h.runtime.State()
 
-	return &model.ProbeResponse{
+	resp := &model.ProbeResponse{
 		NodeID:    nodeID,
-		State:     "running",
+		State:     state,
 		Timestamp: time.Now(),
 		Details: model.ProbeDetails{
 			NodeInfo: &model.NodeInfo{
 				NodeID:       nodeID,
 				NodeType:     "scf",
+				Region:       h.runtime.GetRegion(),
+				Namespace:    h.runtime.GetNamespace(),
 				Version:      version,
 				RunningTasks: make([]string, 0),
-				Capabilities: []string{h.plugin.Name()},
-				Metadata: map[string]string{
-					"go_version": runtime.Version(),
-					"os":         runtime.GOOS,
-					"arch":       runtime.GOARCH,
-				},
-			},
-			TaskStats: model.TaskStatsInfo{},
-			Metrics: &model.NodeMetrics{
-				CPUUsage:    0,
-				MemoryUsage: float64(memStats.Alloc) / 1024 / 1024,
-				Timestamp:   time.Now(),
+				Capabilities: nodeCapabilities(h.plugin.Name(), h.runtime.GetCapabilities()),
+				Metadata:     metadata,
 			},
+			TaskStats:    h.taskStats(nodeID),
+			OneShotTasks: oneShotTasks,
+			Inflight:     inflight,
+			Metrics:      nodeMetrics(),
 			SystemInfo: model.SystemInfo{
 				GoVersion:    runtime.Version(),
 				OS:           runtime.GOOS,
 				Arch:         runtime.GOARCH,
 				NumCPU:       runtime.NumCPU(),
 				NumGoroutine: runtime.NumGoroutine(),
+				NumFD:        countOpenFDs(),
+				NumThread:    countThreads(),
 			},
-			HeartbeatInfo: model.HeartbeatInfo{
-				LastReport:    time.Now(),
-				Interval:      "30s",
-				MooxServerURL: serverURL,
-			},
+			HeartbeatInfo: hbInfo,
 		},
-	}, nil
+	}
+	h.mergePluginDetails(&resp.Details)
+	return resp, nil
+}
+
+// taskStats 按 TaskStore 统计任务数：Total 为全部任务，Running 为分配给本节点的有效任务，
+// Stopped 为已失效（Invalid != 0）的任务
+func (h *ProbeHandler) taskStats(nodeID string) model.TaskStatsInfo {
+	var stats model.TaskStatsInfo
+	if h.taskStore == nil {
+		return stats
+	}
+	for _, task := range h.taskStore.GetAll() {
+		stats.Total++
+		switch {
+		case task.Invalid != 0:
+			stats.Stopped++
+		case task.NodeID == nodeID:
+			stats.Running++
+		}
+	}
+	return stats
+}
+
+// mergePluginDetails 合并插件（ProbeContributor）提供的运行详情，插件未覆盖的字段保留框架生成的值
+func (h *ProbeHandler) mergePluginDetails(details *model.ProbeDetails) {
+	pc, ok := plugin.Lookup[plugin.ProbeContributor](h.plugin)
+	if !ok {
+		return
+	}
+	contrib, err := pc.ProbeDetails()
+	if err != nil {
+		log.Warnf("[ProcessProbe] plugin probe details failed, using defaults: %v", err)
+		return
+	}
+	if contrib == nil {
+		return
+	}
+
+	if contrib.RunningTasks != nil {
+		details.RunningTasks = contrib.RunningTasks
+		running := make([]string, 0, len(contrib.RunningTasks))
+		for _, t := range contrib.RunningTasks {
+			if t != nil {
+				running = append(running, t.TaskID)
+			}
+		}
+		details.NodeInfo.RunningTasks = running
+	}
+	if contrib.TaskStats != (model.TaskStatsInfo{}) {
+		details.TaskStats = contrib.TaskStats
+	}
+	if contrib.Metrics != nil {
+		details.Metrics = contrib.Metrics
+	}
+	if contrib.NodeInfo != nil && len(contrib.NodeInfo.Capabilities) > 0 {
+		details.NodeInfo.Capabilities = nodeCapabilities("", details.NodeInfo.Capabilities, contrib.NodeInfo.Capabilities)
+	}
+}
+
+// nodeCapabilities 合并能力标签：插件名在前，其余按出现顺序去重，忽略空串
+func nodeCapabilities(pluginName string, lists ...[]string) []string {
+	seen := make(map[string]struct{})
+	caps := make([]string, 0, 1)
+	add := func(c string) {
+		if c == "" {
+			return
+		}
+		if _, ok := seen[c]; ok {
+			return
+		}
+		seen[c] = struct{}{}
+		caps = append(caps, c)
+	}
+	add(pluginName)
+	for _, l := range lists {
+		for _, c := range l {
+			add(c)
+		}
+	}
+	return caps
 }
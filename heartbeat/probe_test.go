@@ -0,0 +1,90 @@
+package heartbeat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/plugin"
+)
+
+// probePlugin 测试用插件，contrib 供 contributingPlugin 的 ProbeDetails 返回
+type probePlugin struct {
+	contrib *model.ProbeDetails
+}
+
+func (p *probePlugin) Name() string                                        { return "probe" }
+func (p *probePlugin) Init(ctx context.Context, fw plugin.Framework) error { return nil }
+func (p *probePlugin) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error) {
+	return nil, nil
+}
+
+// contributingPlugin 额外实现 plugin.ProbeContributor
+type contributingPlugin struct {
+	probePlugin
+}
+
+func (p *contributingPlugin) ProbeDetails() (*model.ProbeDetails, error) { return p.contrib, nil }
+
+func TestProbeTaskStats(t *testing.T) {
+	seeded := []*model.TaskInstance{
+		{TaskID: "a", NodeID: "node-1"},
+		{TaskID: "b", NodeID: "node-1"},
+		{TaskID: "c", NodeID: "node-2"},
+		{TaskID: "d", NodeID: "node-1", Invalid: 1},
+		{TaskID: "e", NodeID: "node-2", Invalid: 1},
+	}
+	tests := []struct {
+		name   string
+		store  bool
+		tasks  []*model.TaskInstance
+		plugin plugin.Plugin
+		want   model.TaskStatsInfo
+	}{
+		{name: "no store reports zeros", plugin: &probePlugin{}},
+		{name: "empty store", store: true, plugin: &probePlugin{}},
+		{
+			name:   "seeded store counts own valid tasks as running",
+			store:  true,
+			tasks:  seeded,
+			plugin: &probePlugin{},
+			want:   model.TaskStatsInfo{Total: 5, Running: 2, Stopped: 2},
+		},
+		{
+			name:   "plugin stats override store counts",
+			store:  true,
+			tasks:  seeded,
+			plugin: &contributingPlugin{probePlugin{contrib: &model.ProbeDetails{TaskStats: model.TaskStatsInfo{Total: 9, Running: 9}}}},
+			want:   model.TaskStatsInfo{Total: 9, Running: 9},
+		},
+		{
+			name:   "plugin without stats keeps store counts",
+			store:  true,
+			tasks:  seeded,
+			plugin: &contributingPlugin{probePlugin{contrib: &model.ProbeDetails{}}},
+			want:   model.TaskStatsInfo{Total: 5, Running: 2, Stopped: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := config.NewRuntimeState(&config.FrameworkConfig{})
+			rs.SetNodeID("node-1")
+			var ts *config.TaskInstanceStore
+			if tt.store {
+				ts = config.NewTaskInstanceStore()
+				ts.UpdateTaskInstances(tt.tasks)
+			}
+			h := NewProbeHandler(rs, ts, tt.plugin, nil, nil)
+
+			resp, err := h.ProcessProbe(context.Background(), model.CloudFunctionEvent{Action: "probe"})
+			if err != nil || !resp.Success {
+				t.Fatalf("ProcessProbe() = %+v, %v", resp, err)
+			}
+			got := resp.Data.(*model.ProbeResponse).Details.TaskStats
+			if got != tt.want {
+				t.Fatalf("TaskStats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
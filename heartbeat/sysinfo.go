@@ -0,0 +1,41 @@
+package heartbeat
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// countOpenFDs 返回进程打开的文件描述符数（读取 /proc/self/fd），不支持的平台返回 -1
+func countOpenFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	// ReadDir 自身打开的目录 fd 也会被列出
+	return len(entries) - 1
+}
+
+// countThreads 返回进程的操作系统线程数（读取 /proc/self/status 的 Threads 字段），不支持的平台返回 -1
+func countThreads() int {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Threads:") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Threads:")))
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+	return -1
+}
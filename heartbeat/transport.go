@@ -0,0 +1,214 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/reporter"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// ErrNoTarget 传输层暂无可用目标（如控制面地址尚未由 probe 下发），本次心跳视为跳过而非失败
+var ErrNoTarget = errors.New("heartbeat target not available")
+
+// Transport 心跳传输层：发送已编码的心跳负载（JSON），返回服务端期望的包版本（为空表示不做版本校验）。
+// 默认实现为 HTTP POST 到控制面；经消息总线等方式上报时通过 WithTransport 替换
+type Transport interface {
+	Send(ctx context.Context, payload []byte) (packageVersion string, err error)
+}
+
+// httpTransport 默认传输层：POST 到 probe 下发的 Moox Server 与额外目标，
+// 以权威目标（主目标优先）响应中的 package_version 为准，其 task_instances/timers 交给 onData 同步
+type httpTransport struct {
+	runtime    *config.RuntimeState
+	client     *reporter.Client
+	reportPath string
+	attempts   uint
+	baseDelay  time.Duration
+	extra      []string // 额外的心跳目标（控制面迁移期间双报）
+	requireAll bool     // true 时所有目标都成功才算成功
+	onData     func(ctx context.Context, dataMap map[string]interface{})
+}
+
+// Send 并行上报到所有目标，逐个解析成功目标的响应，按权威目标返回包版本；响应无法解析时仅告警，不视为上报失败
+func (t *httpTransport) Send(ctx context.Context, payload []byte) (string, error) {
+	primary := t.runtime.GetMooxServerURL()
+	targets := t.targets(primary)
+	if len(targets) == 0 {
+		return "", fmt.Errorf("moox server URL not configured: %w", ErrNoTarget)
+	}
+
+	results, err := t.fanOut(ctx, payload, targets)
+	if err != nil {
+		return "", err
+	}
+	return t.resolveResults(ctx, results, primary != ""), nil
+}
+
+// targets 返回本次上报的目标列表：主目标（可为空）在前，额外目标按配置顺序在后
+func (t *httpTransport) targets(primary string) []string {
+	targets := make([]string, 0, 1+len(t.extra))
+	if primary != "" {
+		targets = append(targets, primary)
+	}
+	for _, target := range t.extra {
+		if target != "" && target != primary {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// targetResult 单个目标的上报结果
+type targetResult struct {
+	target string
+	resp   []byte
+	err    error
+}
+
+// fanOut 并行上报到所有目标，按 any/all 模式判定结果，返回与 targets 顺序一致的各目标结果
+func (t *httpTransport) fanOut(ctx context.Context, data []byte, targets []string) ([]targetResult, error) {
+	results := make([]targetResult, len(targets))
+	if len(targets) == 1 {
+		resp, err := t.sendToServer(ctx, data, targets[0])
+		if err != nil {
+			return nil, err
+		}
+		results[0] = targetResult{target: targets[0], resp: resp}
+		return results, nil
+	}
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			resp, err := t.sendToServer(ctx, data, target)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", target, err)
+			}
+			results[i] = targetResult{target: target, resp: resp, err: err}
+		}(i, target)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, res.err)
+		}
+	}
+	if len(errs) > 0 {
+		if t.requireAll || len(errs) == len(targets) {
+			return nil, errors.Join(errs...)
+		}
+		log.WarnContextf(ctx, "heartbeat partially failed (%d/%d targets): %v", len(errs), len(targets), errors.Join(errs...))
+	}
+	return results, nil
+}
+
+// resolveResults 逐个解析成功目标的响应并返回权威目标的包版本。权威目标按固定优先级选取（与完成先后无关）：
+// hasPrimary 时为主目标 results[0]，主目标失败时取配置顺序中第一个成功的额外目标。
+// 只有权威响应的 task_instances/timers 会被同步；其他目标的 package_version 仅记录，与权威版本不一致时告警
+func (t *httpTransport) resolveResults(ctx context.Context, results []targetResult, hasPrimary bool) string {
+	authoritative := -1
+	for i, res := range results {
+		if res.err == nil {
+			authoritative = i
+			break
+		}
+	}
+	if authoritative < 0 {
+		return ""
+	}
+	if hasPrimary && authoritative > 0 {
+		log.WarnContextf(ctx, "primary heartbeat target failed, using %s as authoritative", results[authoritative].target)
+	}
+
+	dataMap, version, err := decodeServerResponse(results[authoritative].resp)
+	if err != nil {
+		log.WarnContextf(ctx, "failed to parse server response from %s: %v", results[authoritative].target, err)
+	} else if dataMap != nil && t.onData != nil {
+		t.onData(ctx, dataMap)
+	}
+
+	for i, res := range results {
+		if i == authoritative || res.err != nil {
+			continue
+		}
+		_, targetVersion, err := decodeServerResponse(res.resp)
+		switch {
+		case err != nil:
+			log.WarnContextf(ctx, "failed to parse server response from %s: %v", res.target, err)
+		case targetVersion != "" && targetVersion != version:
+			log.WarnContextf(ctx, "heartbeat target %s reports package_version %s, differs from authoritative %s (%s), ignored",
+				res.target, targetVersion, results[authoritative].target, version)
+		default:
+			log.DebugContextf(ctx, "heartbeat target %s package_version=%s", res.target, targetVersion)
+		}
+	}
+	return version
+}
+
+// sendToServer POST 心跳数据到指定服务端，返回响应 body（4xx 除 429 外不重试）
+func (t *httpTransport) sendToServer(ctx context.Context, data []byte, mooxServerURL string) ([]byte, error) {
+	if mooxServerURL == "" {
+		return nil, fmt.Errorf("moox server URL is empty")
+	}
+
+	url := t.client.URL(mooxServerURL, t.reportPath)
+
+	respData, err := t.client.PostJSON(ctx, reporter.Request{
+		URL:   url,
+		Body:  data,
+		Retry: reporter.RetryPolicy{Attempts: t.attempts, Delay: t.baseDelay},
+		OnRetry: func(n uint, err error) {
+			log.WarnContextf(ctx, "retrying heartbeat request to %s, attempt: %d, error: %v", mooxServerURL, n+1, err)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("heartbeat request failed: %w", err)
+	}
+	return respData, nil
+}
+
+// decodeServerResponse 解析服务端响应，返回首条数据（可能为 nil）与其中的 package_version
+func decodeServerResponse(respData []byte) (map[string]interface{}, string, error) {
+	var serverResp model.ServerResponse
+	if err := json.Unmarshal(respData, &serverResp); err != nil {
+		return nil, "", fmt.Errorf("failed to parse server response: %w", err)
+	}
+
+	if serverResp.Code != 200 {
+		return nil, "", fmt.Errorf("server returned error code: %d, message: %s", serverResp.Code, serverResp.Message)
+	}
+
+	if len(serverResp.Data) == 0 {
+		return nil, "", nil
+	}
+
+	dataMap, ok := serverResp.Data[0].(map[string]interface{})
+	if !ok {
+		return nil, "", nil
+	}
+	return dataMap, extractPackageVersion(dataMap), nil
+}
+
+// extractPackageVersion 从响应数据中提取 package_version
+func extractPackageVersion(dataMap map[string]interface{}) string {
+	pv, exists := dataMap["package_version"]
+	if !exists {
+		return ""
+	}
+	versionStr, ok := pv.(string)
+	if !ok {
+		return ""
+	}
+	return versionStr
+}
@@ -0,0 +1,128 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/reporter"
+)
+
+// controlPlane 测试用控制面：返回 version 对应的 package_version，status 非 200 时返回错误状态，
+// body 非空时原样返回（用于构造无法解析的响应）
+type controlPlane struct {
+	version string
+	status  int
+	body    string
+	delay   time.Duration
+}
+
+func (c controlPlane) start(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(c.delay)
+		if c.status != 0 && c.status != http.StatusOK {
+			w.WriteHeader(c.status)
+			return
+		}
+		if c.body != "" {
+			w.Write([]byte(c.body))
+			return
+		}
+		fmt.Fprintf(w, `{"code":200,"data":[{"package_version":%q,"source":%q}]}`, c.version, c.version)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestHTTPTransportSend(t *testing.T) {
+	tests := []struct {
+		name        string
+		primary     *controlPlane
+		extra       []controlPlane
+		requireAll  bool
+		wantVersion string
+		wantSynced  string // onData 同步的响应来源（权威目标的版本），空表示未同步
+		wantErr     bool
+	}{
+		{
+			name:        "primary only",
+			primary:     &controlPlane{version: "v1"},
+			wantVersion: "v1", wantSynced: "v1",
+		},
+		{
+			name:        "slow primary stays authoritative",
+			primary:     &controlPlane{version: "v1", delay: 50 * time.Millisecond},
+			extra:       []controlPlane{{version: "v2"}},
+			wantVersion: "v1", wantSynced: "v1",
+		},
+		{
+			name:        "primary failed, first successful extra in config order",
+			primary:     &controlPlane{status: http.StatusBadRequest},
+			extra:       []controlPlane{{status: http.StatusBadRequest}, {version: "v3", delay: 50 * time.Millisecond}, {version: "v4"}},
+			wantVersion: "v3", wantSynced: "v3",
+		},
+		{
+			name:       "primary failed in all mode",
+			primary:    &controlPlane{status: http.StatusBadRequest},
+			extra:      []controlPlane{{version: "v2"}},
+			requireAll: true,
+			wantErr:    true,
+		},
+		{
+			name:        "no primary, extras only",
+			extra:       []controlPlane{{version: "v5", delay: 50 * time.Millisecond}, {version: "v6"}},
+			wantVersion: "v5", wantSynced: "v5",
+		},
+		{
+			name:    "authoritative response unparseable",
+			primary: &controlPlane{body: "not json"},
+			extra:   []controlPlane{{version: "v2"}},
+		},
+		{
+			name:    "all targets failed",
+			primary: &controlPlane{status: http.StatusBadRequest},
+			extra:   []controlPlane{{status: http.StatusBadRequest}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := config.NewRuntimeState(&config.FrameworkConfig{})
+			if tt.primary != nil {
+				rs.UpdateMooxServerURL(tt.primary.start(t))
+			}
+			var extra []string
+			for _, cp := range tt.extra {
+				extra = append(extra, cp.start(t))
+			}
+			var synced string
+			tr := &httpTransport{
+				runtime:    rs,
+				client:     reporter.NewClient(5 * time.Second),
+				reportPath: "/heartbeat",
+				attempts:   1,
+				extra:      extra,
+				requireAll: tt.requireAll,
+				onData: func(ctx context.Context, dataMap map[string]interface{}) {
+					synced, _ = dataMap["source"].(string)
+				},
+			}
+
+			version, err := tr.Send(context.Background(), []byte(`{}`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if version != tt.wantVersion {
+				t.Fatalf("Send() version = %q, want %q", version, tt.wantVersion)
+			}
+			if synced != tt.wantSynced {
+				t.Fatalf("synced response from %q, want %q", synced, tt.wantSynced)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package metrics 框架级 Prometheus 指标：触发器调用、心跳上报、任务状态上报与 Gateway 请求
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 指标结果标签取值
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+	ResultSkipped = "skipped" // 插件返回 DispositionSkip，不计为已处理
+	ResultRetry   = "retry"   // 插件返回 DispositionRetry，等待重投
+)
+
+// Metrics 框架指标集合。所有方法对 nil 接收者安全，未启用指标时调用方无需判空
+type Metrics struct {
+	registry *prometheus.Registry
+
+	triggerInvocations *prometheus.CounterVec
+	triggerDuration    *prometheus.HistogramVec
+	heartbeats         *prometheus.CounterVec
+	taskReports        *prometheus.CounterVec
+	versionMismatches  prometheus.Counter
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+}
+
+// NewRegistry 创建默认的指标注册表，预置 Go 运行时与进程指标
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// New 在 reg 上注册框架指标；reg 为 nil 时使用 NewRegistry()。
+// 用户可在同一个 reg 上注册自定义 collector，由 /metrics 一并暴露
+func New(reg *prometheus.Registry) *Metrics {
+	if reg == nil {
+		reg = NewRegistry()
+	}
+	m := &Metrics{
+		registry: reg,
+		triggerInvocations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "scf",
+			Subsystem: "trigger",
+			Name:      "invocations_total",
+			Help:      "Number of trigger events dispatched to the plugin.",
+		}, []string{"trigger", "type", "result"}),
+		triggerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "scf",
+			Subsystem: "trigger",
+			Name:      "duration_seconds",
+			Help:      "Time spent handling trigger events in the plugin.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"trigger", "type"}),
+		heartbeats: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "scf",
+			Subsystem: "heartbeat",
+			Name:      "reports_total",
+			Help:      "Number of heartbeat reports by result.",
+		}, []string{"result"}),
+		versionMismatches: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "scf",
+			Subsystem: "heartbeat",
+			Name:      "version_mismatch_total",
+			Help:      "Number of heartbeat responses whose package version differs from the local version.",
+		}),
+		taskReports: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "scf",
+			Subsystem: "task",
+			Name:      "reports_total",
+			Help:      "Number of task status reports by result.",
+		}, []string{"result"}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "scf",
+			Subsystem: "gateway",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests served by the gateway.",
+		}, []string{"method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "scf",
+			Subsystem: "gateway",
+			Name:      "request_duration_seconds",
+			Help:      "Time spent serving HTTP requests in the gateway.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+	reg.MustRegister(m.triggerInvocations, m.triggerDuration, m.heartbeats, m.versionMismatches, m.taskReports,
+		m.httpRequests, m.httpDuration)
+	return m
+}
+
+// Registry 返回指标注册表
+func (m *Metrics) Registry() *prometheus.Registry {
+	if m == nil {
+		return nil
+	}
+	return m.registry
+}
+
+// Handler 返回暴露注册表内全部指标的 HTTP handler
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveTrigger 记录一次触发器调用的结果与耗时
+func (m *Metrics) ObserveTrigger(name, triggerType string, d time.Duration, err error) {
+	m.ObserveTriggerResult(name, triggerType, d, result(err))
+}
+
+// ObserveTriggerResult 以指定的结果标签（ResultSuccess/ResultError/ResultSkipped/ResultRetry）记录一次触发器调用
+func (m *Metrics) ObserveTriggerResult(name, triggerType string, d time.Duration, res string) {
+	if m == nil {
+		return
+	}
+	m.triggerInvocations.WithLabelValues(name, triggerType, res).Inc()
+	m.triggerDuration.WithLabelValues(name, triggerType).Observe(d.Seconds())
+}
+
+// ObserveHeartbeat 记录一次心跳上报结果
+func (m *Metrics) ObserveHeartbeat(err error) {
+	if m == nil {
+		return
+	}
+	m.heartbeats.WithLabelValues(result(err)).Inc()
+}
+
+// ObserveVersionMismatch 记录一次心跳响应中的版本不一致
+func (m *Metrics) ObserveVersionMismatch() {
+	if m == nil {
+		return
+	}
+	m.versionMismatches.Inc()
+}
+
+// ObserveTaskReport 记录一次任务状态上报结果
+func (m *Metrics) ObserveTaskReport(err error) {
+	if m == nil {
+		return
+	}
+	m.taskReports.WithLabelValues(result(err)).Inc()
+}
+
+// ObserveHTTPRequest 记录一次 Gateway 请求的状态码与耗时（不按路径区分，避免标签基数过高）
+func (m *Metrics) ObserveHTTPRequest(method string, code int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, strconv.Itoa(code)).Inc()
+	m.httpDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// result 将错误转换为结果标签
+func result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultSuccess
+}
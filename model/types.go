@@ -2,6 +2,8 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,9 +13,11 @@ import (
 type TriggerType string
 
 const (
-	TriggerTimer TriggerType = "timer"
-	TriggerNATS  TriggerType = "nats"
-	TriggerHTTP  TriggerType = "http"
+	TriggerTimer     TriggerType = "timer"
+	TriggerNATS      TriggerType = "nats"
+	TriggerHTTP      TriggerType = "http"
+	TriggerKafka     TriggerType = "kafka"
+	TriggerWebSocket TriggerType = "websocket"
 )
 
 // TriggerEvent 触发事件
@@ -27,11 +31,56 @@ type TriggerEvent struct {
 	Jobs     []TaskJob         `json:"jobs,omitempty"`
 }
 
+// HeaderMetadataPrefix 消息头写入 TriggerEvent.Metadata 时使用的 key 前缀，避免与框架注入的 key 冲突
+const HeaderMetadataPrefix = "header."
+
+// Header 返回触发源消息头（如 NATS Header）中 key 对应的值，多值以逗号拼接；不存在时返回空串
+func (e *TriggerEvent) Header(key string) string {
+	if e == nil || e.Metadata == nil {
+		return ""
+	}
+	return e.Metadata[HeaderMetadataPrefix+key]
+}
+
+// Headers 返回触发源消息头的副本（key 已去除前缀）
+func (e *TriggerEvent) Headers() map[string]string {
+	headers := make(map[string]string)
+	if e == nil {
+		return headers
+	}
+	for k, v := range e.Metadata {
+		if strings.HasPrefix(k, HeaderMetadataPrefix) {
+			headers[strings.TrimPrefix(k, HeaderMetadataPrefix)] = v
+		}
+	}
+	return headers
+}
+
+// TimerSchedule 控制面通过心跳响应下发的动态定时器
+type TimerSchedule struct {
+	Name string `json:"name"`
+	Cron string `json:"cron"`
+}
+
+// TriggerStatus 触发器运行状态快照
+type TriggerStatus struct {
+	Name   string      `json:"name"`
+	Type   TriggerType `json:"type"`
+	Active bool        `json:"active"`          // 已启动、未停止且健康检查通过
+	Error  string      `json:"error,omitempty"` // 健康检查失败原因
+}
+
 // TriggerConfig 触发器配置（从 YAML 解析）
 type TriggerConfig struct {
 	Name     string                 `yaml:"name" json:"name"`
 	Type     string                 `yaml:"type" json:"type"`
 	Settings map[string]interface{} `yaml:"settings" json:"settings"`
+	Enabled  *bool                  `yaml:"enabled,omitempty" json:"enabled,omitempty"` // 未配置时默认启用，false 时跳过注册
+}
+
+// IsEnabled 返回触发器是否启用（未配置 enabled 时默认启用）
+func (c TriggerConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
 }
 
 // ========== 心跳相关 ==========
@@ -48,15 +97,34 @@ type CloudFunctionEvent struct {
 	StorageServerRPC string                 `json:"storage_server_rpc,omitempty"`
 }
 
+// HeartbeatSchemaVersion 心跳负载结构版本。框架字段新增、删除或语义变化时递增，
+// 控制面据此选择解析方式；插件通过 HeartbeatContributor 注入的字段不影响该版本
+const HeartbeatSchemaVersion = 1
+
+// ReservedHeartbeatKeys 心跳负载中由框架写入的顶层字段，插件经 HeartbeatContributor 注入的同名字段会被丢弃
+var ReservedHeartbeatKeys = []string{
+	"node_id", "node_type", "state", "running_version", "metadata", "tasks_md5",
+	"local_dns_records", "metrics", "schema_version",
+}
+
+// 节点生命周期状态，见心跳负载的 state 字段与探测响应的 State
+const (
+	NodeStateInitializing = "initializing" // plugin.Init 尚未完成
+	NodeStateRunning      = "running"      // 正常运行
+	NodeStateStopping     = "stopping"     // 正在退出，控制面应立即停止向该节点调度
+)
+
 // HeartbeatPayload 心跳上报负载（业务特有字段通过 HeartbeatContributor 注入）
 type HeartbeatPayload struct {
-	NodeID       string                 `json:"node_id"`
-	NodeType     string                 `json:"node_type"`
-	Timestamp    time.Time              `json:"timestamp"`
-	RunningTasks []*TaskSummary         `json:"running_tasks"`
-	Metrics      *NodeMetrics           `json:"metrics"`
-	TasksMD5     string                 `json:"tasks_md5"`
-	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	SchemaVersion int                    `json:"schema_version"`
+	NodeID        string                 `json:"node_id"`
+	NodeType      string                 `json:"node_type"`
+	State         string                 `json:"state"`
+	Timestamp     time.Time              `json:"timestamp"`
+	RunningTasks  []*TaskSummary         `json:"running_tasks"`
+	Metrics       *NodeMetrics           `json:"metrics"`
+	TasksMD5      string                 `json:"tasks_md5"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // NodeInfo 节点信息
@@ -73,12 +141,14 @@ type NodeInfo struct {
 
 // NodeMetrics 节点指标
 type NodeMetrics struct {
-	CPUUsage    float64   `json:"cpu_usage"`
-	MemoryUsage float64  `json:"memory_usage"`
-	TaskCount   int       `json:"task_count"`
-	SuccessRate float64   `json:"success_rate"`
-	ErrorCount  int       `json:"error_count"`
-	Timestamp   time.Time `json:"timestamp"`
+	CPUUsage     float64   `json:"cpu_usage"`
+	MemoryUsage  float64   `json:"memory_usage"` // 堆内存已分配量（MB）
+	NumGoroutine int       `json:"num_goroutine,omitempty"`
+	NumCPU       int       `json:"num_cpu,omitempty"`
+	TaskCount    int       `json:"task_count"`
+	SuccessRate  float64   `json:"success_rate"`
+	ErrorCount   int       `json:"error_count"`
+	Timestamp    time.Time `json:"timestamp"`
 }
 
 // TaskSummary 任务摘要
@@ -114,6 +184,14 @@ type ProbeDetails struct {
 	Metrics       *NodeMetrics   `json:"metrics"`
 	SystemInfo    SystemInfo     `json:"system_info"`
 	HeartbeatInfo HeartbeatInfo  `json:"heartbeat_info"`
+	OneShotTasks  int            `json:"one_shot_tasks"`     // 待执行的一次性延迟任务数
+	Inflight      *InflightInfo  `json:"inflight,omitempty"` // NATS 在途消息额度（配置了上限时展示）
+}
+
+// InflightInfo 在途消息额度使用情况
+type InflightInfo struct {
+	InUse int `json:"in_use"`
+	Limit int `json:"limit"`
 }
 
 // TaskStatsInfo 任务统计信息
@@ -132,6 +210,8 @@ type SystemInfo struct {
 	Arch         string `json:"arch"`
 	NumCPU       int    `json:"num_cpu"`
 	NumGoroutine int    `json:"num_goroutine"`
+	NumFD        int    `json:"num_fd"`     // 打开的文件描述符数，不支持的平台为 -1
+	NumThread    int    `json:"num_thread"` // 操作系统线程数，不支持的平台为 -1
 }
 
 // HeartbeatInfo 心跳信息
@@ -141,6 +221,9 @@ type HeartbeatInfo struct {
 	ErrorCount    int64     `json:"error_count"`
 	Interval      string    `json:"interval"`
 	MooxServerURL string    `json:"moox_server_url"`
+	PayloadBytes  int       `json:"payload_bytes"`      // 最近一次心跳负载大小
+	MaxPayload    int       `json:"max_payload_bytes"`  // 启动以来心跳负载最大值
+	Disabled      bool      `json:"disabled,omitempty"` // 心跳上报已关闭
 }
 
 // ========== 任务执行结果 ==========
@@ -158,16 +241,26 @@ type TaskResult struct {
 	Result string `json:"result"` // 失败原因（成功时为空）
 }
 
+// EventDisposition 插件对触发事件的处置方式
+type EventDisposition string
+
+const (
+	DispositionAck   EventDisposition = "ack"   // 处理成功（默认）
+	DispositionSkip  EventDisposition = "skip"  // 忽略：确认消息但不计为已处理
+	DispositionRetry EventDisposition = "retry" // 要求重投递
+)
+
 // TriggerResponse 插件对触发事件的响应
 type TriggerResponse struct {
-	TaskResults []TaskResult `json:"task_results,omitempty"`
-	DataPoints  []DataPoint  `json:"data_points,omitempty"`
-	WriteGroups []WriteGroup `json:"write_groups,omitempty"`
+	Disposition EventDisposition `json:"disposition,omitempty"` // 为空时等同 ack
+	TaskResults []TaskResult     `json:"task_results,omitempty"`
+	DataPoints  []DataPoint      `json:"data_points,omitempty"`
+	WriteGroups []WriteGroup     `json:"write_groups,omitempty"`
 }
 
 // WriteGroup 多组写入（不同 write_mode/dataset）
 type WriteGroup struct {
-	WriteMode  string      `json:"write_mode,omitempty"`  // "set_data" 或 "upsert_object"
+	WriteMode  string      `json:"write_mode,omitempty"` // "set_data" 或 "upsert_object"
 	DatasetID  *int        `json:"dataset_id,omitempty"`
 	Freq       string      `json:"freq,omitempty"`
 	AppKey     string      `json:"app_key,omitempty"`
@@ -196,6 +289,17 @@ type TaskInstance struct {
 	Extra      map[string]interface{} `json:"extra,omitempty"`
 }
 
+// DecodeParams 将 TaskParams（JSON）解析到 v；TaskParams 为空或不是合法 JSON 时返回错误
+func (t *TaskInstance) DecodeParams(v interface{}) error {
+	if t.TaskParams == "" {
+		return fmt.Errorf("task %s: task_params is empty", t.TaskID)
+	}
+	if err := json.Unmarshal([]byte(t.TaskParams), v); err != nil {
+		return fmt.Errorf("task %s: invalid task_params: %w", t.TaskID, err)
+	}
+	return nil
+}
+
 // ========== 框架调度 ==========
 
 // TaskJob 框架筛选出的单个待执行任务单元
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaskInstanceDecodeParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     string
+		wantSymbol string
+		wantErr    string
+	}{
+		{name: "valid params", params: `{"symbol":"BTC","intervals":["1m"]}`, wantSymbol: "BTC"},
+		{name: "empty params", wantErr: "task_params is empty"},
+		{name: "malformed json", params: `{"symbol":`, wantErr: "invalid task_params"},
+		{name: "type mismatch", params: `{"symbol":1}`, wantErr: "invalid task_params"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &TaskInstance{TaskID: "t1", TaskParams: tt.params}
+			var p struct {
+				Symbol string `json:"symbol"`
+			}
+			err := task.DecodeParams(&p)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "t1") {
+					t.Fatalf("DecodeParams() error = %v, want %q for task t1", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || p.Symbol != tt.wantSymbol {
+				t.Fatalf("DecodeParams() = %+v, %v, want symbol %q", p, err, tt.wantSymbol)
+			}
+		})
+	}
+}
@@ -1,5 +1,17 @@
 package scf
 
+import (
+	"time"
+
+	"github.com/mooyang-code/scf-framework/gateway"
+	"github.com/mooyang-code/scf-framework/heartbeat"
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/reporter"
+	"github.com/mooyang-code/scf-framework/trigger"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
 // Option App 配置选项
 type Option func(*options)
 
@@ -12,6 +24,35 @@ type options struct {
 	timerMinuteService   string
 	timerHourService     string
 	enableGateway        bool
+	gatewayAccessLog     bool
+	gatewayReadTimeout   time.Duration
+	labels               map[string]string
+	taskStatusSuccess    int
+	taskStatusFailed     int
+	taskStatusEncoder    reporter.TaskStatusEncoder
+	eventSink            trigger.EventSink
+	warnOnDupTriggers    bool
+	probeCacheWindow     *time.Duration
+	statePath            string
+	taskStorePath        string
+	gatewayRoutes        []gatewayRoute
+	heartbeatOpts        []heartbeat.ReporterOption
+	maxInflightMessages  int
+	heartbeatEnabled     *bool // 覆盖配置文件中的 heartbeat.enabled，nil 表示以配置为准
+	metricsRegistry      *prometheus.Registry
+	tracerProvider       trace.TracerProvider
+	gracefulTimeout      time.Duration
+	nodeID               string
+
+	versionMismatchPolicy  VersionMismatchPolicy // 为空时以配置 heartbeat.on_version_mismatch 为准
+	versionMismatchHandler VersionMismatchHandler
+}
+
+// gatewayRoute 按路径前缀转发到独立后端的路由
+type gatewayRoute struct {
+	prefix string
+	host   string
+	port   int
 }
 
 func defaultOptions() *options {
@@ -22,6 +63,11 @@ func defaultOptions() *options {
 		timerSecondService:   "trpc.timer.second",
 		timerMinuteService:   "trpc.timer.minute",
 		timerHourService:     "trpc.timer.hour",
+		taskStatusSuccess:    model.TaskStatusSuccess,
+		taskStatusFailed:     model.TaskStatusFailed,
+		gatewayAccessLog:     true,
+		gatewayReadTimeout:   gateway.DefaultBodyReadTimeout,
+		gracefulTimeout:      defaultShutdownTimeout,
 	}
 }
 
@@ -40,6 +86,58 @@ func WithGatewayService(name string) Option {
 	}
 }
 
+// WithGatewayAccessLog 开关 Gateway 逐请求访问日志（method/path/status/bytes/耗时，默认开启），
+// 高 QPS 场景可关闭；请求指标不受影响
+func WithGatewayAccessLog(enabled bool) Option {
+	return func(o *options) {
+		o.gatewayAccessLog = enabled
+	}
+}
+
+// WithGatewayBodyReadTimeout 设置 Gateway（/probe 与转发）读取请求 body 的超时（默认 30s），
+// 慢速客户端超时返回 408；<= 0 不限时
+func WithGatewayBodyReadTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.gatewayReadTimeout = d
+	}
+}
+
+// WithNodeID 设置节点 ID，优先于配置文件的 system.node_id；SCF 环境变量（SCF_FUNCTIONNAME /
+// TENCENTCLOUD_FUNCTIONNAME）存在时仍以环境变量为准。用于本地开发与非 SCF 部署
+func WithNodeID(id string) Option {
+	return func(o *options) {
+		o.nodeID = id
+	}
+}
+
+// WithGracefulTimeout 设置整个退出流程（最后一次心跳、排空触发器、关闭插件、等待任务上报）的最长时间，
+// 默认 30s；<= 0 保持默认
+func WithGracefulTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.gracefulTimeout = d
+		}
+	}
+}
+
+// WithVersionMismatchPolicy 设置心跳发现版本不一致时的处理方式，优先于配置 heartbeat.on_version_mismatch；
+// 非法取值忽略
+func WithVersionMismatchPolicy(p VersionMismatchPolicy) Option {
+	return func(o *options) {
+		switch p {
+		case VersionMismatchShutdown, VersionMismatchWarn, VersionMismatchCustom:
+			o.versionMismatchPolicy = p
+		}
+	}
+}
+
+// WithVersionMismatchHandler 设置版本不一致回调（如告警、上报事件），在任何处理方式下都会先于框架处理调用
+func WithVersionMismatchHandler(fn VersionMismatchHandler) Option {
+	return func(o *options) {
+		o.versionMismatchHandler = fn
+	}
+}
+
 // WithHeartbeatService 设置心跳定时器 service name
 func WithHeartbeatService(name string) Option {
 	return func(o *options) {
@@ -55,3 +153,123 @@ func WithTimerServices(second, minute, hour string) Option {
 		o.timerHourService = hour
 	}
 }
+
+// WithLabels 设置部署标签（如 deploy_id、commit、cohort），与配置 system.labels 合并，同名时以此为准
+func WithLabels(labels map[string]string) Option {
+	return func(o *options) {
+		if o.labels == nil {
+			o.labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			o.labels[k] = v
+		}
+	}
+}
+
+// WithTaskStatusCodes 设置上报给服务端的任务成功/失败状态码（默认 2/4）。
+// 插件仍返回 model.TaskStatusSuccess/model.TaskStatusFailed，由框架在上报时映射。
+func WithTaskStatusCodes(success, failed int) Option {
+	return func(o *options) {
+		o.taskStatusSuccess = success
+		o.taskStatusFailed = failed
+	}
+}
+
+// WithTaskStatusEncoder 自定义任务状态上报的请求体编码（字段名、嵌套结构、Content-Type），
+// 用于适配不同控制面的接口；默认为 reporter.DefaultTaskStatusEncoder
+func WithTaskStatusEncoder(enc reporter.TaskStatusEncoder) Option {
+	return func(o *options) {
+		o.taskStatusEncoder = enc
+	}
+}
+
+// WithEventSink 设置事件旁路输出（如 trigger.NATSEventSink），每个已分发事件及其处理结果
+// 会被异步投递，缓冲满时丢弃
+func WithEventSink(sink trigger.EventSink) Option {
+	return func(o *options) {
+		o.eventSink = sink
+	}
+}
+
+// WithWarnOnDuplicateTriggers 重名触发器仅告警而不阻止启动（默认启动失败）
+func WithWarnOnDuplicateTriggers() Option {
+	return func(o *options) {
+		o.warnOnDupTriggers = true
+	}
+}
+
+// WithProbeCacheWindow 设置探测响应缓存窗口（默认 1s），窗口内的并发探测共享同一份响应；<= 0 表示不缓存
+func WithProbeCacheWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.probeCacheWindow = &d
+	}
+}
+
+// WithStatePath 设置插件状态存储（Framework.State()）的持久化文件路径，未设置时仅保存在内存中
+func WithStatePath(path string) Option {
+	return func(o *options) {
+		o.statePath = path
+	}
+}
+
+// WithTaskStorePath 设置任务列表快照文件路径（如 /tmp/tasks.json）。每次任务列表更新后写入快照，
+// 启动时从快照恢复上次的任务，冷启动无需等待首次心跳即可执行；快照损坏时以空列表启动。未设置时不持久化
+func WithTaskStorePath(path string) Option {
+	return func(o *options) {
+		o.taskStorePath = path
+	}
+}
+
+// WithGatewayRoute 将 prefix 下的请求转发到 host:port，转发前去除 prefix（需启用 Gateway）
+func WithGatewayRoute(prefix, host string, port int) Option {
+	return func(o *options) {
+		o.gatewayRoutes = append(o.gatewayRoutes, gatewayRoute{prefix: prefix, host: host, port: port})
+	}
+}
+
+// WithHeartbeatPolicy 设置心跳请求超时、最大尝试次数与指数退避基础间隔（默认 5s / 5 次 / 1s）
+func WithHeartbeatPolicy(timeout time.Duration, attempts uint, baseDelay time.Duration) Option {
+	return func(o *options) {
+		o.heartbeatOpts = append(o.heartbeatOpts, heartbeat.WithRetryPolicy(timeout, attempts, baseDelay))
+	}
+}
+
+// WithHeartbeatTransport 替换心跳传输层（默认 HTTP POST 到控制面），如经消息总线上报心跳；
+// 负载内容与版本校验不变，自定义传输层需自行投递负载并返回服务端期望的包版本
+func WithHeartbeatTransport(t heartbeat.Transport) Option {
+	return func(o *options) {
+		o.heartbeatOpts = append(o.heartbeatOpts, heartbeat.WithTransport(t))
+	}
+}
+
+// WithHeartbeatEnabled 启用/关闭心跳上报，优先于配置文件中的 heartbeat.enabled（默认启用）。
+// 关闭后不创建心跳上报器、不向控制面发送心跳，任务状态上报等其他功能不受影响
+func WithHeartbeatEnabled(enabled bool) Option {
+	return func(o *options) {
+		o.heartbeatEnabled = &enabled
+	}
+}
+
+// WithMetricsRegistry 使用自定义的 Prometheus 注册表（默认 metrics.NewRegistry()），
+// 框架指标注册到该表上，用户注册的 collector 也会在 Gateway 的 GET /metrics 一并暴露
+func WithMetricsRegistry(reg *prometheus.Registry) Option {
+	return func(o *options) {
+		o.metricsRegistry = reg
+	}
+}
+
+// WithTracerProvider 设置 OpenTelemetry TracerProvider（默认 no-op）。启用后触发器分发、
+// HTTPPluginAdapter 调用与 Gateway 转发会创建 span，并通过 W3C traceparent 请求头串联
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithMaxInflightMessages 设置所有 NATS 触发器共享的在途消息上限（已拉取未处理完的消息数），
+// 达到上限时暂停拉取；<= 0（默认）不限制
+func WithMaxInflightMessages(n int) Option {
+	return func(o *options) {
+		o.maxInflightMessages = n
+	}
+}
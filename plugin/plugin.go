@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mooyang-code/scf-framework/config"
 	"github.com/mooyang-code/scf-framework/dnsproxy"
 	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/reporter"
 	"github.com/mooyang-code/scf-framework/storage"
+	"github.com/mooyang-code/scf-framework/tracing"
 	"trpc.group/trpc-go/trpc-go/log"
 )
 
@@ -23,14 +27,89 @@ type Plugin interface {
 	OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error)
 }
 
+// ResponderPlugin 另一种插件形态：OnTrigger 只返回 error，执行结果写入框架传入的 resp。
+// 与 Plugin 的 OnTrigger 签名不同，一个类型只能实现其中一种；需经 AsPlugin 包装后传给 scf.New
+type ResponderPlugin interface {
+	Name() string
+	Init(ctx context.Context, fw Framework) error
+	OnTrigger(ctx context.Context, event *model.TriggerEvent, resp *model.TriggerResponse) error
+}
+
+// AsPlugin 将 ResponderPlugin 包装为 Plugin：每次触发时创建空的 TriggerResponse 交给插件填写，
+// 返回 error 时已写入的 TaskResults 等仍照常上报。被包装插件实现的可选接口（HealthChecker、HeartbeatContributor 等）
+// 由框架通过 Lookup 穿透包装查找，无需在包装层重复实现。
+//
+// 用法：
+//
+//	type MyPlugin struct{}
+//
+//	func (p *MyPlugin) Name() string { return "my-plugin" }
+//
+//	func (p *MyPlugin) Init(ctx context.Context, fw plugin.Framework) error { return nil }
+//
+//	func (p *MyPlugin) OnTrigger(ctx context.Context, event *model.TriggerEvent, resp *model.TriggerResponse) error {
+//		resp.TaskResults = append(resp.TaskResults, model.TaskResult{TaskID: "t1", Status: 2})
+//		return nil
+//	}
+//
+//	app := scf.New(plugin.AsPlugin(&MyPlugin{}))
+func AsPlugin(p ResponderPlugin) Plugin {
+	return &responderAdapter{ResponderPlugin: p}
+}
+
+// responderAdapter AsPlugin 返回的包装
+type responderAdapter struct {
+	ResponderPlugin
+}
+
+// OnTrigger 实现 Plugin 接口
+func (a *responderAdapter) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error) {
+	resp := &model.TriggerResponse{}
+	err := a.ResponderPlugin.OnTrigger(ctx, event, resp)
+	return resp, err
+}
+
+// Unwrap 返回被包装的插件
+func (a *responderAdapter) Unwrap() interface{} {
+	return a.ResponderPlugin
+}
+
+// Lookup 在插件及其包装链（实现了 Unwrap() interface{} 的包装，如 AsPlugin 的返回值）上查找可选接口 T，
+// 框架检查 HealthChecker、HeartbeatContributor 等可选接口时均通过此函数
+func Lookup[T any](p interface{}) (T, bool) {
+	for p != nil {
+		if v, ok := p.(T); ok {
+			return v, true
+		}
+		u, ok := p.(interface{ Unwrap() interface{} })
+		if !ok {
+			break
+		}
+		p = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
 // Framework 框架接口，插件通过此接口访问框架能力
 type Framework interface {
 	Config() *config.FrameworkConfig
 	Runtime() *config.RuntimeState
 	TaskStore() *config.TaskInstanceStore
+	State() *config.StateStore         // 插件级 key/value 状态，可持久化，仅适合少量数据
 	DNSResolver() *dnsproxy.Resolver   // 无配置时返回 nil
 	StorageWriter() *storage.RPCWriter // xData 写入器
 	StorageReader() *storage.Reader    // xData 读取器
+	// TaskReporter 任务状态上报器；OnTrigger 返回的 TaskResults 由框架自动上报，仅在需要手动上报时使用
+	TaskReporter() *reporter.TaskReporter
+	// DecodePluginConfig 解析本插件的配置：plugins.<Name()> 节点优先，否则使用 plugin 节点
+	DecodePluginConfig(v interface{}) error
+	// ScheduleOnce 在 delay 之后执行一次 fn，框架停止时未执行的任务被取消
+	ScheduleOnce(delay time.Duration, fn func(ctx context.Context))
+	// TriggerActive 返回指定触发器当前是否活跃（已启动、未停止且健康），未注册时返回 false
+	TriggerActive(name string) bool
+	// Triggers 返回所有已注册触发器（含动态定时器）的运行状态快照
+	Triggers() []model.TriggerStatus
 }
 
 // HeartbeatContributor 可选接口，插件可实现此接口向心跳负载注入额外字段
@@ -43,6 +122,46 @@ type DynamicHeartbeatContributor interface {
 	HeartbeatExtraFunc() func() map[string]interface{}
 }
 
+// HealthChecker 可选接口，插件可实现此接口参与框架健康检查
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// ConfigReloader 可选接口，插件可实现此接口在 SIGHUP 时热加载 plugin 配置节点
+type ConfigReloader interface {
+	OnConfigReload(ctx context.Context, cfg *config.FrameworkConfig) error
+}
+
+// ProbeContributor 可选接口，插件可提供探测响应中的运行详情（如正在采集的任务）。
+// 框架只采用返回值中的 RunningTasks、TaskStats（非零值）与 Metrics（非 nil），
+// 系统信息、心跳信息等仍由框架生成；返回错误时沿用框架生成的内容
+type ProbeContributor interface {
+	ProbeDetails() (*model.ProbeDetails, error)
+}
+
+// Starter 可选接口，在 Gateway 与触发器注册启动之后、TRPC Server 开始服务之前调用，
+// 适合执行首次补采等需要完整运行环境的工作；返回错误将中止启动
+type Starter interface {
+	OnStart(ctx context.Context) error
+}
+
+// Closer 可选接口，插件可在此释放 Init 中创建的连接、缓存与 goroutine；
+// App.Shutdown 在触发器停止之后、TRPC Server 关闭之前调用
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// Subscriber 可选接口，插件可声明只接收指定名称触发器的事件；未实现时接收全部事件
+type Subscriber interface {
+	Subscriptions() []string
+}
+
+// TaskParamsValidator 可选接口，插件可在任务列表更新时校验每个有效任务的 TaskParams（通常借助
+// TaskInstance.DecodeParams）；校验失败的任务在 TaskStore 中标记为 Invalid=1，不再进入调度，仅告警一次
+type TaskParamsValidator interface {
+	ValidateTaskParams(task *model.TaskInstance) error
+}
+
 // ========== HTTPPluginAdapter ==========
 
 // HTTPPluginOption HTTPPluginAdapter 的选项函数
@@ -69,23 +188,51 @@ func WithHeartbeatExtraFunc(fn func() map[string]interface{}) HTTPPluginOption {
 	}
 }
 
+// WithHeartbeatExtraEndpoint 每次心跳时 GET baseURL+path 拉取插件进程的 JSON 对象，合并到心跳额外字段
+// （覆盖静态字段）；请求失败或超时时沿用上一次成功拉取的结果
+func WithHeartbeatExtraEndpoint(path string) HTTPPluginOption {
+	return func(a *HTTPPluginAdapter) {
+		a.extraEndpoint = path
+	}
+}
+
+// WithStreamThreshold 设置流式发送阈值：event.Payload 超过该字节数时，
+// 通过 io.Pipe 分块写入请求体、边写边发送，避免整体缓冲；<= 0 表示禁用流式发送
+func WithStreamThreshold(n int) HTTPPluginOption {
+	return func(a *HTTPPluginAdapter) {
+		a.streamThreshold = n
+	}
+}
+
+// defaultStreamThreshold 默认流式发送阈值（1MB）
+const defaultStreamThreshold = 1 << 20
+
+// extraFetchTimeout 心跳时拉取插件额外字段的超时，避免拖慢心跳
+const extraFetchTimeout = 2 * time.Second
+
 // HTTPPluginAdapter 通过 HTTP 调用外部插件进程的适配器
 type HTTPPluginAdapter struct {
 	name               string
 	baseURL            string
 	client             *http.Client
 	readyTimeout       time.Duration
+	streamThreshold    int
 	heartbeatExtra     map[string]interface{}
 	heartbeatExtraFunc func() map[string]interface{}
+	extraEndpoint      string // 心跳额外字段拉取路径，空表示不拉取
+
+	extraMu   sync.Mutex
+	lastExtra map[string]interface{} // 最近一次成功拉取的额外字段
 }
 
 // NewHTTPPluginAdapter 创建 HTTPPluginAdapter
 func NewHTTPPluginAdapter(name, baseURL string, opts ...HTTPPluginOption) *HTTPPluginAdapter {
 	a := &HTTPPluginAdapter{
-		name:         name,
-		baseURL:      baseURL,
-		client:       &http.Client{Timeout: 30 * time.Second},
-		readyTimeout: 30 * time.Second,
+		name:            name,
+		baseURL:         baseURL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		readyTimeout:    30 * time.Second,
+		streamThreshold: defaultStreamThreshold,
 	}
 	for _, opt := range opts {
 		opt(a)
@@ -100,22 +247,13 @@ func (a *HTTPPluginAdapter) Name() string {
 
 // Init 循环探测 GET /health 等待插件进程就绪
 func (a *HTTPPluginAdapter) Init(ctx context.Context, _ Framework) error {
-	healthURL := fmt.Sprintf("%s/health", a.baseURL)
 	deadline := time.Now().Add(a.readyTimeout)
 
 	for time.Now().Before(deadline) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
-		if err != nil {
-			return fmt.Errorf("failed to create health check request: %w", err)
-		}
-
-		resp, err := a.client.Do(req)
+		err := a.CheckHealth(ctx)
 		if err == nil {
-			resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				log.InfoContextf(ctx, "[HTTPPluginAdapter] plugin %s is ready", a.name)
-				return nil
-			}
+			log.InfoContextf(ctx, "[HTTPPluginAdapter] plugin %s is ready", a.name)
+			return nil
 		}
 
 		select {
@@ -128,28 +266,44 @@ func (a *HTTPPluginAdapter) Init(ctx context.Context, _ Framework) error {
 	return fmt.Errorf("plugin %s not ready after %v", a.name, a.readyTimeout)
 }
 
-// OnTrigger POST /on-trigger 发送 TriggerEvent JSON，解析插件响应中的 TaskResults
-func (a *HTTPPluginAdapter) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error) {
-	triggerURL := fmt.Sprintf("%s/on-trigger", a.baseURL)
+// CheckHealth 单次探测 GET /health，返回 nil 表示插件进程健康
+func (a *HTTPPluginAdapter) CheckHealth(ctx context.Context) error {
+	healthURL := fmt.Sprintf("%s/health", a.baseURL)
 
-	data, err := json.Marshal(event)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal trigger event: %w", err)
+		return fmt.Errorf("failed to create health check request: %w", err)
 	}
 
-	// 调试日志：打印发送给插件的 payload 片段
-	logData := string(data)
-	if len(logData) > 500 {
-		logData = logData[:500] + "..."
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("plugin %s health check failed: %w", a.name, err)
 	}
-	log.InfoContextf(ctx, "[HTTPPluginAdapter] sending to plugin: url=%s, body_len=%d, body=%s",
-		triggerURL, len(data), logData)
+	resp.Body.Close()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, triggerURL, bytes.NewReader(data))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("plugin %s health check returned status %d", a.name, resp.StatusCode)
+	}
+	return nil
+}
+
+// OnTrigger POST /on-trigger 发送 TriggerEvent JSON，解析插件响应中的 TaskResults；
+// ctx 中的 span 以 W3C traceparent 请求头传递给插件进程
+func (a *HTTPPluginAdapter) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error) {
+	triggerURL := fmt.Sprintf("%s/on-trigger", a.baseURL)
+
+	body, err := a.newTriggerBody(ctx, triggerURL, event)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, triggerURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create trigger request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
 
 	resp, err := a.client.Do(req)
 	if err != nil {
@@ -161,27 +315,27 @@ func (a *HTTPPluginAdapter) OnTrigger(ctx context.Context, event *model.TriggerE
 	log.InfoContextf(ctx, "[HTTPPluginAdapter] plugin %s responded: statusCode=%d", a.name, resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("plugin %s returned status %d for trigger event", a.name, resp.StatusCode)
+		return nil, a.engineError(resp)
 	}
 
 	// 读取并解析响应 body
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.WarnContextf(ctx, "[HTTPPluginAdapter] failed to read response body from plugin %s: %v", a.name, err)
 		return nil, nil
 	}
 
-	log.InfoContextf(ctx, "[HTTPPluginAdapter] plugin %s response body: len=%d, body=%s", a.name, len(body), string(body))
+	log.InfoContextf(ctx, "[HTTPPluginAdapter] plugin %s response body: len=%d, body=%s", a.name, len(respBody), string(respBody))
 
-	if len(body) == 0 {
+	if len(respBody) == 0 {
 		log.WarnContextf(ctx, "[HTTPPluginAdapter] plugin %s returned empty body", a.name)
 		return nil, nil
 	}
 
 	var triggerResp model.TriggerResponse
-	if err := json.Unmarshal(body, &triggerResp); err != nil {
+	if err := json.Unmarshal(respBody, &triggerResp); err != nil {
 		log.WarnContextf(ctx, "[HTTPPluginAdapter] failed to parse trigger response from plugin %s: %v, body=%s",
-			a.name, err, string(body))
+			a.name, err, string(respBody))
 		return nil, nil
 	}
 
@@ -189,13 +343,114 @@ func (a *HTTPPluginAdapter) OnTrigger(ctx context.Context, event *model.TriggerE
 	return &triggerResp, nil
 }
 
-// HeartbeatExtra 返回心跳额外字段（合并静态和动态）
+// maxErrorBodyLen 错误信息中保留的插件响应 body 最大字节数
+const maxErrorBodyLen = 1024
+
+// engineError 将插件进程的非 200 响应转为错误：body 为 {"code","message"} 时取其字段，
+// 否则附带截断后的原始 body（通常包含插件侧的错误与堆栈）
+func (a *HTTPPluginAdapter) engineError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen+1))
+
+	var structured struct {
+		Code    interface{} `json:"code"`
+		Message string      `json:"message"`
+	}
+	if json.Unmarshal(body, &structured) == nil && structured.Message != "" {
+		if structured.Code != nil {
+			return fmt.Errorf("plugin %s returned status %d for trigger event: code=%v, message=%s",
+				a.name, resp.StatusCode, structured.Code, structured.Message)
+		}
+		return fmt.Errorf("plugin %s returned status %d for trigger event: %s",
+			a.name, resp.StatusCode, structured.Message)
+	}
+
+	text := strings.TrimSpace(string(body))
+	if len(body) > maxErrorBodyLen {
+		text = strings.TrimSpace(strings.ToValidUTF8(string(body[:maxErrorBodyLen]), "")) + "...(truncated)"
+	}
+	if text == "" {
+		return fmt.Errorf("plugin %s returned status %d for trigger event", a.name, resp.StatusCode)
+	}
+	return fmt.Errorf("plugin %s returned status %d for trigger event: %s", a.name, resp.StatusCode, text)
+}
+
+// newTriggerBody 构建 /on-trigger 请求体：
+// 小负载整体序列化后发送；大负载通过 io.Pipe 流式发送（见 writeTriggerEvent），不再额外持有一份序列化后的 Payload
+func (a *HTTPPluginAdapter) newTriggerBody(ctx context.Context, triggerURL string, event *model.TriggerEvent) (io.ReadCloser, error) {
+	if a.streamThreshold > 0 && len(event.Payload) > a.streamThreshold {
+		log.InfoContextf(ctx, "[HTTPPluginAdapter] streaming to plugin: url=%s, payload_len=%d, threshold=%d",
+			triggerURL, len(event.Payload), a.streamThreshold)
+
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(writeTriggerEvent(pw, event))
+		}()
+		return pr, nil
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trigger event: %w", err)
+	}
+
+	// 调试日志：打印发送给插件的 payload 片段
+	logData := string(data)
+	if len(logData) > 500 {
+		logData = logData[:500] + "..."
+	}
+	log.InfoContextf(ctx, "[HTTPPluginAdapter] sending to plugin: url=%s, body_len=%d, body=%s",
+		triggerURL, len(data), logData)
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// streamChunkSize 流式发送时每次写入 Payload 的字节数
+const streamChunkSize = 32 << 10
+
+// writeTriggerEvent 以 JSON 写出 event：除 Payload 外的字段（体积小）整体序列化，
+// Payload 原样按 streamChunkSize 分块写入 w，边写边发送，不做整体缓冲
+func writeTriggerEvent(w io.Writer, event *model.TriggerEvent) error {
+	envelope := *event
+	envelope.Payload = nil
+	head, err := json.Marshal(&envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trigger event: %w", err)
+	}
+	if !json.Valid(event.Payload) {
+		return fmt.Errorf("failed to marshal trigger event: payload is not valid JSON")
+	}
+
+	// head 形如 {"type":...}，去掉开头的 { 后接在 payload 之后
+	if _, err := io.WriteString(w, `{"payload":`); err != nil {
+		return err
+	}
+	for p := event.Payload; len(p) > 0; {
+		n := min(len(p), streamChunkSize)
+		if _, err := w.Write(p[:n]); err != nil {
+			return err
+		}
+		p = p[n:]
+	}
+	if _, err := io.WriteString(w, ","); err != nil {
+		return err
+	}
+	_, err = w.Write(head[1:])
+	return err
+}
+
+// HeartbeatExtra 返回心跳额外字段（依次合并静态字段、插件进程拉取的字段、动态函数字段）
 func (a *HTTPPluginAdapter) HeartbeatExtra() map[string]interface{} {
 	result := make(map[string]interface{})
 	// 静态字段
 	for k, v := range a.heartbeatExtra {
 		result[k] = v
 	}
+	// 插件进程字段
+	if a.extraEndpoint != "" {
+		for k, v := range a.fetchExtra() {
+			result[k] = v
+		}
+	}
 	// 动态字段（每次心跳时实时获取）
 	if a.heartbeatExtraFunc != nil {
 		for k, v := range a.heartbeatExtraFunc() {
@@ -205,6 +460,66 @@ func (a *HTTPPluginAdapter) HeartbeatExtra() map[string]interface{} {
 	return result
 }
 
+// fetchExtra GET extraEndpoint 拉取额外字段；失败时返回上一次成功的结果
+func (a *HTTPPluginAdapter) fetchExtra() map[string]interface{} {
+	a.extraMu.Lock()
+	defer a.extraMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), extraFetchTimeout)
+	defer cancel()
+
+	extra, err := a.getJSONObject(ctx, a.baseURL+a.extraEndpoint)
+	if err != nil {
+		log.WarnContextf(ctx, "[HTTPPluginAdapter] fetch heartbeat extra from plugin %s failed, using last value: %v", a.name, err)
+		return a.lastExtra
+	}
+	a.lastExtra = extra
+	return extra
+}
+
+// getJSONObject GET url 并将 200 响应解析为 JSON 对象
+func (a *HTTPPluginAdapter) getJSONObject(ctx context.Context, url string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var obj map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON object: %w", err)
+	}
+	return obj, nil
+}
+
+// Close POST /shutdown 通知插件进程释放资源；插件进程未提供该接口（404）时视为成功
+func (a *HTTPPluginAdapter) Close(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/shutdown", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create shutdown request: %w", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send shutdown to plugin %s: %w", a.name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		log.InfoContextf(ctx, "[HTTPPluginAdapter] plugin %s shutdown: statusCode=%d", a.name, resp.StatusCode)
+		return nil
+	default:
+		return fmt.Errorf("plugin %s returned status %d for shutdown", a.name, resp.StatusCode)
+	}
+}
+
 // BaseURL 返回插件基础 URL（供 Gateway 转发使用）
 func (a *HTTPPluginAdapter) BaseURL() string {
 	return a.baseURL
@@ -0,0 +1,327 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// echoResponder 测试用 ResponderPlugin：为每个事件写入一条成功的任务结果，事件名为 "fail" 时返回错误
+type echoResponder struct{}
+
+func (p *echoResponder) Name() string                                 { return "echo" }
+func (p *echoResponder) Init(ctx context.Context, fw Framework) error { return nil }
+func (p *echoResponder) OnTrigger(ctx context.Context, event *model.TriggerEvent, resp *model.TriggerResponse) error {
+	resp.TaskResults = append(resp.TaskResults, model.TaskResult{TaskID: event.Name, Status: 2})
+	if event.Name == "fail" {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+// healthyResponder 额外实现可选接口 HealthChecker 的 ResponderPlugin
+type healthyResponder struct {
+	echoResponder
+	checked bool
+}
+
+func (p *healthyResponder) CheckHealth(ctx context.Context) error {
+	p.checked = true
+	return nil
+}
+
+func ExampleAsPlugin() {
+	p := AsPlugin(&echoResponder{})
+
+	resp, err := p.OnTrigger(context.Background(), &model.TriggerEvent{Name: "collect"})
+	fmt.Println(resp.TaskResults[0].TaskID, resp.TaskResults[0].Status, err)
+	// Output: collect 2 <nil>
+}
+
+func TestAsPluginOnTrigger(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		wantErr bool
+	}{
+		{name: "success", event: "collect"},
+		{name: "error keeps task results", event: "fail", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := AsPlugin(&echoResponder{})
+			resp, err := p.OnTrigger(context.Background(), &model.TriggerEvent{Name: tt.event})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OnTrigger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resp == nil || len(resp.TaskResults) != 1 || resp.TaskResults[0].TaskID != tt.event {
+				t.Fatalf("OnTrigger() resp = %+v, want one task result for %q", resp, tt.event)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	healthy := &healthyResponder{}
+	tests := []struct {
+		name   string
+		plugin Plugin
+		want   bool
+	}{
+		{name: "wrapped plugin implements HealthChecker", plugin: AsPlugin(healthy), want: true},
+		{name: "wrapped plugin without HealthChecker", plugin: AsPlugin(&echoResponder{}), want: false},
+		{name: "plain plugin implements HealthChecker", plugin: NewHTTPPluginAdapter("py", "http://127.0.0.1:1"), want: true},
+		{name: "nil plugin", plugin: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Lookup[HealthChecker](tt.plugin)
+			if ok != tt.want {
+				t.Fatalf("Lookup[HealthChecker]() ok = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	hc, _ := Lookup[HealthChecker](AsPlugin(healthy))
+	if err := hc.CheckHealth(context.Background()); err != nil || !healthy.checked {
+		t.Fatalf("CheckHealth() through wrapper: err = %v, checked = %v", err, healthy.checked)
+	}
+	if a, ok := Lookup[*HTTPPluginAdapter](NewHTTPPluginAdapter("py", "http://127.0.0.1:1")); !ok || a.Name() != "py" {
+		t.Fatalf("Lookup[*HTTPPluginAdapter]() = %v, %v", a, ok)
+	}
+}
+
+func TestNewTriggerBodyStreaming(t *testing.T) {
+	big := json.RawMessage(`{"rows":"` + strings.Repeat("x", 3*streamChunkSize+7) + `"}`)
+	tests := []struct {
+		name      string
+		threshold int
+		event     *model.TriggerEvent
+		wantErr   bool
+	}{
+		{
+			name:      "small payload buffered",
+			threshold: 1 << 20,
+			event:     &model.TriggerEvent{Type: model.TriggerNATS, Name: "n", Payload: json.RawMessage(`{"a":1}`)},
+		},
+		{
+			name:      "large payload streamed in chunks",
+			threshold: 16,
+			event: &model.TriggerEvent{Type: model.TriggerNATS, Name: "n", Payload: big,
+				Metadata: map[string]string{"nodeID": "node-1"}, TasksMD5: "md5"},
+		},
+		{
+			name:      "streaming disabled",
+			threshold: 0,
+			event:     &model.TriggerEvent{Type: model.TriggerTimer, Name: "t", Payload: big},
+		},
+		{
+			name:      "invalid payload fails while streaming",
+			threshold: 1,
+			event:     &model.TriggerEvent{Type: model.TriggerNATS, Name: "n", Payload: json.RawMessage(`{broken`)},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewHTTPPluginAdapter("py", "http://127.0.0.1:1", WithStreamThreshold(tt.threshold))
+			body, err := a.newTriggerBody(context.Background(), "http://127.0.0.1:1/on-trigger", tt.event)
+			if err == nil {
+				defer body.Close()
+				var data []byte
+				data, err = io.ReadAll(body)
+				if err == nil {
+					var got model.TriggerEvent
+					if err := json.Unmarshal(data, &got); err != nil {
+						t.Fatalf("body is not valid JSON: %v", err)
+					}
+					if !reflect.DeepEqual(&got, tt.event) {
+						t.Fatalf("decoded event = %+v, want %+v", got, tt.event)
+					}
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newTriggerBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// countingWriter 记录每次 Write 的长度
+type countingWriter struct {
+	bytes.Buffer
+	writes []int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestWriteTriggerEventChunks(t *testing.T) {
+	payload := json.RawMessage(`"` + strings.Repeat("y", 2*streamChunkSize) + `"`)
+	w := &countingWriter{}
+	if err := writeTriggerEvent(w, &model.TriggerEvent{Name: "n", Payload: payload}); err != nil {
+		t.Fatalf("writeTriggerEvent() error = %v", err)
+	}
+	for _, n := range w.writes {
+		if n > streamChunkSize {
+			t.Fatalf("write of %d bytes exceeds chunk size %d", n, streamChunkSize)
+		}
+	}
+}
+
+// engineReply 测试插件进程的一次响应
+type engineReply struct {
+	status int
+	body   string
+}
+
+// stubEngine 按顺序返回 replies 的测试插件进程，用完后重复最后一个
+type stubEngine struct {
+	mu      sync.Mutex
+	replies []engineReply
+	calls   int
+}
+
+func (e *stubEngine) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.mu.Lock()
+		reply := e.replies[min(e.calls, len(e.replies)-1)]
+		e.calls++
+		e.mu.Unlock()
+		w.WriteHeader(reply.status)
+		io.WriteString(w, reply.body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHeartbeatExtraEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		static  map[string]interface{}
+		fn      func() map[string]interface{}
+		replies []engineReply
+		want    []map[string]interface{} // 每次心跳的期望结果
+	}{
+		{
+			name:    "engine fields merged over static",
+			static:  map[string]interface{}{"region": "sh", "factors": []interface{}{"static"}},
+			replies: []engineReply{{http.StatusOK, `{"factors":["ma","rsi"],"load":0.5}`}},
+			want: []map[string]interface{}{
+				{"region": "sh", "factors": []interface{}{"ma", "rsi"}, "load": 0.5},
+			},
+		},
+		{
+			name:    "live values refreshed each heartbeat",
+			replies: []engineReply{{http.StatusOK, `{"load":0.1}`}, {http.StatusOK, `{"load":0.9}`}},
+			want:    []map[string]interface{}{{"load": 0.1}, {"load": 0.9}},
+		},
+		{
+			name:    "error falls back to last value",
+			static:  map[string]interface{}{"region": "sh"},
+			replies: []engineReply{{http.StatusOK, `{"load":0.1}`}, {http.StatusInternalServerError, "boom"}, {http.StatusOK, `not json`}},
+			want: []map[string]interface{}{
+				{"region": "sh", "load": 0.1},
+				{"region": "sh", "load": 0.1},
+				{"region": "sh", "load": 0.1},
+			},
+		},
+		{
+			name:    "error before any success keeps static only",
+			static:  map[string]interface{}{"region": "sh"},
+			replies: []engineReply{{http.StatusNotFound, ""}},
+			want:    []map[string]interface{}{{"region": "sh"}},
+		},
+		{
+			name:    "func fields override engine fields",
+			fn:      func() map[string]interface{} { return map[string]interface{}{"load": "from-func"} },
+			replies: []engineReply{{http.StatusOK, `{"load":0.1,"factors":["ma"]}`}},
+			want:    []map[string]interface{}{{"load": "from-func", "factors": []interface{}{"ma"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &stubEngine{replies: tt.replies}
+			srv := engine.start(t)
+			opts := []HTTPPluginOption{WithHeartbeatExtra(tt.static), WithHeartbeatExtraEndpoint("/heartbeat-extra")}
+			if tt.fn != nil {
+				opts = append(opts, WithHeartbeatExtraFunc(tt.fn))
+			}
+			a := NewHTTPPluginAdapter("py", srv.URL, opts...)
+
+			for i, want := range tt.want {
+				if got := a.HeartbeatExtra(); !reflect.DeepEqual(got, want) {
+					t.Fatalf("heartbeat %d extra = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOnTriggerEngineError(t *testing.T) {
+	long := strings.Repeat("e", maxErrorBodyLen+10)
+	tests := []struct {
+		name    string
+		reply   engineReply
+		wantMsg string
+	}{
+		{
+			name:    "structured error with code",
+			reply:   engineReply{http.StatusInternalServerError, `{"code":"E_FACTOR","message":"factor ma not found"}`},
+			wantMsg: "plugin py returned status 500 for trigger event: code=E_FACTOR, message=factor ma not found",
+		},
+		{
+			name:    "structured error without code",
+			reply:   engineReply{http.StatusBadRequest, `{"message":"bad payload"}`},
+			wantMsg: "plugin py returned status 400 for trigger event: bad payload",
+		},
+		{
+			name:    "plain text traceback",
+			reply:   engineReply{http.StatusInternalServerError, "Traceback (most recent call last):\n  KeyError: 'ma'\n"},
+			wantMsg: "plugin py returned status 500 for trigger event: Traceback (most recent call last):\n  KeyError: 'ma'",
+		},
+		{
+			name:    "JSON without message kept as text",
+			reply:   engineReply{http.StatusBadGateway, `{"detail":"upstream"}`},
+			wantMsg: `plugin py returned status 502 for trigger event: {"detail":"upstream"}`,
+		},
+		{
+			name:    "long body truncated",
+			reply:   engineReply{http.StatusInternalServerError, long},
+			wantMsg: "plugin py returned status 500 for trigger event: " + long[:maxErrorBodyLen] + "...(truncated)",
+		},
+		{
+			name:    "empty body",
+			reply:   engineReply{http.StatusServiceUnavailable, ""},
+			wantMsg: "plugin py returned status 503 for trigger event",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := (&stubEngine{replies: []engineReply{tt.reply}}).start(t)
+			a := NewHTTPPluginAdapter("py", srv.URL)
+
+			resp, err := a.OnTrigger(context.Background(), &model.TriggerEvent{Type: model.TriggerTimer, Name: "t"})
+			if resp != nil || err == nil {
+				t.Fatalf("OnTrigger() = %v, %v, want error", resp, err)
+			}
+			if err.Error() != tt.wantMsg {
+				t.Fatalf("OnTrigger() error = %q, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
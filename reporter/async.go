@@ -0,0 +1,47 @@
+package reporter
+
+import "errors"
+
+// 异步上报工作池默认值
+const (
+	DefaultAsyncWorkers   = 16   // 并发执行异步上报的 worker 数
+	DefaultAsyncQueueSize = 1024 // 等待 worker 处理的积压上限
+)
+
+// ErrReportQueueFull 异步上报积压队列已满，本次上报被丢弃
+var ErrReportQueueFull = errors.New("task report queue full")
+
+// WithAsyncWorkers 设置异步上报工作池：workers 个 worker 并发上报，最多积压 queueSize 条，
+// 队列满时新的上报被丢弃并告警。<= 0 的参数保持默认（DefaultAsyncWorkers/DefaultAsyncQueueSize）
+func WithAsyncWorkers(workers, queueSize int) TaskReporterOption {
+	return func(r *TaskReporter) {
+		if workers > 0 {
+			r.asyncWorkers = workers
+		}
+		if queueSize > 0 {
+			r.asyncQueueSize = queueSize
+		}
+	}
+}
+
+// submit 将异步上报任务放入队列（首次调用时启动 worker），队列满时返回 false，不阻塞调用方
+func (r *TaskReporter) submit(job func()) bool {
+	r.startOnce.Do(r.startWorkers)
+	select {
+	case r.queue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// startWorkers 启动固定数量的 worker，随进程常驻
+func (r *TaskReporter) startWorkers() {
+	for i := 0; i < r.asyncWorkers; i++ {
+		go func() {
+			for job := range r.queue {
+				job()
+			}
+		}()
+	}
+}
@@ -0,0 +1,93 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// blockingControlPlane 阻塞每个请求直到 release 关闭，并记录最大并发请求数
+type blockingControlPlane struct {
+	release  chan struct{}
+	inflight atomic.Int32
+	peak     atomic.Int32
+	served   atomic.Int32
+}
+
+func (c *blockingControlPlane) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := c.inflight.Add(1)
+	for {
+		p := c.peak.Load()
+		if n <= p || c.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	<-c.release
+	c.inflight.Add(-1)
+	c.served.Add(1)
+}
+
+func TestReportAsyncBoundedConcurrency(t *testing.T) {
+	tests := []struct {
+		name      string
+		workers   int
+		queueSize int
+		reports   int
+		wantPeak  int
+	}{
+		{name: "flood beyond queue is dropped", workers: 4, queueSize: 8, reports: 200, wantPeak: 4},
+		{name: "single worker", workers: 1, queueSize: 2, reports: 50, wantPeak: 1},
+		{name: "defaults", reports: 2000, wantPeak: DefaultAsyncWorkers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := &blockingControlPlane{release: make(chan struct{})}
+			srv := httptest.NewServer(cp)
+			t.Cleanup(srv.Close)
+			var releaseOnce sync.Once
+			releaseAll := func() { releaseOnce.Do(func() { close(cp.release) }) }
+			t.Cleanup(releaseAll)
+
+			rs := config.NewRuntimeState(&config.FrameworkConfig{})
+			rs.SetNodeID("node-1")
+			rs.UpdateMooxServerURL(srv.URL)
+			r := NewTaskReporter(rs, WithAsyncWorkers(tt.workers, tt.queueSize))
+
+			for i := 0; i < tt.reports; i++ {
+				r.ReportAsync(context.Background(), fmt.Sprintf("task-%d", i), model.TaskStatusSuccess, "")
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for int(cp.inflight.Load()) < tt.wantPeak && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			// 所有 worker 都阻塞后再观察一段时间，并发数不应继续增长
+			time.Sleep(50 * time.Millisecond)
+			if peak := int(cp.peak.Load()); peak != tt.wantPeak {
+				t.Fatalf("peak concurrent reports = %d, want %d", peak, tt.wantPeak)
+			}
+
+			releaseAll()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := r.Wait(ctx); err != nil {
+				t.Fatalf("Wait() error = %v", err)
+			}
+			workers, queueSize := tt.workers, tt.queueSize
+			if workers == 0 {
+				workers, queueSize = DefaultAsyncWorkers, DefaultAsyncQueueSize
+			}
+			// 队列满时丢弃：送达数不超过 worker 数 + 积压上限
+			if served := int(cp.served.Load()); served > workers+queueSize || served < queueSize {
+				t.Fatalf("served %d reports, want between %d and %d", served, queueSize, workers+queueSize)
+			}
+		})
+	}
+}
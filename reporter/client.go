@@ -0,0 +1,139 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go"
+)
+
+// Client 控制面 HTTP 客户端，心跳与任务状态上报共用：统一处理 scheme（http/https）、
+// Authorization 鉴权头与重试策略
+type Client struct {
+	http      *http.Client
+	useTLS    bool
+	authToken string
+}
+
+// ClientOption Client 的选项函数
+type ClientOption func(*Client)
+
+// WithTLS 使用 https 访问控制面：服务端地址为 http:// 或未带 scheme 时改写为 https://
+func WithTLS(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.useTLS = enabled
+	}
+}
+
+// WithAuthToken 每个请求携带 "Authorization: Bearer <token>"，空串表示不鉴权
+func WithAuthToken(token string) ClientOption {
+	return func(c *Client) {
+		c.authToken = token
+	}
+}
+
+// NewClient 创建控制面 HTTP 客户端
+func NewClient(timeout time.Duration, opts ...ClientOption) *Client {
+	c := &Client{http: &http.Client{Timeout: timeout}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// URL 按 TLS 配置规范化服务端地址的 scheme 后拼接路径
+func (c *Client) URL(base, path string) string {
+	switch {
+	case strings.HasPrefix(base, "https://"):
+	case strings.HasPrefix(base, "http://"):
+		if c.useTLS {
+			base = "https://" + strings.TrimPrefix(base, "http://")
+		}
+	case c.useTLS:
+		base = "https://" + base
+	default:
+		base = "http://" + base
+	}
+	return JoinURL(base, path)
+}
+
+// RetryPolicy 重试策略：最多 Attempts 次，以 Delay 为基础指数退避
+type RetryPolicy struct {
+	Attempts uint
+	Delay    time.Duration
+}
+
+// Request 一次控制面 JSON POST 请求
+type Request struct {
+	URL     string
+	Body    []byte
+	Header  http.Header             // 额外请求头，每次重试都会携带；含 Content-Type 时覆盖默认的 application/json
+	Retry   RetryPolicy             // 零值表示只尝试一次
+	OnRetry func(n uint, err error) // 重试回调，可为 nil
+}
+
+// PostJSON 发送 JSON POST 请求并返回 200 响应 body；非 200 状态按 ClassifyStatusError
+// 判断是否重试（4xx 除 429 外不重试）
+func (c *Client) PostJSON(ctx context.Context, req Request) ([]byte, error) {
+	var respData []byte
+
+	attempts := req.Retry.Attempts
+	if attempts == 0 {
+		attempts = 1
+	}
+
+	retryOpts := []retry.Option{
+		retry.Attempts(attempts),
+		retry.Delay(req.Retry.Delay),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.Context(ctx),
+	}
+	if req.OnRetry != nil {
+		retryOpts = append(retryOpts, retry.OnRetry(req.OnRetry))
+	}
+
+	err := retry.Do(
+		func() error {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+			if err != nil {
+				return retry.Unrecoverable(fmt.Errorf("failed to create request: %w", err))
+			}
+			if req.Header.Get("Content-Type") == "" {
+				httpReq.Header.Set("Content-Type", "application/json")
+			}
+			for key, values := range req.Header {
+				for _, v := range values {
+					httpReq.Header.Add(key, v)
+				}
+			}
+			if c.authToken != "" {
+				httpReq.Header.Set("Authorization", "Bearer "+c.authToken)
+			}
+
+			resp, err := c.http.Do(httpReq)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK {
+				return ClassifyStatusError(resp.StatusCode,
+					fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body)))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read response body: %w", err)
+			}
+			respData = body
+			return nil
+		},
+		retryOpts...,
+	)
+	return respData, err
+}
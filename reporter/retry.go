@@ -0,0 +1,24 @@
+package reporter
+
+import (
+	"net/http"
+
+	"github.com/avast/retry-go"
+)
+
+// IsRetryableStatus 判断 HTTP 状态码是否值得重试：5xx 与 429 重试，其余 4xx 视为永久失败
+func IsRetryableStatus(statusCode int) bool {
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return statusCode < http.StatusBadRequest || statusCode >= http.StatusInternalServerError
+}
+
+// ClassifyStatusError 按状态码包装非 200 响应错误：
+// 不可重试的状态码返回 retry.Unrecoverable(err)，使 retry.Do 立即停止；否则原样返回
+func ClassifyStatusError(statusCode int, err error) error {
+	if err == nil || IsRetryableStatus(statusCode) {
+		return err
+	}
+	return retry.Unrecoverable(err)
+}
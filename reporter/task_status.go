@@ -1,35 +1,143 @@
 package reporter
 
 import (
-	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"sync"
 	"time"
 
-	"github.com/avast/retry-go"
 	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/metrics"
+	"github.com/mooyang-code/scf-framework/model"
 	"trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/log"
 )
 
 // TaskReporter 任务状态上报器
 type TaskReporter struct {
-	runtime *config.RuntimeState
-	client  *http.Client
+	runtime       *config.RuntimeState
+	client        *Client
+	clientOpts    []ClientOption
+	successStatus int               // 上报给服务端的成功状态码
+	failedStatus  int               // 上报给服务端的失败状态码
+	reportPath    string            // 上报接口路径
+	encoder       TaskStatusEncoder // 上报请求体编码
+
+	pending sync.WaitGroup   // 进行中的异步上报
+	metrics *metrics.Metrics // 可为 nil
+
+	// 异步上报工作池（见 async.go）
+	asyncWorkers   int
+	asyncQueueSize int
+	queue          chan func()
+	startOnce      sync.Once
+
+	// 批量上报（见 task_status_batch.go）
+	batchPath     string        // 批量上报接口路径
+	batchInterval time.Duration // 缓冲模式攒批时间，<= 0 表示不启用缓冲
+	batchMaxSize  int           // 单批最大任务数
+	batchMu       sync.Mutex
+	batchBuf      []TaskStatusUpdate
+	batchCtx      context.Context // 缓冲中首条上报的 context（已 CloneContext）
+	batchTimer    *time.Timer
+}
+
+// TaskReporterOption TaskReporter 的选项函数
+type TaskReporterOption func(*TaskReporter)
+
+// WithStatusCodes 设置上报给服务端的成功/失败状态码（默认 model.TaskStatusSuccess/model.TaskStatusFailed）
+func WithStatusCodes(success, failed int) TaskReporterOption {
+	return func(r *TaskReporter) {
+		r.successStatus = success
+		r.failedStatus = failed
+	}
+}
+
+// WithReportPath 设置任务状态上报接口路径（默认 DefaultTaskStatusPath），空串保持默认
+func WithReportPath(path string) TaskReporterOption {
+	return func(r *TaskReporter) {
+		if path != "" {
+			r.reportPath = path
+		}
+	}
+}
+
+// WithClientOptions 设置控制面 HTTP 客户端选项（TLS、鉴权等）
+func WithClientOptions(opts ...ClientOption) TaskReporterOption {
+	return func(r *TaskReporter) {
+		r.clientOpts = append(r.clientOpts, opts...)
+	}
+}
+
+// WithMetrics 设置任务状态上报结果的指标收集
+func WithMetrics(m *metrics.Metrics) TaskReporterOption {
+	return func(r *TaskReporter) {
+		r.metrics = m
+	}
+}
+
+// WithEncoder 设置任务状态上报请求体的编码方式，nil 保持默认 JSON 结构
+func WithEncoder(enc TaskStatusEncoder) TaskReporterOption {
+	return func(r *TaskReporter) {
+		if enc != nil {
+			r.encoder = enc
+		}
+	}
 }
 
 // NewTaskReporter 创建 TaskReporter
-func NewTaskReporter(rs *config.RuntimeState) *TaskReporter {
-	return &TaskReporter{
-		runtime: rs,
-		client:  &http.Client{Timeout: 10 * time.Second},
+func NewTaskReporter(rs *config.RuntimeState, opts ...TaskReporterOption) *TaskReporter {
+	r := &TaskReporter{
+		runtime:       rs,
+		successStatus: model.TaskStatusSuccess,
+		failedStatus:  model.TaskStatusFailed,
+		reportPath:    DefaultTaskStatusPath,
+		encoder:       DefaultTaskStatusEncoder,
+		batchPath:     DefaultTaskStatusBatchPath,
+		batchMaxSize:  DefaultBatchMaxSize,
+
+		asyncWorkers:   DefaultAsyncWorkers,
+		asyncQueueSize: DefaultAsyncQueueSize,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.queue = make(chan func(), r.asyncQueueSize)
+	r.client = NewClient(10*time.Second, r.clientOpts...)
+	return r
+}
+
+// mapStatus 将框架内部状态（model.TaskStatusSuccess/Failed）映射为服务端状态码，其他值原样透传
+func (r *TaskReporter) mapStatus(status int) int {
+	switch status {
+	case model.TaskStatusSuccess:
+		return r.successStatus
+	case model.TaskStatusFailed:
+		return r.failedStatus
+	default:
+		return status
 	}
 }
 
-// reportTaskStatusRequest 上报请求体
+// TaskStatusEncoder 将一次任务状态上报编码为请求体及其 Content-Type，
+// 用于适配字段名或结构不同的控制面接口。status 为已映射后的服务端状态码
+type TaskStatusEncoder interface {
+	Encode(taskID, nodeID string, status int, result string) (body []byte, contentType string, err error)
+}
+
+// TaskStatusEncoderFunc 函数形式的 TaskStatusEncoder
+type TaskStatusEncoderFunc func(taskID, nodeID string, status int, result string) ([]byte, string, error)
+
+// Encode 实现 TaskStatusEncoder
+func (f TaskStatusEncoderFunc) Encode(taskID, nodeID string, status int, result string) ([]byte, string, error) {
+	return f(taskID, nodeID, status, result)
+}
+
+// reportTaskStatusRequest 默认上报请求体
 type reportTaskStatusRequest struct {
 	ID     string `json:"id"`
 	NodeID string `json:"node_id"`
@@ -37,20 +145,73 @@ type reportTaskStatusRequest struct {
 	Result string `json:"result"`
 }
 
+// DefaultTaskStatusEncoder 默认编码：{"id","node_id","status","result"} JSON
+var DefaultTaskStatusEncoder TaskStatusEncoder = TaskStatusEncoderFunc(
+	func(taskID, nodeID string, status int, result string) ([]byte, string, error) {
+		data, err := json.Marshal(reportTaskStatusRequest{
+			ID:     taskID,
+			NodeID: nodeID,
+			Status: status,
+			Result: result,
+		})
+		return data, "application/json", err
+	})
+
+// IdempotencyKeyHeader 任务状态上报携带的幂等键请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// newIdempotencyKey 生成一次逻辑上报的幂等键：taskID:status:随机串
+func newIdempotencyKey(taskID string, status int) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s:%d:%d", taskID, status, time.Now().UnixNano())
+	}
+	return fmt.Sprintf("%s:%d:%s", taskID, status, hex.EncodeToString(b))
+}
+
 // ReportAsync 异步上报任务状态，不阻塞调用方。
 // 使用 trpc.CloneContext 创建脱离 deadline 但保留日志字段的 context，
-// 避免调用方 context 取消导致上报中断。
+// 避免调用方 context 取消导致上报中断。上报由固定大小的工作池执行（WithAsyncWorkers），积压队列满时丢弃并告警；
+// 启用缓冲模式（WithBatching）时攒批后经 ReportBatch 发送
 func (r *TaskReporter) ReportAsync(ctx context.Context, taskID string, status int, result string) {
 	log.InfoContextf(ctx, "[TaskReporter] start async report: taskID=%s, status=%d", taskID, status)
+	if r.batchInterval > 0 {
+		r.enqueue(ctx, TaskStatusUpdate{TaskID: taskID, Status: status, Result: result})
+		return
+	}
 	asyncCtx := trpc.CloneContext(ctx)
-	go func() {
+	r.pending.Add(1)
+	ok := r.submit(func() {
+		defer r.pending.Done()
 		if err := r.Report(asyncCtx, taskID, status, result); err != nil {
 			log.ErrorContextf(asyncCtx, "[TaskReporter] async report failed: taskID=%s, status=%d, error=%v", taskID, status, err)
 		}
+	})
+	if !ok {
+		r.pending.Done()
+		r.metrics.ObserveTaskReport(ErrReportQueueFull)
+		log.WarnContextf(ctx, "[TaskReporter] async queue full (size=%d), drop report: taskID=%s, status=%d",
+			r.asyncQueueSize, taskID, status)
+	}
+}
+
+// Wait 立即发送缓冲中的状态并等待进行中的异步上报完成，ctx 到期时返回错误（退出前调用，避免丢失最终状态）
+func (r *TaskReporter) Wait(ctx context.Context) error {
+	r.flushBuffered()
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
 	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait pending task reports: %w", ctx.Err())
+	}
 }
 
-// Report 同步上报任务状态，3 次重试 + 指数退避
+// Report 同步上报任务状态，3 次重试 + 指数退避（4xx 除 429 外不重试）
 func (r *TaskReporter) Report(ctx context.Context, taskID string, status int, result string) error {
 	mooxServerURL := r.runtime.GetMooxServerURL()
 	if mooxServerURL == "" {
@@ -59,52 +220,33 @@ func (r *TaskReporter) Report(ctx context.Context, taskID string, status int, re
 	}
 
 	nodeID := r.runtime.GetNodeID()
-	url := mooxServerURL + "/gateway/collectmgr/ReportTaskStatus"
+	url := r.client.URL(mooxServerURL, r.reportPath)
 
-	reqBody := reportTaskStatusRequest{
-		ID:     taskID,
-		NodeID: nodeID,
-		Status: status,
-		Result: result,
-	}
+	mapped := r.mapStatus(status)
 
-	data, err := json.Marshal(reqBody)
+	data, contentType, err := r.encoder.Encode(taskID, nodeID, mapped, result)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	// 幂等键在重试循环外生成：同一次逻辑上报的所有重试携带相同的 key，服务端据此去重
+	header := http.Header{IdempotencyKeyHeader: []string{newIdempotencyKey(taskID, mapped)}}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
 	}
 
-	log.InfoContextf(ctx, "[TaskReporter] reporting: taskID=%s, nodeID=%s, status=%d, url=%s", taskID, nodeID, status, url)
-
-	err = retry.Do(
-		func() error {
-			req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
-			if err != nil {
-				return fmt.Errorf("failed to create request: %w", err)
-			}
-			req.Header.Set("Content-Type", "application/json")
-
-			resp, err := r.client.Do(req)
-			if err != nil {
-				return err
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				body, _ := io.ReadAll(resp.Body)
-				return fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
-			}
+	log.InfoContextf(ctx, "[TaskReporter] reporting: taskID=%s, nodeID=%s, status=%d, url=%s, idempotencyKey=%s",
+		taskID, nodeID, status, url, header.Get(IdempotencyKeyHeader))
 
-			return nil
-		},
-		retry.Attempts(3),
-		retry.Delay(500*time.Millisecond),
-		retry.DelayType(retry.BackOffDelay),
-		retry.LastErrorOnly(true),
-		retry.OnRetry(func(n uint, err error) {
+	_, err = r.client.PostJSON(ctx, Request{
+		URL:    url,
+		Body:   data,
+		Header: header,
+		Retry:  RetryPolicy{Attempts: 3, Delay: 500 * time.Millisecond},
+		OnRetry: func(n uint, err error) {
 			log.WarnContextf(ctx, "[TaskReporter] retrying: taskID=%s, attempt=%d, error=%v", taskID, n+1, err)
-		}),
-		retry.Context(ctx),
-	)
+		},
+	})
+	r.metrics.ObserveTaskReport(err)
 
 	if err != nil {
 		log.ErrorContextf(ctx, "[TaskReporter] report failed after retries: taskID=%s, status=%d, error=%v", taskID, status, err)
@@ -0,0 +1,153 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// 批量上报默认值
+const (
+	DefaultBatchFlushInterval = 200 * time.Millisecond // 缓冲模式下的最长攒批时间
+	DefaultBatchMaxSize       = 100                    // 单次批量上报的最大任务数
+)
+
+// TaskStatusUpdate 一条任务状态（批量上报的元素），Status 为框架内部状态（model.TaskStatusSuccess/Failed 等）
+type TaskStatusUpdate struct {
+	TaskID string
+	Status int
+	Result string
+}
+
+// WithBatchReportPath 设置批量上报接口路径（默认 DefaultTaskStatusBatchPath），空串保持默认
+func WithBatchReportPath(path string) TaskReporterOption {
+	return func(r *TaskReporter) {
+		if path != "" {
+			r.batchPath = path
+		}
+	}
+}
+
+// WithBatching 启用缓冲模式：ReportAsync 不再逐条发送，而是攒批后经 ReportBatch 上报。
+// 首条入缓冲后最多等待 interval，或缓冲达到 maxSize 条时立即发送；
+// interval <= 0 表示不启用（默认），maxSize <= 0 使用 DefaultBatchMaxSize
+func WithBatching(interval time.Duration, maxSize int) TaskReporterOption {
+	return func(r *TaskReporter) {
+		r.batchInterval = interval
+		if maxSize > 0 {
+			r.batchMaxSize = maxSize
+		}
+	}
+}
+
+// enqueue 缓冲模式下加入一条待上报状态，缓冲满时立即发送
+func (r *TaskReporter) enqueue(ctx context.Context, u TaskStatusUpdate) {
+	r.batchMu.Lock()
+	if len(r.batchBuf) == 0 {
+		// 每个非空缓冲对应一次 pending 计数，由 flushBuffered 发送完成后释放，Wait 据此等待
+		r.pending.Add(1)
+		r.batchCtx = trpc.CloneContext(ctx)
+		r.batchTimer = time.AfterFunc(r.batchInterval, r.flushBuffered)
+	}
+	r.batchBuf = append(r.batchBuf, u)
+	full := len(r.batchBuf) >= r.batchMaxSize
+	r.batchMu.Unlock()
+
+	if full {
+		r.flushBuffered()
+	}
+}
+
+// flushBuffered 取出当前缓冲并交由工作池批量上报，缓冲为空时不做任何事
+func (r *TaskReporter) flushBuffered() {
+	r.batchMu.Lock()
+	batch, ctx := r.batchBuf, r.batchCtx
+	r.batchBuf, r.batchCtx = nil, nil
+	if r.batchTimer != nil {
+		r.batchTimer.Stop()
+		r.batchTimer = nil
+	}
+	r.batchMu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+	ok := r.submit(func() {
+		defer r.pending.Done()
+		if err := r.ReportBatch(ctx, batch); err != nil {
+			log.ErrorContextf(ctx, "[TaskReporter] async batch report failed: count=%d, error=%v", len(batch), err)
+		}
+	})
+	if !ok {
+		r.pending.Done()
+		for range batch {
+			r.metrics.ObserveTaskReport(ErrReportQueueFull)
+		}
+		log.WarnContextf(ctx, "[TaskReporter] async queue full (size=%d), drop batch: count=%d", r.asyncQueueSize, len(batch))
+	}
+}
+
+// ReportBatch 同步批量上报任务状态：将每条状态按 encoder 编码后组成 JSON 数组，一次 POST 到批量接口，
+// 3 次重试 + 指数退避（4xx 除 429 外不重试）。encoder 需产出 JSON
+func (r *TaskReporter) ReportBatch(ctx context.Context, updates []TaskStatusUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	mooxServerURL := r.runtime.GetMooxServerURL()
+	if mooxServerURL == "" {
+		log.WarnContextf(ctx, "[TaskReporter] skip batch report: moox server URL not available")
+		return nil
+	}
+
+	nodeID := r.runtime.GetNodeID()
+	url := r.client.URL(mooxServerURL, r.batchPath)
+
+	items := make([]json.RawMessage, 0, len(updates))
+	for _, u := range updates {
+		data, _, err := r.encoder.Encode(u.TaskID, nodeID, r.mapStatus(u.Status), u.Result)
+		if err != nil {
+			return fmt.Errorf("failed to encode task %s: %w", u.TaskID, err)
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("failed to encode task %s: batch report requires JSON encoding", u.TaskID)
+		}
+		items = append(items, data)
+	}
+	body, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to encode batch request: %w", err)
+	}
+	header := http.Header{
+		IdempotencyKeyHeader: []string{newIdempotencyKey("batch", len(updates))},
+		"Content-Type":       []string{"application/json"},
+	}
+
+	log.InfoContextf(ctx, "[TaskReporter] batch reporting: count=%d, nodeID=%s, url=%s, idempotencyKey=%s",
+		len(updates), nodeID, url, header.Get(IdempotencyKeyHeader))
+
+	_, err = r.client.PostJSON(ctx, Request{
+		URL:    url,
+		Body:   body,
+		Header: header,
+		Retry:  RetryPolicy{Attempts: 3, Delay: 500 * time.Millisecond},
+		OnRetry: func(n uint, err error) {
+			log.WarnContextf(ctx, "[TaskReporter] retrying batch: count=%d, attempt=%d, error=%v", len(updates), n+1, err)
+		},
+	})
+	for range updates {
+		r.metrics.ObserveTaskReport(err)
+	}
+
+	if err != nil {
+		log.ErrorContextf(ctx, "[TaskReporter] batch report failed after retries: count=%d, error=%v", len(updates), err)
+		return err
+	}
+
+	log.InfoContextf(ctx, "[TaskReporter] batch report success: count=%d", len(updates))
+	return nil
+}
@@ -0,0 +1,172 @@
+package reporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// capturedRequest 测试控制面收到的请求
+type capturedRequest struct {
+	path        string
+	body        string
+	contentType string
+	idemKey     string
+}
+
+// testControlPlane 记录请求并按 status 响应的测试控制面
+type testControlPlane struct {
+	mu       sync.Mutex
+	requests []capturedRequest
+	status   int
+}
+
+func (c *testControlPlane) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	c.requests = append(c.requests, capturedRequest{
+		path:        r.URL.Path,
+		body:        string(body),
+		contentType: r.Header.Get("Content-Type"),
+		idemKey:     r.Header.Get(IdempotencyKeyHeader),
+	})
+	status := c.status
+	c.mu.Unlock()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+}
+
+func (c *testControlPlane) captured() []capturedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]capturedRequest(nil), c.requests...)
+}
+
+// newTestReporter 创建上报到测试控制面的 TaskReporter
+func newTestReporter(t *testing.T, cp *testControlPlane, opts ...TaskReporterOption) *TaskReporter {
+	t.Helper()
+	srv := httptest.NewServer(cp)
+	t.Cleanup(srv.Close)
+	rs := config.NewRuntimeState(&config.FrameworkConfig{})
+	rs.SetNodeID("node-1")
+	rs.UpdateMooxServerURL(srv.URL)
+	return NewTaskReporter(rs, opts...)
+}
+
+func TestTaskStatusEncoder(t *testing.T) {
+	nested := TaskStatusEncoderFunc(func(taskID, nodeID string, status int, result string) ([]byte, string, error) {
+		return []byte(fmt.Sprintf(`{"task":{"task_id":%q,"node":%q},"state":%d}`, taskID, nodeID, status)), "application/vnd.moox+json", nil
+	})
+	form := TaskStatusEncoderFunc(func(taskID, nodeID string, status int, result string) ([]byte, string, error) {
+		return []byte(fmt.Sprintf("task_id=%s&status=%d", taskID, status)), "application/x-www-form-urlencoded", nil
+	})
+	failing := TaskStatusEncoderFunc(func(string, string, int, string) ([]byte, string, error) {
+		return nil, "", errors.New("unsupported")
+	})
+
+	tests := []struct {
+		name            string
+		opts            []TaskReporterOption
+		wantBody        string
+		wantContentType string
+		wantErr         bool
+	}{
+		{
+			name:            "default shape",
+			wantBody:        `{"id":"t1","node_id":"node-1","status":2,"result":"ok"}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "nil encoder keeps default",
+			opts:            []TaskReporterOption{WithEncoder(nil)},
+			wantBody:        `{"id":"t1","node_id":"node-1","status":2,"result":"ok"}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "custom nested encoder with mapped status",
+			opts:            []TaskReporterOption{WithEncoder(nested), WithStatusCodes(20, 30)},
+			wantBody:        `{"task":{"task_id":"t1","node":"node-1"},"state":20}`,
+			wantContentType: "application/vnd.moox+json",
+		},
+		{
+			name:            "non-JSON encoder",
+			opts:            []TaskReporterOption{WithEncoder(form)},
+			wantBody:        "task_id=t1&status=2",
+			wantContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:    "encoder error is returned without request",
+			opts:    []TaskReporterOption{WithEncoder(failing)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := &testControlPlane{}
+			r := newTestReporter(t, cp, tt.opts...)
+
+			err := r.Report(context.Background(), "t1", model.TaskStatusSuccess, "ok")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Report() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			reqs := cp.captured()
+			if tt.wantErr {
+				if len(reqs) != 0 {
+					t.Fatalf("sent %d requests after encoder error, want 0", len(reqs))
+				}
+				return
+			}
+			if len(reqs) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(reqs))
+			}
+			if reqs[0].body != tt.wantBody || reqs[0].contentType != tt.wantContentType {
+				t.Fatalf("request body/content-type = %s / %s, want %s / %s",
+					reqs[0].body, reqs[0].contentType, tt.wantBody, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestReportBatchRequiresJSONEncoder(t *testing.T) {
+	form := TaskStatusEncoderFunc(func(taskID, nodeID string, status int, result string) ([]byte, string, error) {
+		return []byte("task_id=" + taskID), "application/x-www-form-urlencoded", nil
+	})
+	tests := []struct {
+		name     string
+		encoder  TaskStatusEncoder
+		wantBody string
+		wantErr  bool
+	}{
+		{name: "default encoder", wantBody: `[{"id":"a","node_id":"node-1","status":2,"result":""},{"id":"b","node_id":"node-1","status":4,"result":"x"}]`},
+		{name: "non-JSON encoder rejected", encoder: form, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := &testControlPlane{}
+			r := newTestReporter(t, cp, WithEncoder(tt.encoder))
+			err := r.ReportBatch(context.Background(), []TaskStatusUpdate{
+				{TaskID: "a", Status: model.TaskStatusSuccess},
+				{TaskID: "b", Status: model.TaskStatusFailed, Result: "x"},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReportBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if reqs := cp.captured(); len(reqs) != 1 || reqs[0].body != tt.wantBody {
+				t.Fatalf("batch requests = %+v, want body %s", reqs, tt.wantBody)
+			}
+		})
+	}
+}
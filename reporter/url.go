@@ -0,0 +1,18 @@
+package reporter
+
+import "strings"
+
+// 默认的控制面上报路径
+const (
+	DefaultHeartbeatPath       = "/gateway/cloudnode/ReportHeartbeatInner"
+	DefaultTaskStatusPath      = "/gateway/collectmgr/ReportTaskStatus"
+	DefaultTaskStatusBatchPath = "/gateway/collectmgr/ReportTaskStatusBatch"
+)
+
+// JoinURL 拼接服务端地址与路径，去除多余的斜杠（"http://a/" + "/b" -> "http://a/b"）
+func JoinURL(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
@@ -0,0 +1,125 @@
+// Package runner 可选的任务执行辅助：按定时触发遍历本节点任务、筛选到期任务并逐个执行，
+// 执行结果交由框架经 TaskReporter 上报，插件只需实现单个任务的执行逻辑
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/plugin"
+	"github.com/mooyang-code/scf-framework/trigger"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// ExecuteFunc 执行单个到期任务，返回 model.TaskStatusSuccess/model.TaskStatusFailed 及结果说明（失败原因，成功时可为空）
+type ExecuteFunc func(ctx context.Context, task *model.TaskInstance) (status int, result string)
+
+// DueFunc 判断任务在 now 时刻是否到期
+type DueFunc func(task *model.TaskInstance, now time.Time) bool
+
+// Option TaskRunner 的选项函数
+type Option func(*TaskRunner)
+
+// WithDue 自定义到期判断（默认 DueByIntervals）
+func WithDue(fn DueFunc) Option {
+	return func(r *TaskRunner) {
+		if fn != nil {
+			r.due = fn
+		}
+	}
+}
+
+// WithConcurrency 设置同一次 Run 中并行执行的任务数（默认 1，串行）
+func WithConcurrency(n int) Option {
+	return func(r *TaskRunner) {
+		if n > 0 {
+			r.concurrency = n
+		}
+	}
+}
+
+// DueByIntervals 默认到期判断：task_params 的 intervals 中任一周期在 now 到期（同 timer 触发器的框架调度筛选）
+func DueByIntervals(task *model.TaskInstance, now time.Time) bool {
+	return len(trigger.FilterTaskJobs([]*model.TaskInstance{task}, now)) > 0
+}
+
+// TaskRunner 任务执行循环：每次 Run 取本节点的有效任务（TaskStore.GetByNode），对到期任务调用 ExecuteFunc
+type TaskRunner struct {
+	fw          plugin.Framework
+	execute     ExecuteFunc
+	due         DueFunc
+	concurrency int
+}
+
+// New 创建 TaskRunner，通常在插件 Init 中创建：
+//
+//	p.runner = runner.New(fw, p.collect)
+func New(fw plugin.Framework, execute ExecuteFunc, opts ...Option) *TaskRunner {
+	r := &TaskRunner{
+		fw:          fw,
+		execute:     execute,
+		due:         DueByIntervals,
+		concurrency: 1,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run 执行 now 时刻本节点所有到期任务（每个任务一次），结果以 TaskResults 返回，由框架异步上报。
+// 在 timer 触发器的 OnTrigger 中直接返回即可：
+//
+//	return p.runner.Run(ctx, time.Now().UTC())
+func (r *TaskRunner) Run(ctx context.Context, now time.Time) (*model.TriggerResponse, error) {
+	nodeID := r.fw.Runtime().GetNodeID()
+	if nodeID == "" {
+		log.WarnContextf(ctx, "[TaskRunner] node ID not available yet, skip run")
+		return nil, nil
+	}
+
+	var due []*model.TaskInstance
+	for _, task := range r.fw.TaskStore().GetByNode(nodeID) {
+		if r.due(task, now) {
+			due = append(due, task)
+		}
+	}
+	if len(due) == 0 {
+		log.DebugContextf(ctx, "[TaskRunner] no due tasks at %s", now.Format(time.RFC3339))
+		return nil, nil
+	}
+	log.InfoContextf(ctx, "[TaskRunner] executing %d due tasks, concurrency=%d", len(due), r.concurrency)
+
+	results := make([]model.TaskResult, len(due))
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i, task := range due {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			status, result := r.runOne(ctx, task)
+			results[i] = model.TaskResult{TaskID: task.TaskID, Status: status, Result: result}
+		}()
+	}
+	wg.Wait()
+
+	return &model.TriggerResponse{TaskResults: results}, nil
+}
+
+// runOne 执行单个任务，panic 视为执行失败
+func (r *TaskRunner) runOne(ctx context.Context, task *model.TaskInstance) (status int, result string) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.ErrorContextf(ctx, "[TaskRunner] task %s panic: %v", task.TaskID, p)
+			status, result = model.TaskStatusFailed, fmt.Sprintf("panic: %v", p)
+		}
+	}()
+	return r.execute(ctx, task)
+}
@@ -0,0 +1,78 @@
+// Package tracing 框架级 OpenTelemetry 链路追踪：触发器 → 插件 → 转发的 span 创建与 W3C traceparent 传播
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName 框架 tracer 的 instrumentation 名称
+const instrumentationName = "github.com/mooyang-code/scf-framework"
+
+// propagator W3C Trace Context（traceparent/tracestate）
+var propagator = propagation.TraceContext{}
+
+// noopTracer 未配置 TracerProvider 时使用
+var noopTracer = noop.NewTracerProvider().Tracer(instrumentationName)
+
+// Tracer 框架 tracer。所有方法对 nil 接收者安全，未启用追踪时调用方无需判空
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New 基于 tp 创建 Tracer；tp 为 nil 时为 no-op
+func New(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		return &Tracer{tracer: noopTracer}
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+// Start 创建子 span，返回携带该 span 的 context
+func (t *Tracer) Start(ctx context.Context, name string, kind trace.SpanKind,
+	attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tr := noopTracer
+	if t != nil && t.tracer != nil {
+		tr = t.tracer
+	}
+	return tr.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 非 nil 时记录错误并将状态置为 Error
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject 将 ctx 中的 span 以 traceparent/tracestate 写入请求头
+func Inject(ctx context.Context, h http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// Extract 从请求头读取 traceparent/tracestate，返回以其为父 span 的 context；无有效头时原样返回
+func Extract(ctx context.Context, h http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(h))
+}
+
+// ExtractMap 同 Extract，从 map 形式的消息头（如 TriggerEvent.Headers()）读取，key 不区分大小写；
+// ctx 中已有有效 span 时不覆盖
+func ExtractMap(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 || trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	carrier := make(propagation.MapCarrier, len(headers))
+	for k, v := range headers {
+		carrier[strings.ToLower(k)] = v
+	}
+	return propagator.Extract(ctx, carrier)
+}
@@ -0,0 +1,193 @@
+package trigger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// TestHarness 触发器测试脚手架：作为 TriggerHandler 捕获触发器派发的事件，并按预设结果返回，
+// 便于在测试中验证触发器对源消息的转换以及成功 Ack / 失败 Nak 的处理。仅供测试使用。
+type TestHarness struct {
+	mu       sync.Mutex
+	events   []*model.TriggerEvent
+	results  []error // 依次返回的 handler 结果，耗尽后返回 fallback
+	fallback error
+	notify   chan struct{}
+}
+
+// NewTestHarness 创建 TestHarness，默认 handler 返回 nil
+func NewTestHarness() *TestHarness {
+	return &TestHarness{notify: make(chan struct{}, 1)}
+}
+
+// Handler 返回捕获事件的 TriggerHandler，可直接传给 Trigger.Start
+func (h *TestHarness) Handler() TriggerHandler {
+	return func(_ context.Context, event *model.TriggerEvent) error {
+		h.mu.Lock()
+		h.events = append(h.events, event)
+		err := h.fallback
+		if len(h.results) > 0 {
+			err = h.results[0]
+			h.results = h.results[1:]
+		}
+		h.mu.Unlock()
+
+		select {
+		case h.notify <- struct{}{}:
+		default:
+		}
+		return err
+	}
+}
+
+// Return 按顺序设置后续 handler 调用的返回值
+func (h *TestHarness) Return(errs ...error) {
+	h.mu.Lock()
+	h.results = append(h.results, errs...)
+	h.mu.Unlock()
+}
+
+// SetFallback 设置预设结果耗尽后 handler 的返回值
+func (h *TestHarness) SetFallback(err error) {
+	h.mu.Lock()
+	h.fallback = err
+	h.mu.Unlock()
+}
+
+// Events 返回已捕获事件的副本
+func (h *TestHarness) Events() []*model.TriggerEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*model.TriggerEvent(nil), h.events...)
+}
+
+// WaitEvents 等待至少捕获 n 个事件（适用于异步消费循环），ctx 到期时返回已捕获的事件与 ctx 错误
+func (h *TestHarness) WaitEvents(ctx context.Context, n int) ([]*model.TriggerEvent, error) {
+	for {
+		events := h.Events()
+		if len(events) >= n {
+			return events, nil
+		}
+		select {
+		case <-h.notify:
+		case <-ctx.Done():
+			return events, ctx.Err()
+		}
+	}
+}
+
+// Reset 清空已捕获事件与预设结果
+func (h *TestHarness) Reset() {
+	h.mu.Lock()
+	h.events = nil
+	h.results = nil
+	h.fallback = nil
+	h.mu.Unlock()
+}
+
+// DriveNATS 不连接 NATS，直接以 harness 为 handler 让 t 逐条处理 msgs，返回本次捕获的事件。
+// t 需已完成 Init；处理后通过 FakeNATSMsg.Decision 断言 Ack/Nak 结果
+func (h *TestHarness) DriveNATS(ctx context.Context, t *NATSTrigger, msgs ...*FakeNATSMsg) []*model.TriggerEvent {
+	before := len(h.Events())
+	t.handler = h.Handler()
+	for _, msg := range msgs {
+		t.processMessage(ctx, msg)
+	}
+	return h.Events()[before:]
+}
+
+// AckDecision 消息的确认结果
+type AckDecision string
+
+const (
+	AckPending    AckDecision = ""            // 未确认
+	AckAcked      AckDecision = "ack"         // Ack / DoubleAck
+	AckNaked      AckDecision = "nak"         // Nak / NakWithDelay
+	AckTerminated AckDecision = "term"        // Term / TermWithReason
+	AckInProgress AckDecision = "in_progress" // 仅调用了 InProgress
+)
+
+// FakeNATSMsg 实现 jetstream.Msg 的测试消息，记录触发器对其做出的确认决定
+type FakeNATSMsg struct {
+	MsgSubject string
+	MsgData    []byte
+	MsgHeaders nats.Header
+	MsgMeta    *jetstream.MsgMetadata
+
+	mu       sync.Mutex
+	decision AckDecision
+}
+
+// NewFakeNATSMsg 创建测试消息
+func NewFakeNATSMsg(subject string, data []byte) *FakeNATSMsg {
+	return &FakeNATSMsg{MsgSubject: subject, MsgData: data, MsgHeaders: nats.Header{}}
+}
+
+// Decision 返回最终的确认结果
+func (m *FakeNATSMsg) Decision() AckDecision {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.decision
+}
+
+func (m *FakeNATSMsg) setDecision(d AckDecision) error {
+	m.mu.Lock()
+	m.decision = d
+	m.mu.Unlock()
+	return nil
+}
+
+// Metadata 返回预设的消息元数据，未设置时返回空元数据
+func (m *FakeNATSMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	if m.MsgMeta != nil {
+		return m.MsgMeta, nil
+	}
+	return &jetstream.MsgMetadata{Timestamp: time.Now()}, nil
+}
+
+// Data 返回消息体
+func (m *FakeNATSMsg) Data() []byte { return m.MsgData }
+
+// Headers 返回消息头
+func (m *FakeNATSMsg) Headers() nats.Header { return m.MsgHeaders }
+
+// Subject 返回消息 subject
+func (m *FakeNATSMsg) Subject() string { return m.MsgSubject }
+
+// Reply 测试消息无 reply subject
+func (m *FakeNATSMsg) Reply() string { return "" }
+
+// Ack 记录 Ack
+func (m *FakeNATSMsg) Ack() error { return m.setDecision(AckAcked) }
+
+// DoubleAck 记录 Ack
+func (m *FakeNATSMsg) DoubleAck(context.Context) error { return m.setDecision(AckAcked) }
+
+// Nak 记录 Nak
+func (m *FakeNATSMsg) Nak() error { return m.setDecision(AckNaked) }
+
+// NakWithDelay 记录 Nak
+func (m *FakeNATSMsg) NakWithDelay(time.Duration) error { return m.setDecision(AckNaked) }
+
+// InProgress 记录 InProgress（已有最终决定时不覆盖）
+func (m *FakeNATSMsg) InProgress() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.decision == AckPending {
+		m.decision = AckInProgress
+	}
+	return nil
+}
+
+// Term 记录 Term
+func (m *FakeNATSMsg) Term() error { return m.setDecision(AckTerminated) }
+
+// TermWithReason 记录 Term
+func (m *FakeNATSMsg) TermWithReason(string) error { return m.setDecision(AckTerminated) }
+
+var _ jetstream.Msg = (*FakeNATSMsg)(nil)
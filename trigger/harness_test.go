@@ -0,0 +1,124 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// newTestNATSTrigger 创建已 Init（未连接）的 NATS 触发器
+func newTestNATSTrigger(t *testing.T, settings map[string]interface{}) *NATSTrigger {
+	t.Helper()
+	s := map[string]interface{}{"url": "nats://127.0.0.1:4222", "stream": "ORDERS", "subject": "orders.>"}
+	for k, v := range settings {
+		s[k] = v
+	}
+	trig := NewNATSTrigger("orders")
+	if err := trig.Init(context.Background(), model.TriggerConfig{Name: "orders", Type: string(model.TriggerNATS), Settings: s}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	return trig
+}
+
+func TestHarnessDriveNATS(t *testing.T) {
+	tests := []struct {
+		name         string
+		result       error
+		wantDecision AckDecision
+	}{
+		{name: "success acks", result: nil, wantDecision: AckAcked},
+		{name: "handler error naks", result: errors.New("boom"), wantDecision: AckNaked},
+		{name: "skipped by plugin acks", result: ErrEventSkipped, wantDecision: AckAcked},
+		{name: "retry requested naks", result: ErrEventRetry, wantDecision: AckNaked},
+		{name: "stopping naks", result: ErrStopping, wantDecision: AckNaked},
+		{name: "wrapped error naks", result: errors.Join(errors.New("ctx"), errors.New("boom")), wantDecision: AckNaked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trig := newTestNATSTrigger(t, nil)
+			h := NewTestHarness()
+			h.Return(tt.result)
+
+			msg := NewFakeNATSMsg("orders.created", []byte(`{"id":1}`))
+			msg.MsgMeta = &jetstream.MsgMetadata{
+				NumDelivered: 2,
+				Sequence:     jetstream.SequencePair{Stream: 42},
+				Timestamp:    time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC),
+			}
+			events := h.DriveNATS(context.Background(), trig, msg)
+
+			if len(events) != 1 {
+				t.Fatalf("captured %d events, want 1", len(events))
+			}
+			ev := events[0]
+			if ev.Type != model.TriggerNATS || ev.Name != "orders" || string(ev.Payload) != `{"id":1}` {
+				t.Fatalf("event = %+v, want NATS event orders with original payload", ev)
+			}
+			wantMeta := map[string]string{
+				"subject":       "orders.created",
+				"num_delivered": "2",
+				"stream_seq":    "42",
+				"timestamp":     "2026-01-05T10:00:00Z",
+			}
+			for k, v := range wantMeta {
+				if ev.Metadata[k] != v {
+					t.Fatalf("metadata[%s] = %q, want %q", k, ev.Metadata[k], v)
+				}
+			}
+			if got := msg.Decision(); got != tt.wantDecision {
+				t.Fatalf("decision = %q, want %q", got, tt.wantDecision)
+			}
+		})
+	}
+}
+
+func TestHarnessResults(t *testing.T) {
+	errFirst := errors.New("first")
+	tests := []struct {
+		name     string
+		results  []error
+		fallback error
+		msgs     int
+		want     []AckDecision
+	}{
+		{name: "default handler succeeds", msgs: 2, want: []AckDecision{AckAcked, AckAcked}},
+		{name: "canned results in order", results: []error{errFirst, nil}, msgs: 2, want: []AckDecision{AckNaked, AckAcked}},
+		{name: "fallback after canned results", results: []error{nil}, fallback: errFirst, msgs: 3,
+			want: []AckDecision{AckAcked, AckNaked, AckNaked}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trig := newTestNATSTrigger(t, nil)
+			h := NewTestHarness()
+			h.Return(tt.results...)
+			h.SetFallback(tt.fallback)
+
+			msgs := make([]*FakeNATSMsg, tt.msgs)
+			for i := range msgs {
+				msgs[i] = NewFakeNATSMsg("orders.created", []byte(`{}`))
+			}
+			if got := len(h.DriveNATS(context.Background(), trig, msgs...)); got != tt.msgs {
+				t.Fatalf("captured %d events, want %d", got, tt.msgs)
+			}
+			for i, msg := range msgs {
+				if msg.Decision() != tt.want[i] {
+					t.Fatalf("msg %d decision = %q, want %q", i, msg.Decision(), tt.want[i])
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if _, err := h.WaitEvents(ctx, tt.msgs+1); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("WaitEvents() beyond captured: err = %v, want deadline exceeded", err)
+			}
+			h.Reset()
+			if len(h.Events()) != 0 {
+				t.Fatalf("Events() after Reset = %d, want 0", len(h.Events()))
+			}
+		})
+	}
+}
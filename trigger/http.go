@@ -0,0 +1,150 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// HTTPRouter HTTP 路由注册接口（gateway.Gateway、http.ServeMux 均满足）
+type HTTPRouter interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// defaultHTTPMaxBodyBytes HTTP 触发器默认请求体上限
+const defaultHTTPMaxBodyBytes = 10 << 20
+
+// HTTPTrigger HTTP 触发器：在 Gateway 上绑定 POST <path>，将请求同步转换为触发事件
+type HTTPTrigger struct {
+	name         string
+	path         string
+	maxBodyBytes int64
+	router       HTTPRouter
+
+	mu      sync.RWMutex
+	handler TriggerHandler // Start 之前为 nil，此时返回 503
+}
+
+// NewHTTPTrigger 创建 HTTPTrigger
+func NewHTTPTrigger(name string, router HTTPRouter) *HTTPTrigger {
+	return &HTTPTrigger{name: name, router: router}
+}
+
+// Name 返回触发器名称
+func (t *HTTPTrigger) Name() string {
+	return t.name
+}
+
+// Type 返回触发器类型
+func (t *HTTPTrigger) Type() model.TriggerType {
+	return model.TriggerHTTP
+}
+
+// Init 从 TriggerConfig.Settings 解析 path，并在 Gateway 上注册路由
+func (t *HTTPTrigger) Init(_ context.Context, cfg model.TriggerConfig) error {
+	if t.router == nil {
+		return fmt.Errorf("http trigger %q requires gateway to be enabled", t.name)
+	}
+
+	t.path, _ = cfg.Settings["path"].(string)
+	if t.path == "" {
+		return fmt.Errorf("http trigger %q missing path setting", t.name)
+	}
+	if !strings.HasPrefix(t.path, "/") {
+		t.path = "/" + t.path
+	}
+	t.maxBodyBytes = int64(getIntSetting(cfg.Settings, "max_body_bytes", defaultHTTPMaxBodyBytes))
+
+	t.router.Handle("POST "+t.path, t)
+	return nil
+}
+
+// Start 开始接收请求
+func (t *HTTPTrigger) Start(ctx context.Context, handler TriggerHandler) error {
+	t.mu.Lock()
+	t.handler = handler
+	t.mu.Unlock()
+
+	log.InfoContextf(ctx, "[HTTPTrigger] %s started: path=POST %s", t.name, t.path)
+	return nil
+}
+
+// Stop 停止接收请求（路由仍保留，返回 503）
+func (t *HTTPTrigger) Stop(_ context.Context) error {
+	t.mu.Lock()
+	t.handler = nil
+	t.mu.Unlock()
+	return nil
+}
+
+// ServeHTTP 将请求转换为 TriggerEvent 并同步调用 handler：成功 200，失败 500，停止中 503
+func (t *HTTPTrigger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	t.mu.RLock()
+	handler := t.handler
+	t.mu.RUnlock()
+	if handler == nil {
+		writeTriggerResponse(w, http.StatusServiceUnavailable, ErrStopping)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, t.maxBodyBytes))
+	if err != nil {
+		writeTriggerResponse(w, http.StatusBadRequest, fmt.Errorf("read request body: %w", err))
+		return
+	}
+
+	event := &model.TriggerEvent{
+		Type: model.TriggerHTTP,
+		Name: t.name,
+		Metadata: map[string]string{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"query":  r.URL.RawQuery,
+		},
+	}
+	if len(body) > 0 {
+		event.Payload = body
+	}
+	for k, vals := range r.Header {
+		event.Metadata[model.HeaderMetadataPrefix+k] = strings.Join(vals, ",")
+	}
+
+	start := time.Now()
+	err = handler(ctx, event)
+	switch {
+	case err == nil, errors.Is(err, ErrEventSkipped):
+		writeTriggerResponse(w, http.StatusOK, nil)
+	case errors.Is(err, ErrStopping):
+		writeTriggerResponse(w, http.StatusServiceUnavailable, err)
+	default:
+		log.ErrorContextf(ctx, "[HTTPTrigger] %s handler error: %v", t.name, err)
+		writeTriggerResponse(w, http.StatusInternalServerError, err)
+	}
+	log.DebugContextf(ctx, "[HTTPTrigger] %s handled in %s, err=%v", t.name, time.Since(start), err)
+}
+
+// writeTriggerResponse 写入 JSON 响应
+func writeTriggerResponse(w http.ResponseWriter, status int, err error) {
+	resp := &model.Response{
+		Success:   err == nil,
+		Message:   "ok",
+		Timestamp: time.Now(),
+	}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
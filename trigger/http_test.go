@@ -0,0 +1,94 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+func TestHTTPTriggerServeHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		handlerErr  error
+		notStarted  bool // 未 Start 或已 Stop
+		method      string
+		body        string
+		wantStatus  int
+		wantSuccess bool
+		wantMessage string
+	}{
+		{name: "success", body: `{"symbol":"BTC"}`, wantStatus: http.StatusOK, wantSuccess: true, wantMessage: "ok"},
+		{name: "skipped by plugin is success", handlerErr: ErrEventSkipped, wantStatus: http.StatusOK, wantSuccess: true, wantMessage: "ok"},
+		{name: "retry requested is failure", handlerErr: ErrEventRetry, wantStatus: http.StatusInternalServerError, wantMessage: ErrEventRetry.Error()},
+		{name: "handler error", handlerErr: errors.New("exchange unreachable"), wantStatus: http.StatusInternalServerError, wantMessage: "exchange unreachable"},
+		{name: "manager stopping", handlerErr: ErrStopping, wantStatus: http.StatusServiceUnavailable, wantMessage: ErrStopping.Error()},
+		{name: "trigger stopped", notStarted: true, wantStatus: http.StatusServiceUnavailable, wantMessage: ErrStopping.Error()},
+		{name: "body too large", body: strings.Repeat("x", 65), wantStatus: http.StatusBadRequest, wantMessage: "read request body"},
+		{name: "only POST is routed", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			trig := NewHTTPTrigger("invoke", mux)
+			cfg := model.TriggerConfig{Name: "invoke", Type: string(model.TriggerHTTP),
+				Settings: map[string]interface{}{"path": "invoke/collect", "max_body_bytes": 64}}
+			if err := trig.Init(context.Background(), cfg); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+
+			var got *model.TriggerEvent
+			handler := func(ctx context.Context, event *model.TriggerEvent) error {
+				got = event
+				return tt.handlerErr
+			}
+			if err := trig.Start(context.Background(), handler); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			if tt.notStarted {
+				trig.Stop(context.Background())
+			}
+
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req, _ := http.NewRequest(method, srv.URL+"/invoke/collect?force=1", strings.NewReader(tt.body))
+			req.Header.Set("X-Request-Id", "req-1")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantMessage == "" {
+				return
+			}
+			var body model.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.Success != tt.wantSuccess || !strings.Contains(body.Message, tt.wantMessage) {
+				t.Fatalf("response = %+v, want success=%v message containing %q", body, tt.wantSuccess, tt.wantMessage)
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				if got == nil || got.Type != model.TriggerHTTP || got.Name != "invoke" || string(got.Payload) != tt.body ||
+					got.Metadata["method"] != http.MethodPost || got.Metadata["path"] != "/invoke/collect" ||
+					got.Metadata["query"] != "force=1" || got.Metadata[model.HeaderMetadataPrefix+"X-Request-Id"] != "req-1" {
+					t.Fatalf("event = %+v, want request converted to trigger event", got)
+				}
+			}
+		})
+	}
+}
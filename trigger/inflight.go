@@ -0,0 +1,60 @@
+package trigger
+
+import "context"
+
+// InflightLimiter 进程级在途消息额度，所有 NATS 触发器共享：拉取前按批次申请额度，
+// 每条消息处理完成后归还，额度耗尽时阻塞后续拉取，从而限制缓冲事件占用的内存。
+// nil 表示不限制。
+type InflightLimiter struct {
+	sem chan struct{}
+}
+
+// NewInflightLimiter 创建额度为 limit 的限制器，limit <= 0 时返回 nil（不限制）
+func NewInflightLimiter(limit int) *InflightLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &InflightLimiter{sem: make(chan struct{}, limit)}
+}
+
+// AcquireUpTo 阻塞获取至少 1 个、至多 want 个额度，返回实际获取数量；ctx 取消时返回错误
+func (l *InflightLimiter) AcquireUpTo(ctx context.Context, want int) (int, error) {
+	if l == nil || want <= 0 {
+		return want, nil
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	n := 1
+	for n < want {
+		select {
+		case l.sem <- struct{}{}:
+			n++
+		default:
+			return n, nil
+		}
+	}
+	return n, nil
+}
+
+// Release 归还 n 个额度
+func (l *InflightLimiter) Release(n int) {
+	if l == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		<-l.sem
+	}
+}
+
+// Usage 返回当前已占用额度与总额度
+func (l *InflightLimiter) Usage() (inUse, limit int) {
+	if l == nil {
+		return 0, 0
+	}
+	return len(l.sem), cap(l.sem)
+}
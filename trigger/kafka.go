@@ -0,0 +1,279 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/segmentio/kafka-go"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// KafkaConfig Kafka 触发器配置
+type KafkaConfig struct {
+	Brokers     []string
+	Topic       string
+	GroupID     string
+	OffsetReset string // 消费组无已提交 offset 时的起点：earliest / latest
+	MaxRetries  int    // handler 失败时原地重试次数，耗尽后提交 offset 跳过该消息
+	RetryDelay  int    // 重试间隔（秒）
+}
+
+// kafkaCommitTimeout 提交 offset 的最长等待时间。提交不随消费循环取消：handler 已成功处理的消息
+// 在 Stop 期间仍需提交，否则 rebalance 后被重复消费
+const kafkaCommitTimeout = 5 * time.Second
+
+// kafkaFetchBackoff 拉取失败后的重试间隔
+const kafkaFetchBackoff = 1 * time.Second
+
+// kafkaReader 消费循环使用的 kafka.Reader 方法，测试中可替换
+type kafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaTrigger Kafka 消费组触发器，多个 SCF 实例共享同一 group_id 时分摊分区
+type KafkaTrigger struct {
+	name     string
+	config   KafkaConfig
+	reader   kafkaReader
+	handler  TriggerHandler
+	cancel   context.CancelFunc
+	loopDone chan struct{}
+
+	errMu   sync.Mutex
+	lastErr error // 最近一次拉取错误，成功拉取后清空
+}
+
+// NewKafkaTrigger 创建 KafkaTrigger
+func NewKafkaTrigger(name string) *KafkaTrigger {
+	return &KafkaTrigger{name: name}
+}
+
+// Name 返回触发器名称
+func (t *KafkaTrigger) Name() string {
+	return t.name
+}
+
+// Type 返回触发器类型
+func (t *KafkaTrigger) Type() model.TriggerType {
+	return model.TriggerKafka
+}
+
+// Init 从 TriggerConfig.Settings 解析 KafkaConfig
+func (t *KafkaTrigger) Init(_ context.Context, cfg model.TriggerConfig) error {
+	s := cfg.Settings
+
+	switch v := s["brokers"].(type) {
+	case string:
+		for _, b := range strings.Split(v, ",") {
+			if b = strings.TrimSpace(b); b != "" {
+				t.config.Brokers = append(t.config.Brokers, b)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if b, ok := item.(string); ok && b != "" {
+				t.config.Brokers = append(t.config.Brokers, b)
+			}
+		}
+	}
+	if len(t.config.Brokers) == 0 {
+		return fmt.Errorf("kafka trigger %q missing brokers setting", t.name)
+	}
+
+	t.config.Topic, _ = s["topic"].(string)
+	if t.config.Topic == "" {
+		return fmt.Errorf("kafka trigger %q missing topic setting", t.name)
+	}
+	t.config.GroupID, _ = s["group_id"].(string)
+	if t.config.GroupID == "" {
+		return fmt.Errorf("kafka trigger %q missing group_id setting", t.name)
+	}
+
+	t.config.OffsetReset, _ = s["offset_reset"].(string)
+	switch t.config.OffsetReset {
+	case "":
+		t.config.OffsetReset = "latest"
+	case "earliest", "latest":
+	default:
+		return fmt.Errorf("kafka trigger %q invalid offset_reset %q: must be earliest or latest",
+			t.name, t.config.OffsetReset)
+	}
+
+	t.config.MaxRetries = getIntSetting(s, "max_retries", 3)
+	t.config.RetryDelay = getIntSetting(s, "retry_delay", 1)
+	return nil
+}
+
+// Start 创建消费组 Reader，启动 consumeLoop
+func (t *KafkaTrigger) Start(ctx context.Context, handler TriggerHandler) error {
+	t.handler = handler
+
+	startOffset := kafka.LastOffset
+	if t.config.OffsetReset == "earliest" {
+		startOffset = kafka.FirstOffset
+	}
+
+	t.reader = kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     t.config.Brokers,
+		Topic:       t.config.Topic,
+		GroupID:     t.config.GroupID,
+		StartOffset: startOffset,
+	})
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	t.cancel = cancel
+	t.loopDone = make(chan struct{})
+
+	go t.consumeLoop(loopCtx)
+
+	log.InfoContextf(ctx, "[KafkaTrigger] %s started: brokers=%v, topic=%s, group=%s, offset_reset=%s",
+		t.name, t.config.Brokers, t.config.Topic, t.config.GroupID, t.config.OffsetReset)
+	return nil
+}
+
+// Stop 停止消费循环，等待当前消息处理完成（以 ctx 为截止）后关闭 Reader
+func (t *KafkaTrigger) Stop(ctx context.Context) error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+
+	var err error
+	if t.loopDone != nil {
+		select {
+		case <-t.loopDone:
+		case <-ctx.Done():
+			err = fmt.Errorf("kafka trigger %q consume loop not drained: %w", t.name, ctx.Err())
+		}
+	}
+
+	if t.reader != nil {
+		if cErr := t.reader.Close(); cErr != nil && err == nil {
+			err = fmt.Errorf("failed to close kafka reader for trigger %q: %w", t.name, cErr)
+		}
+	}
+	return err
+}
+
+// CheckHealth 检查最近一次拉取是否成功
+func (t *KafkaTrigger) CheckHealth(_ context.Context) error {
+	if t.reader == nil {
+		return fmt.Errorf("kafka trigger %q not started", t.name)
+	}
+	t.errMu.Lock()
+	defer t.errMu.Unlock()
+	if t.lastErr != nil {
+		return fmt.Errorf("kafka trigger %q fetch failing: %w", t.name, t.lastErr)
+	}
+	return nil
+}
+
+// setLastErr 记录拉取结果供健康检查使用
+func (t *KafkaTrigger) setLastErr(err error) {
+	t.errMu.Lock()
+	t.lastErr = err
+	t.errMu.Unlock()
+}
+
+// consumeLoop 持续拉取并处理 Kafka 消息，handler 返回后才提交 offset
+func (t *KafkaTrigger) consumeLoop(ctx context.Context) {
+	defer close(t.loopDone)
+
+	fetchLog := newErrLogLimiter("[KafkaTrigger] "+t.name+" fetch", defaultErrLogInterval)
+	for {
+		msg, err := t.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				log.InfoContextf(ctx, "[KafkaTrigger] %s consume loop exiting", t.name)
+				return
+			}
+			t.setLastErr(err)
+			fetchLog.failure(ctx, err)
+			select {
+			case <-ctx.Done():
+				log.InfoContextf(ctx, "[KafkaTrigger] %s consume loop exiting", t.name)
+				return
+			case <-time.After(kafkaFetchBackoff):
+			}
+			continue
+		}
+		t.setLastErr(nil)
+		fetchLog.success(ctx)
+
+		event := &model.TriggerEvent{
+			Type:    model.TriggerKafka,
+			Name:    t.name,
+			Payload: msg.Value,
+			Metadata: map[string]string{
+				"topic":     msg.Topic,
+				"partition": strconv.Itoa(msg.Partition),
+				"offset":    strconv.FormatInt(msg.Offset, 10),
+			},
+		}
+		if len(msg.Key) > 0 {
+			event.Metadata["key"] = string(msg.Key)
+		}
+		for _, h := range msg.Headers {
+			event.Metadata[model.HeaderMetadataPrefix+h.Key] = string(h.Value)
+		}
+
+		if !t.handle(ctx, event, msg) {
+			// 停止中：不提交，rebalance 后由其他实例重新消费
+			return
+		}
+
+		t.commit(ctx, msg)
+	}
+}
+
+// commit 提交 offset；使用脱离消费循环取消的 ctx，Stop 期间已处理完成的消息仍能提交
+func (t *KafkaTrigger) commit(ctx context.Context, msg kafka.Message) {
+	commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), kafkaCommitTimeout)
+	defer cancel()
+	if err := t.reader.CommitMessages(commitCtx, msg); err != nil {
+		log.ErrorContextf(ctx, "[KafkaTrigger] %s commit offset failed: partition=%d, offset=%d, err=%v",
+			t.name, msg.Partition, msg.Offset, err)
+	}
+}
+
+// handle 调用 handler，失败或插件要求重试时原地重试（Kafka 无逐条 Nak，不提交会阻塞后续 offset 的语义）；
+// 返回 false 表示框架停止中，消息不应提交
+func (t *KafkaTrigger) handle(ctx context.Context, event *model.TriggerEvent, msg kafka.Message) bool {
+	for attempt := 0; ; attempt++ {
+		err := t.handler(ctx, event)
+		switch {
+		case err == nil:
+			return true
+		case errors.Is(err, ErrEventSkipped):
+			log.DebugContextf(ctx, "[KafkaTrigger] %s message skipped by plugin: partition=%d, offset=%d",
+				t.name, msg.Partition, msg.Offset)
+			return true
+		case errors.Is(err, ErrStopping):
+			return false
+		case errors.Is(err, ErrEventRetry):
+			log.InfoContextf(ctx, "[KafkaTrigger] %s message retry requested by plugin: partition=%d, offset=%d",
+				t.name, msg.Partition, msg.Offset)
+		default:
+			log.ErrorContextf(ctx, "[KafkaTrigger] %s handler error: %v", t.name, err)
+		}
+
+		if attempt >= t.config.MaxRetries {
+			log.ErrorContextf(ctx, "[KafkaTrigger] %s giving up after %d retries, committing: partition=%d, offset=%d",
+				t.name, attempt, msg.Partition, msg.Offset)
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(time.Duration(t.config.RetryDelay) * time.Second):
+		}
+	}
+}
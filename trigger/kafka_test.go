@@ -0,0 +1,182 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// fakeKafkaReader 按序返回 msgs 中的消息，取完后阻塞到 ctx 取消；记录每次提交
+type fakeKafkaReader struct {
+	msgs     chan kafka.Message
+	fetchErr error // 非空时 FetchMessage 始终返回该错误
+
+	mu        sync.Mutex
+	committed []int64
+	commitErr []error // 提交时 ctx.Err()，用于确认提交不随消费循环取消
+}
+
+func (r *fakeKafkaReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if r.fetchErr != nil {
+		return kafka.Message{}, r.fetchErr
+	}
+	select {
+	case m := <-r.msgs:
+		return m, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeKafkaReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range msgs {
+		r.committed = append(r.committed, m.Offset)
+		r.commitErr = append(r.commitErr, ctx.Err())
+	}
+	return nil
+}
+
+func (r *fakeKafkaReader) Close() error { return nil }
+
+func (r *fakeKafkaReader) commits() ([]int64, []error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.committed...), append([]error(nil), r.commitErr...)
+}
+
+// newFakeKafkaReader 创建预先装入 msgs 的 fakeKafkaReader
+func newFakeKafkaReader(msgs ...kafka.Message) *fakeKafkaReader {
+	r := &fakeKafkaReader{msgs: make(chan kafka.Message, len(msgs))}
+	for _, m := range msgs {
+		r.msgs <- m
+	}
+	return r
+}
+
+// startFakeKafkaTrigger 以 fake reader 代替 kafka.Reader 启动消费循环
+func startFakeKafkaTrigger(r *fakeKafkaReader, maxRetries int, handler TriggerHandler) *KafkaTrigger {
+	kt := NewKafkaTrigger("orders")
+	kt.config = KafkaConfig{Topic: "orders", MaxRetries: maxRetries}
+	kt.reader = r
+	kt.handler = handler
+	kt.loopDone = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	kt.cancel = cancel
+	go kt.consumeLoop(ctx)
+	return kt
+}
+
+func TestKafkaTriggerHandle(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxRetries    int
+		results       []error // handler 依次返回的结果，超出部分重复最后一个
+		wantCalls     int
+		wantCommitted bool
+	}{
+		{name: "success commits", results: []error{nil}, wantCalls: 1, wantCommitted: true},
+		{name: "skipped commits without retry", maxRetries: 3, results: []error{ErrEventSkipped}, wantCalls: 1, wantCommitted: true},
+		{name: "retry then success commits", maxRetries: 3, results: []error{ErrEventRetry, errors.New("db down"), nil}, wantCalls: 3, wantCommitted: true},
+		{name: "retries exhausted gives up and commits", maxRetries: 2, results: []error{errors.New("db down")}, wantCalls: 3, wantCommitted: true},
+		{name: "stopping does not commit", maxRetries: 3, results: []error{ErrStopping}, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := 0
+			handled := make(chan *model.TriggerEvent, 1)
+			handler := func(ctx context.Context, event *model.TriggerEvent) error {
+				mu.Lock()
+				defer mu.Unlock()
+				err := tt.results[min(calls, len(tt.results)-1)]
+				calls++
+				if calls == tt.wantCalls {
+					handled <- event
+				}
+				return err
+			}
+			msg := kafka.Message{Topic: "orders", Partition: 2, Offset: 42, Key: []byte("k1"), Value: []byte("v1"),
+				Headers: []kafka.Header{{Key: "trace", Value: []byte("abc")}}}
+			r := newFakeKafkaReader(msg)
+			kt := startFakeKafkaTrigger(r, tt.maxRetries, handler)
+			defer kt.Stop(context.Background())
+
+			var event *model.TriggerEvent
+			select {
+			case event = <-handled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("handler not called")
+			}
+			if string(event.Payload) != "v1" || event.Metadata["partition"] != "2" || event.Metadata["offset"] != "42" ||
+				event.Metadata["key"] != "k1" || event.Metadata[model.HeaderMetadataPrefix+"trace"] != "abc" {
+				t.Fatalf("event = %+v, want payload and metadata from message", event)
+			}
+
+			if !tt.wantCommitted {
+				// 停止中：不提交并退出消费循环
+				select {
+				case <-kt.loopDone:
+				case <-time.After(5 * time.Second):
+					t.Fatal("consume loop did not exit on ErrStopping")
+				}
+			} else if err := kt.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+			mu.Lock()
+			gotCalls := calls
+			mu.Unlock()
+			if gotCalls != tt.wantCalls {
+				t.Fatalf("handler called %d times, want %d", gotCalls, tt.wantCalls)
+			}
+			committed, _ := r.commits()
+			if tt.wantCommitted != (len(committed) == 1 && committed[0] == 42) {
+				t.Fatalf("committed offsets = %v, want committed %v", committed, tt.wantCommitted)
+			}
+		})
+	}
+}
+
+func TestKafkaTriggerStop(t *testing.T) {
+	t.Run("message finished during stop is still committed", func(t *testing.T) {
+		entered := make(chan struct{})
+		handler := func(ctx context.Context, event *model.TriggerEvent) error {
+			close(entered)
+			<-ctx.Done() // 处理跨越 Stop：消费循环 ctx 已取消，handler 仍成功返回
+			return nil
+		}
+		r := newFakeKafkaReader(kafka.Message{Offset: 7})
+		kt := startFakeKafkaTrigger(r, 0, handler)
+		<-entered
+		if err := kt.Stop(context.Background()); err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+		committed, errs := r.commits()
+		if len(committed) != 1 || committed[0] != 7 || errs[0] != nil {
+			t.Fatalf("commits = %v (ctx errs %v), want offset 7 committed with live ctx", committed, errs)
+		}
+	})
+
+	t.Run("fetch backoff returns promptly on stop", func(t *testing.T) {
+		r := &fakeKafkaReader{fetchErr: errors.New("broker unreachable")}
+		kt := startFakeKafkaTrigger(r, 0, func(ctx context.Context, event *model.TriggerEvent) error { return nil })
+		time.Sleep(20 * time.Millisecond) // 进入退避等待
+		if err := kt.CheckHealth(context.Background()); err == nil {
+			t.Fatal("CheckHealth() = nil, want fetch error")
+		}
+		begin := time.Now()
+		if err := kt.Stop(context.Background()); err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+		if took := time.Since(begin); took >= kafkaFetchBackoff/2 {
+			t.Fatalf("Stop() took %s, want well under the %s fetch backoff", took, kafkaFetchBackoff)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package trigger
+
+import (
+	"context"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// defaultErrLogInterval 重复错误汇总日志的默认间隔
+const defaultErrLogInterval = 60 * time.Second
+
+// errLogLimiter 对持续重复的错误限流打印：首次立即告警，之后每个 interval 汇总一次被抑制的次数，
+// 恢复后打印一次恢复日志并重置。非并发安全，仅在单个消费循环内使用。
+type errLogLimiter struct {
+	name       string // 日志前缀，如 "[NATSTrigger] xxx fetch"
+	interval   time.Duration
+	failing    bool
+	since      time.Time // 本轮连续失败开始时间
+	lastLog    time.Time
+	suppressed int
+}
+
+// newErrLogLimiter 创建错误日志限流器
+func newErrLogLimiter(name string, interval time.Duration) *errLogLimiter {
+	if interval <= 0 {
+		interval = defaultErrLogInterval
+	}
+	return &errLogLimiter{name: name, interval: interval}
+}
+
+// failure 记录一次失败
+func (l *errLogLimiter) failure(ctx context.Context, err error) {
+	now := time.Now()
+	if !l.failing {
+		l.failing = true
+		l.since = now
+		l.lastLog = now
+		l.suppressed = 0
+		log.WarnContextf(ctx, "%s failed: %v", l.name, err)
+		return
+	}
+
+	l.suppressed++
+	if now.Sub(l.lastLog) >= l.interval {
+		log.WarnContextf(ctx, "%s still failing for %s, %d errors suppressed, last: %v",
+			l.name, now.Sub(l.since).Truncate(time.Second), l.suppressed, err)
+		l.lastLog = now
+		l.suppressed = 0
+	}
+}
+
+// success 记录一次成功，若此前处于失败状态则打印恢复日志并重置
+func (l *errLogLimiter) success(ctx context.Context) {
+	if !l.failing {
+		return
+	}
+	log.InfoContextf(ctx, "%s recovered after %s", l.name, time.Since(l.since).Truncate(time.Second))
+	l.failing = false
+	l.suppressed = 0
+}
@@ -3,15 +3,22 @@ package trigger
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/mooyang-code/scf-framework/config"
 	"github.com/mooyang-code/scf-framework/dnsproxy"
+	"github.com/mooyang-code/scf-framework/metrics"
 	"github.com/mooyang-code/scf-framework/model"
 	"github.com/mooyang-code/scf-framework/plugin"
 	"github.com/mooyang-code/scf-framework/reporter"
 	"github.com/mooyang-code/scf-framework/storage"
+	"github.com/mooyang-code/scf-framework/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"trpc.group/trpc-go/trpc-go"
 	"trpc.group/trpc-go/trpc-go/log"
 )
@@ -27,6 +34,27 @@ type Manager struct {
 	dnsResolver   *dnsproxy.Resolver
 	storageWriter *storage.RPCWriter
 	storageReader *storage.Reader
+	sink          *asyncSink
+	warnOnDupName bool             // 重名触发器仅告警（默认返回错误）
+	httpRouter    HTTPRouter       // HTTP 触发器注册路由的 Gateway，未启用时为 nil
+	natsLimiter   *InflightLimiter // 所有 NATS 触发器共享的在途消息额度，nil 表示不限制
+	metrics       *metrics.Metrics // 触发器调用指标，nil 表示不收集
+	tracer        *tracing.Tracer  // 触发器调用 span，nil 表示不追踪
+
+	mu       sync.Mutex
+	started  bool           // StartAll 已成功返回
+	stopping bool           // 停止中，拒绝新的触发事件
+	inflight sync.WaitGroup // 进行中的 handler 调用
+
+	subscriptions map[string]struct{} // 插件订阅的触发器名称，nil 表示全部
+
+	handler TriggerHandler // Init 时创建的统一 handler，供动态定时器与热加载的触发器使用
+
+	timersMu     sync.Mutex          // 保护 staticTimers，并使定时器"检查是否静态 + 增删"成为原子操作
+	staticTimers map[string]struct{} // 配置文件中声明的定时器名称，不允许被动态定时器覆盖
+
+	reloadMu sync.Mutex                     // 串行化 Reload，保护 configs
+	configs  map[string]model.TriggerConfig // 当前生效的触发器配置（含未启用的），Reload 时据此比较
 }
 
 // NewManager 创建触发器管理器
@@ -44,40 +72,328 @@ func NewManager(p plugin.Plugin, ts *config.TaskInstanceStore, rs *config.Runtim
 	}
 }
 
+// SetEventSink 设置事件旁路输出，事件处理完成后异步调用；缓冲满时丢弃，StopAll 时在 ctx 期限内输出缓冲中的事件
+func (m *Manager) SetEventSink(sink EventSink) {
+	if sink == nil {
+		return
+	}
+	m.sink = newAsyncSink(sink, defaultSinkBufferSize)
+}
+
+// SetHTTPRouter 设置 HTTP 触发器注册路由使用的 Gateway，需在 Init 之前调用
+func (m *Manager) SetHTTPRouter(r HTTPRouter) {
+	m.httpRouter = r
+}
+
+// SetMetrics 设置触发器调用次数与耗时的指标收集
+func (m *Manager) SetMetrics(mt *metrics.Metrics) {
+	m.metrics = mt
+}
+
+// SetTracer 设置触发器调用的链路追踪，每次分发创建以触发器命名的 span
+func (m *Manager) SetTracer(t *tracing.Tracer) {
+	m.tracer = t
+}
+
+// SetMaxInflightMessages 设置所有 NATS 触发器共享的在途消息上限（<= 0 不限制），需在 Init 之前调用
+func (m *Manager) SetMaxInflightMessages(n int) {
+	m.natsLimiter = NewInflightLimiter(n)
+}
+
+// InflightUsage 返回 NATS 在途消息占用与上限（未限制时均为 0）
+func (m *Manager) InflightUsage() (inUse, limit int) {
+	return m.natsLimiter.Usage()
+}
+
+// SetWarnOnDuplicateNames 设置重名触发器的处理方式：true 仅告警，false（默认）Init 返回错误
+func (m *Manager) SetWarnOnDuplicateNames(warn bool) {
+	m.warnOnDupName = warn
+}
+
 // Init 根据配置创建并初始化触发器实例
 func (m *Manager) Init(ctx context.Context, configs []model.TriggerConfig) error {
-	handler := m.wrapHandler()
+	if dups := duplicateTriggerNames(configs); len(dups) > 0 {
+		if !m.warnOnDupName {
+			return fmt.Errorf("duplicate trigger names: %v", dups)
+		}
+		log.WarnContextf(ctx, "[TriggerManager] duplicate trigger names may cause double dispatch: %v", dups)
+	}
+
+	if m.runtime == nil {
+		log.WarnContextf(ctx, "[TriggerManager] runtime state is nil, nodeID/version will not be injected into event metadata")
+	}
+
+	m.initSubscriptions(ctx, configs)
+
+	m.handler = m.wrapHandler()
+	m.timersMu.Lock()
+	m.staticTimers = make(map[string]struct{})
+	m.timersMu.Unlock()
+
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	m.configs = make(map[string]model.TriggerConfig, len(configs))
 
 	for _, cfg := range configs {
-		switch cfg.Type {
-		case string(model.TriggerTimer):
-			cronExpr, _ := cfg.Settings["cron"].(string)
-			if cronExpr == "" {
-				return fmt.Errorf("timer trigger %q missing cron setting", cfg.Name)
-			}
-			if err := m.timer.AddCron(cfg.Name, cronExpr, handler); err != nil {
-				return fmt.Errorf("failed to add cron %q: %w", cfg.Name, err)
-			}
-			log.InfoContextf(ctx, "[TriggerManager] registered timer trigger: name=%s, cron=%s", cfg.Name, cronExpr)
+		m.configs[cfg.Name] = cfg
+		if !cfg.IsEnabled() {
+			log.InfoContextf(ctx, "[TriggerManager] trigger %s (type=%s) disabled by config, skipped", cfg.Name, cfg.Type)
+			continue
+		}
+		t, err := m.register(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		if t != nil {
+			m.triggers = append(m.triggers, t)
+		}
+	}
+	return nil
+}
 
-		case string(model.TriggerNATS):
-			t := NewNATSTrigger(cfg.Name)
-			if m.storageReader != nil {
-				t.SetStorageReader(m.storageReader)
+// register 按配置创建并初始化单个触发器：timer 类型直接注册到 TimerTrigger 并返回 nil，
+// 其他类型返回已 Init 但未 Start 的触发器
+func (m *Manager) register(ctx context.Context, cfg model.TriggerConfig) (Trigger, error) {
+	switch cfg.Type {
+	case string(model.TriggerTimer):
+		cronExpr, _ := cfg.Settings["cron"].(string)
+		if cronExpr == "" {
+			return nil, fmt.Errorf("timer trigger %q missing cron setting", cfg.Name)
+		}
+		granularity, _ := cfg.Settings["granularity"].(string)
+		loc := time.UTC
+		if tz, _ := cfg.Settings["timezone"].(string); tz != "" {
+			l, err := time.LoadLocation(tz)
+			if err != nil {
+				return nil, fmt.Errorf("timer trigger %q invalid timezone %q: %w", cfg.Name, tz, err)
 			}
-			if err := t.Init(ctx, cfg); err != nil {
-				return fmt.Errorf("failed to init NATS trigger %q: %w", cfg.Name, err)
+			loc = l
+		}
+		jitter, err := parseDurationSetting(cfg.Settings, "jitter")
+		if err != nil {
+			return nil, fmt.Errorf("timer trigger %q invalid jitter: %w", cfg.Name, err)
+		}
+		skipIfRunning, _ := cfg.Settings["skip_if_running"].(bool)
+		catchUp, _ := cfg.Settings["catch_up"].(bool)
+		opts := CronOptions{
+			Granularity:   Granularity(granularity),
+			Location:      loc,
+			Jitter:        jitter,
+			SkipIfRunning: skipIfRunning,
+			CatchUp:       catchUp,
+			CatchUpMax:    getIntSetting(cfg.Settings, "catch_up_max", DefaultCatchUpMax),
+		}
+		m.timersMu.Lock()
+		// 同名动态定时器让位于配置文件声明的定时器
+		m.timer.RemoveCron(cfg.Name)
+		err = m.timer.AddCronWithOptions(cfg.Name, cronExpr, opts, m.handler)
+		if err == nil {
+			m.staticTimers[cfg.Name] = struct{}{}
+		}
+		m.timersMu.Unlock()
+		if err != nil {
+			return nil, fmt.Errorf("failed to add cron %q: %w", cfg.Name, err)
+		}
+		log.InfoContextf(ctx, "[TriggerManager] registered timer trigger: name=%s, cron=%s, timezone=%s, jitter=%s",
+			cfg.Name, cronExpr, loc, jitter)
+		return nil, nil
+
+	case string(model.TriggerNATS):
+		t := NewNATSTrigger(cfg.Name)
+		if m.storageReader != nil {
+			t.SetStorageReader(m.storageReader)
+		}
+		t.SetInflightLimiter(m.natsLimiter)
+		if err := t.Init(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("failed to init NATS trigger %q: %w", cfg.Name, err)
+		}
+		log.InfoContextf(ctx, "[TriggerManager] registered NATS trigger: name=%s", cfg.Name)
+		return t, nil
+
+	case string(model.TriggerHTTP):
+		t := NewHTTPTrigger(cfg.Name, m.httpRouter)
+		if err := t.Init(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("failed to init http trigger %q: %w", cfg.Name, err)
+		}
+		log.InfoContextf(ctx, "[TriggerManager] registered http trigger: name=%s", cfg.Name)
+		return t, nil
+
+	case string(model.TriggerKafka):
+		t := NewKafkaTrigger(cfg.Name)
+		if err := t.Init(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("failed to init kafka trigger %q: %w", cfg.Name, err)
+		}
+		log.InfoContextf(ctx, "[TriggerManager] registered kafka trigger: name=%s", cfg.Name)
+		return t, nil
+
+	case string(model.TriggerWebSocket):
+		t := NewWebSocketTrigger(cfg.Name)
+		if err := t.Init(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("failed to init websocket trigger %q: %w", cfg.Name, err)
+		}
+		log.InfoContextf(ctx, "[TriggerManager] registered websocket trigger: name=%s", cfg.Name)
+		return t, nil
+
+	default:
+		return nil, fmt.Errorf("unknown trigger type %q for trigger %q", cfg.Type, cfg.Name)
+	}
+}
+
+// Reload 按新的触发器配置热更新：新增/删除/变更的定时器即时生效，NATS/Kafka/WebSocket 等常驻触发器配置变化时
+// 停止旧实例并以新配置重新启动。HTTP 触发器的路由无法注销，其增删改仅告警需重启。
+// 单个触发器失败不影响其他触发器，所有错误汇总返回；失败的触发器保留旧配置，下次 Reload 重试
+func (m *Manager) Reload(ctx context.Context, configs []model.TriggerConfig) error {
+	if dups := duplicateTriggerNames(configs); len(dups) > 0 {
+		return fmt.Errorf("duplicate trigger names: %v", dups)
+	}
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	m.mu.Lock()
+	running := m.started && !m.stopping
+	m.mu.Unlock()
+	if !running {
+		return fmt.Errorf("trigger manager not running")
+	}
+
+	desired := make(map[string]model.TriggerConfig, len(configs))
+	for _, cfg := range configs {
+		desired[cfg.Name] = cfg
+	}
+
+	var errs []error
+	// 删除：配置中已不存在的触发器
+	for name, old := range m.configs {
+		if _, ok := desired[name]; ok {
+			continue
+		}
+		if err := m.reloadOne(ctx, &old, nil); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(m.configs, name)
+	}
+	// 新增与变更：按配置顺序处理
+	for _, cfg := range configs {
+		old, exists := m.configs[cfg.Name]
+		if exists && reflect.DeepEqual(old, cfg) {
+			continue
+		}
+		var oldPtr *model.TriggerConfig
+		if exists {
+			oldPtr = &old
+		}
+		if err := m.reloadOne(ctx, oldPtr, &cfg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.configs[cfg.Name] = cfg
+	}
+	return errors.Join(errs...)
+}
+
+// reloadOne 将单个触发器从 oldCfg 切换到 newCfg；nil 表示不存在，未启用的配置视同不存在
+func (m *Manager) reloadOne(ctx context.Context, oldCfg, newCfg *model.TriggerConfig) error {
+	if oldCfg != nil && !oldCfg.IsEnabled() {
+		oldCfg = nil
+	}
+	if newCfg != nil && !newCfg.IsEnabled() {
+		newCfg = nil
+	}
+	if oldCfg == nil && newCfg == nil {
+		return nil
+	}
+	for _, c := range []*model.TriggerConfig{oldCfg, newCfg} {
+		if c != nil && c.Type == string(model.TriggerHTTP) {
+			log.WarnContextf(ctx, "[TriggerManager] http trigger %s changed, requires restart", c.Name)
+			return nil
+		}
+	}
+
+	// 先构建新实例，失败时旧实例保持运行
+	var next Trigger
+	if newCfg != nil && newCfg.Type != string(model.TriggerTimer) {
+		t, err := m.register(ctx, *newCfg)
+		if err != nil {
+			return err
+		}
+		next = t
+	}
+
+	if oldCfg != nil {
+		if oldCfg.Type == string(model.TriggerTimer) {
+			m.timersMu.Lock()
+			m.timer.RemoveCron(oldCfg.Name)
+			delete(m.staticTimers, oldCfg.Name)
+			m.timersMu.Unlock()
+		} else if old := m.removeTrigger(oldCfg.Name); old != nil {
+			if err := old.Stop(ctx); err != nil {
+				log.ErrorContextf(ctx, "[TriggerManager] failed to stop trigger %q: %v", oldCfg.Name, err)
 			}
-			m.triggers = append(m.triggers, t)
-			log.InfoContextf(ctx, "[TriggerManager] registered NATS trigger: name=%s", cfg.Name)
+		}
+		log.InfoContextf(ctx, "[TriggerManager] trigger %s (type=%s) removed by reload", oldCfg.Name, oldCfg.Type)
+	}
 
-		default:
-			return fmt.Errorf("unknown trigger type %q for trigger %q", cfg.Type, cfg.Name)
+	if newCfg == nil {
+		return nil
+	}
+	if next == nil {
+		// timer：旧条目已移除，直接注册新条目
+		_, err := m.register(ctx, *newCfg)
+		return err
+	}
+	if err := next.Start(ctx, m.handler); err != nil {
+		return fmt.Errorf("failed to start trigger %q: %w", newCfg.Name, err)
+	}
+	// Reload 与 StopAll 可并发：StopAll 已取快照时新实例不会被其停止，由此处停止且不加入运行列表
+	m.mu.Lock()
+	stopping := m.stopping
+	if !stopping {
+		m.triggers = append(m.triggers, next)
+	}
+	m.mu.Unlock()
+	if stopping {
+		if err := next.Stop(ctx); err != nil {
+			log.ErrorContextf(ctx, "[TriggerManager] failed to stop trigger %q: %v", newCfg.Name, err)
 		}
+		return fmt.Errorf("trigger %q not started by reload: %w", newCfg.Name, ErrStopping)
 	}
+	log.InfoContextf(ctx, "[TriggerManager] trigger %s (type=%s) started by reload", newCfg.Name, newCfg.Type)
 	return nil
 }
 
+// removeTrigger 从运行列表中移除指定名称的触发器并返回，不存在时返回 nil
+func (m *Manager) removeTrigger(name string) Trigger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, t := range m.triggers {
+		if t.Name() == name {
+			m.triggers = append(m.triggers[:i:i], m.triggers[i+1:]...)
+			return t
+		}
+	}
+	return nil
+}
+
+// snapshot 返回运行中触发器列表的副本，供并发读取
+func (m *Manager) snapshot() []Trigger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Trigger(nil), m.triggers...)
+}
+
+// duplicateTriggerNames 返回配置中重复出现的触发器名称（跨所有类型，按首次出现顺序）
+func duplicateTriggerNames(configs []model.TriggerConfig) []string {
+	counts := make(map[string]int, len(configs))
+	var dups []string
+	for _, cfg := range configs {
+		counts[cfg.Name]++
+		if counts[cfg.Name] == 2 {
+			dups = append(dups, cfg.Name)
+		}
+	}
+	return dups
+}
+
 // StartAll 启动所有触发器
 func (m *Manager) StartAll(ctx context.Context) error {
 	handler := m.wrapHandler()
@@ -88,16 +404,150 @@ func (m *Manager) StartAll(ctx context.Context) error {
 		}
 		log.InfoContextf(ctx, "[TriggerManager] started trigger: name=%s, type=%s", t.Name(), t.Type())
 	}
+
+	m.mu.Lock()
+	m.started = true
+	m.mu.Unlock()
 	return nil
 }
 
-// StopAll 停止所有触发器
-func (m *Manager) StopAll(ctx context.Context) {
-	for _, t := range m.triggers {
+// StopAll 停止接收新的触发事件并停止所有触发器，等待进行中的 handler 返回；
+// ctx 到期时不再等待并返回错误
+func (m *Manager) StopAll(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopping = true
+	m.mu.Unlock()
+
+	for _, t := range m.snapshot() {
 		if err := t.Stop(ctx); err != nil {
 			log.ErrorContextf(ctx, "[TriggerManager] failed to stop trigger %q: %v", t.Name(), err)
 		}
 	}
+
+	done := make(chan struct{})
+	go func() {
+		m.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		log.InfoContextf(ctx, "[TriggerManager] all in-flight handlers finished")
+	case <-ctx.Done():
+		err = fmt.Errorf("wait for in-flight handlers: %w", ctx.Err())
+	}
+
+	if m.sink != nil {
+		m.sink.stop(ctx)
+	}
+	return err
+}
+
+// acquire 登记一次 handler 调用，停止中返回 false
+func (m *Manager) acquire() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopping {
+		return false
+	}
+	m.inflight.Add(1)
+	return true
+}
+
+// CheckHealth 检查所有实现了 HealthChecker 的触发器，返回 name → error（nil 表示健康）
+func (m *Manager) CheckHealth(ctx context.Context) map[string]error {
+	triggers := m.snapshot()
+	result := make(map[string]error, len(triggers))
+	for _, t := range triggers {
+		if hc, ok := t.(HealthChecker); ok {
+			result[t.Name()] = hc.CheckHealth(ctx)
+		}
+	}
+	return result
+}
+
+// CheckReady 汇总非定时器触发器的就绪状态：实现了 ReadinessChecker 的以其为准，否则使用 HealthChecker
+func (m *Manager) CheckReady(ctx context.Context) error {
+	var errs []error
+	for _, t := range m.snapshot() {
+		switch c := t.(type) {
+		case ReadinessChecker:
+			errs = append(errs, c.CheckReady(ctx))
+		case HealthChecker:
+			errs = append(errs, c.CheckHealth(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Statuses 返回所有已注册触发器（含动态定时器）的运行状态快照，并发安全
+func (m *Manager) Statuses(ctx context.Context) []model.TriggerStatus {
+	m.mu.Lock()
+	running := m.started && !m.stopping
+	m.mu.Unlock()
+
+	health := m.CheckHealth(ctx)
+	triggers := m.snapshot()
+	timerNames := m.timer.Names()
+	statuses := make([]model.TriggerStatus, 0, len(triggers)+len(timerNames))
+	for _, name := range timerNames {
+		statuses = append(statuses, model.TriggerStatus{Name: name, Type: model.TriggerTimer, Active: running})
+	}
+	for _, t := range triggers {
+		st := model.TriggerStatus{Name: t.Name(), Type: t.Type(), Active: running}
+		if err := health[t.Name()]; err != nil {
+			st.Active = false
+			st.Error = err.Error()
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// TriggerActive 返回指定名称的触发器当前是否活跃；未注册时返回 false
+func (m *Manager) TriggerActive(ctx context.Context, name string) bool {
+	for _, st := range m.Statuses(ctx) {
+		if st.Name == name {
+			return st.Active
+		}
+	}
+	return false
+}
+
+// AddTimer 运行时添加（或替换同名的）动态定时器，下一次匹配的 Tick 起生效；
+// 不允许覆盖配置文件中声明的定时器
+func (m *Manager) AddTimer(ctx context.Context, name, cronExpr string) error {
+	if m.handler == nil {
+		return fmt.Errorf("trigger manager not initialized")
+	}
+	m.timersMu.Lock()
+	defer m.timersMu.Unlock()
+	if _, ok := m.staticTimers[name]; ok {
+		return fmt.Errorf("timer %q is defined in config and cannot be replaced at runtime", name)
+	}
+
+	m.timer.RemoveCron(name)
+	if err := m.timer.AddCron(name, cronExpr, m.handler); err != nil {
+		return fmt.Errorf("failed to add cron %q: %w", name, err)
+	}
+	log.InfoContextf(ctx, "[TriggerManager] added dynamic timer: name=%s, cron=%s", name, cronExpr)
+	return nil
+}
+
+// RemoveTimer 运行时移除动态定时器，返回是否存在；配置文件中声明的定时器不会被移除
+func (m *Manager) RemoveTimer(ctx context.Context, name string) bool {
+	m.timersMu.Lock()
+	defer m.timersMu.Unlock()
+	if _, ok := m.staticTimers[name]; ok {
+		log.WarnContextf(ctx, "[TriggerManager] timer %q is defined in config, not removed", name)
+		return false
+	}
+	removed := m.timer.RemoveCron(name)
+	if removed {
+		log.InfoContextf(ctx, "[TriggerManager] removed dynamic timer: name=%s", name)
+	}
+	return removed
 }
 
 // Timer 返回内部的 TimerTrigger，供 TRPC Timer handler 调用 Tick
@@ -105,11 +555,62 @@ func (m *Manager) Timer() *TimerTrigger {
 	return m.timer
 }
 
+// initSubscriptions 读取插件声明的订阅（plugin.Subscriber），订阅了未配置的触发器时告警
+func (m *Manager) initSubscriptions(ctx context.Context, configs []model.TriggerConfig) {
+	sub, ok := plugin.Lookup[plugin.Subscriber](m.plugin)
+	if !ok {
+		return
+	}
+
+	configured := make(map[string]struct{}, len(configs))
+	for _, cfg := range configs {
+		configured[cfg.Name] = struct{}{}
+	}
+
+	m.subscriptions = make(map[string]struct{})
+	for _, name := range sub.Subscriptions() {
+		m.subscriptions[name] = struct{}{}
+		if _, ok := configured[name]; !ok {
+			log.WarnContextf(ctx, "[TriggerManager] plugin %s subscribes to unknown trigger %q", m.plugin.Name(), name)
+		}
+	}
+	log.InfoContextf(ctx, "[TriggerManager] plugin %s subscriptions: %v", m.plugin.Name(), sub.Subscriptions())
+}
+
+// subscribed 判断插件是否订阅了该触发器；插件未声明订阅时接收全部事件
+func (m *Manager) subscribed(name string) bool {
+	if m.subscriptions == nil {
+		return true
+	}
+	_, ok := m.subscriptions[name]
+	return ok
+}
+
 // wrapHandler 包装 plugin.OnTrigger，注入 metadata/TaskStore 快照，并处理响应
 func (m *Manager) wrapHandler() TriggerHandler {
-	return func(ctx context.Context, event *model.TriggerEvent) error {
+	return func(ctx context.Context, event *model.TriggerEvent) (err error) {
+		if !m.acquire() {
+			return ErrStopping
+		}
+		defer m.inflight.Done()
+
+		if !m.subscribed(event.Name) {
+			log.DebugContextf(ctx, "[TriggerManager] plugin %s not subscribed to trigger %s, event ignored",
+				m.plugin.Name(), event.Name)
+			return nil
+		}
+
 		ctx = trpc.CloneContext(ctx)
 
+		// 延续消息头中的 traceparent（如 NATS Header），HTTP 触发器已由 Gateway 提取
+		ctx = tracing.ExtractMap(ctx, event.Headers())
+		ctx, span := m.tracer.Start(ctx, event.Name, trace.SpanKindConsumer,
+			attribute.String("scf.trigger.name", event.Name),
+			attribute.String("scf.trigger.type", string(event.Type)),
+			attribute.String("scf.plugin", m.plugin.Name()),
+		)
+		defer func() { tracing.End(span, err) }()
+
 		nodeID, version := m.injectMetadata(event)
 
 		ctx = log.WithContextFields(ctx,
@@ -127,12 +628,26 @@ func (m *Manager) wrapHandler() TriggerHandler {
 		log.InfoContextf(ctx, "[TriggerManager] dispatching trigger: name=%s, type=%s",
 			event.Name, event.Type)
 
+		if m.sink != nil {
+			defer func() {
+				m.sink.emit(ctx, event, err)
+			}()
+		}
+
+		start := time.Now()
 		resp, err := m.plugin.OnTrigger(ctx, event)
+		elapsed := time.Since(start)
 		if err != nil {
 			log.ErrorContextf(ctx, "[TriggerManager] trigger %s failed: %v", event.Name, err)
 		}
 
 		m.logResponse(ctx, event.Name, resp, err)
+		if dErr := dispositionError(resp); err == nil && dErr != nil {
+			m.metrics.ObserveTriggerResult(event.Name, string(event.Type), elapsed, dispositionResult(dErr))
+			log.InfoContextf(ctx, "[TriggerManager] trigger %s disposition=%s, skip reporting", event.Name, resp.Disposition)
+			return dErr
+		}
+		m.metrics.ObserveTrigger(event.Name, string(event.Type), elapsed, err)
 		m.reportTaskResults(ctx, resp)
 		m.writeResponse(ctx, resp)
 
@@ -166,6 +681,16 @@ func (m *Manager) injectMetadata(event *model.TriggerEvent) (nodeID, version str
 	return
 }
 
+// eventFireTime 返回事件的计划触发时刻（Metadata fire_time，UTC），缺失或无法解析时取当前时刻
+func eventFireTime(event *model.TriggerEvent) time.Time {
+	if v := event.Metadata["fire_time"]; v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Now().UTC()
+}
+
 // injectTaskStore 注入 TaskStore 快照到 event，对 timer 触发器执行调度筛选。
 // 返回 true 表示无 jobs 可执行，调用方应跳过后续处理。
 func (m *Manager) injectTaskStore(ctx context.Context, event *model.TriggerEvent) (skip bool) {
@@ -184,7 +709,8 @@ func (m *Manager) injectTaskStore(ctx context.Context, event *model.TriggerEvent
 
 	// 对 timer 类型触发器执行框架调度筛选
 	if event.Type == model.TriggerTimer {
-		jobs := FilterTaskJobs(tasks, time.Now().UTC())
+		// 按事件的计划触发时刻筛选：补偿触发（catch_up）的事件在当前时刻之后才执行，应得到其所属周期的 jobs
+		jobs := FilterTaskJobs(tasks, eventFireTime(event))
 		if len(jobs) == 0 {
 			log.InfoContextf(ctx, "[TriggerManager] no jobs to execute, skipping trigger %s", event.Name)
 			return true
@@ -205,6 +731,29 @@ func (m *Manager) injectTaskStore(ctx context.Context, event *model.TriggerEvent
 	return false
 }
 
+// dispositionResult 返回 Disposition 哨兵错误对应的指标结果标签：skip 不计为已处理，retry 单独计数
+func dispositionResult(dErr error) string {
+	if errors.Is(dErr, ErrEventRetry) {
+		return metrics.ResultRetry
+	}
+	return metrics.ResultSkipped
+}
+
+// dispositionError 将插件返回的 Disposition 转换为对应的哨兵错误，ack 或为空时返回 nil
+func dispositionError(resp *model.TriggerResponse) error {
+	if resp == nil {
+		return nil
+	}
+	switch resp.Disposition {
+	case model.DispositionSkip:
+		return ErrEventSkipped
+	case model.DispositionRetry:
+		return ErrEventRetry
+	default:
+		return nil
+	}
+}
+
 // logResponse 记录 OnTrigger 返回摘要
 func (m *Manager) logResponse(ctx context.Context, triggerName string, resp *model.TriggerResponse, err error) {
 	var taskResults, dataPoints, writeGroups int
@@ -0,0 +1,400 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mooyang-code/scf-framework/config"
+	"github.com/mooyang-code/scf-framework/metrics"
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/mooyang-code/scf-framework/plugin"
+)
+
+// recordingPlugin 测试用插件：记录收到的事件
+type recordingPlugin struct {
+	mu     sync.Mutex
+	events []*model.TriggerEvent
+}
+
+func (p *recordingPlugin) Name() string                                        { return "recorder" }
+func (p *recordingPlugin) Init(ctx context.Context, fw plugin.Framework) error { return nil }
+func (p *recordingPlugin) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error) {
+	p.mu.Lock()
+	p.events = append(p.events, event)
+	p.mu.Unlock()
+	return nil, nil
+}
+
+// Events 返回已收到事件的副本
+func (p *recordingPlugin) Events() []*model.TriggerEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*model.TriggerEvent(nil), p.events...)
+}
+
+// subscribingPlugin 实现 plugin.Subscriber 的 recordingPlugin
+type subscribingPlugin struct {
+	recordingPlugin
+	subs []string
+}
+
+func (p *subscribingPlugin) Subscriptions() []string { return p.subs }
+
+// timerConfig 构造定时器触发器配置
+func timerConfig(name, cron string) model.TriggerConfig {
+	return model.TriggerConfig{Name: name, Type: string(model.TriggerTimer), Settings: map[string]interface{}{"cron": cron}}
+}
+
+// timerNames 返回已注册定时器条目的名称
+func timerNames(tt *TimerTrigger) []string {
+	tt.mu.RLock()
+	defer tt.mu.RUnlock()
+	names := make([]string, 0, len(tt.entries))
+	for _, entry := range tt.entries {
+		names = append(names, entry.name)
+	}
+	return names
+}
+
+// newTestRuntime 创建指定 NodeID 与版本号的 RuntimeState
+func newTestRuntime(nodeID, version string) *config.RuntimeState {
+	rs := config.NewRuntimeState(&config.FrameworkConfig{System: config.SystemConfig{Version: version}})
+	rs.SetNodeID(nodeID)
+	return rs
+}
+
+func TestManagerInitDuplicateNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   []model.TriggerConfig
+		warn      bool
+		wantErr   string
+		wantTimer []string
+	}{
+		{
+			name:      "unique names",
+			configs:   []model.TriggerConfig{timerConfig("a", "* * * * *"), timerConfig("b", "*/5 * * * *")},
+			wantTimer: []string{"a", "b"},
+		},
+		{
+			name:    "duplicate timers",
+			configs: []model.TriggerConfig{timerConfig("a", "* * * * *"), timerConfig("a", "*/5 * * * *")},
+			wantErr: "duplicate trigger names: [a]",
+		},
+		{
+			name: "duplicate across types",
+			configs: []model.TriggerConfig{
+				timerConfig("collect", "* * * * *"),
+				{Name: "collect", Type: string(model.TriggerNATS)},
+				timerConfig("x", "* * * * *"),
+				timerConfig("x", "* * * * *"),
+				timerConfig("collect", "* * * * *"),
+			},
+			wantErr: "duplicate trigger names: [collect x]",
+		},
+		{
+			name:      "duplicate timers only warn",
+			configs:   []model.TriggerConfig{timerConfig("a", "* * * * *"), timerConfig("a", "*/5 * * * *")},
+			warn:      true,
+			wantTimer: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(&recordingPlugin{}, nil, nil, nil, nil, nil, nil)
+			m.SetWarnOnDuplicateNames(tt.warn)
+
+			err := m.Init(context.Background(), tt.configs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Init() error = %v, want %q", err, tt.wantErr)
+				}
+				if m.Timer().HasEntries() {
+					t.Fatalf("Init() registered timers %v despite duplicate names", timerNames(m.Timer()))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if got := strings.Join(timerNames(m.Timer()), ","); got != strings.Join(tt.wantTimer, ",") {
+				t.Fatalf("timer names = %q, want %q", got, strings.Join(tt.wantTimer, ","))
+			}
+		})
+	}
+}
+
+func TestManagerInjectsRuntimeMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		runtime     *config.RuntimeState
+		metadata    map[string]string
+		wantNodeID  string
+		wantVersion string
+		wantExtra   map[string]string
+	}{
+		{
+			name:        "runtime state injected",
+			runtime:     newTestRuntime("node-1", "v1.2.3"),
+			wantNodeID:  "node-1",
+			wantVersion: "v1.2.3",
+		},
+		{
+			name:        "existing metadata kept",
+			runtime:     newTestRuntime("node-2", "v2"),
+			metadata:    map[string]string{"source": "test"},
+			wantNodeID:  "node-2",
+			wantVersion: "v2",
+			wantExtra:   map[string]string{"source": "test"},
+		},
+		{
+			name: "nil runtime injects nothing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &recordingPlugin{}
+			m := NewManager(p, nil, tt.runtime, nil, nil, nil, nil)
+			if err := m.Init(context.Background(), []model.TriggerConfig{timerConfig("tick", "* * * * *")}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+
+			event := &model.TriggerEvent{Type: model.TriggerTimer, Name: "tick", Metadata: tt.metadata}
+			if err := m.wrapHandler()(context.Background(), event); err != nil {
+				t.Fatalf("handler() error = %v", err)
+			}
+			events := p.Events()
+			if len(events) != 1 {
+				t.Fatalf("plugin received %d events, want 1", len(events))
+			}
+			got := events[0].Metadata
+			if tt.runtime != nil && got["nodeID"] == "" {
+				t.Fatalf("event.Metadata[nodeID] is empty")
+			}
+			if got["nodeID"] != tt.wantNodeID || got["version"] != tt.wantVersion {
+				t.Fatalf("metadata nodeID/version = %q/%q, want %q/%q",
+					got["nodeID"], got["version"], tt.wantNodeID, tt.wantVersion)
+			}
+			for k, v := range tt.wantExtra {
+				if got[k] != v {
+					t.Fatalf("metadata[%s] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestManagerSubscriptions(t *testing.T) {
+	configs := []model.TriggerConfig{
+		timerConfig("collect", "* * * * *"),
+		timerConfig("calc", "*/5 * * * *"),
+		timerConfig("cleanup", "0 * * * *"),
+	}
+	collector := &subscribingPlugin{subs: []string{"collect"}}
+	calculator := &subscribingPlugin{subs: []string{"calc", "cleanup"}}
+	all := &recordingPlugin{}
+	tests := []struct {
+		name   string
+		plugin interface {
+			plugin.Plugin
+			Events() []*model.TriggerEvent
+		}
+		want []string
+	}{
+		{name: "collector", plugin: collector, want: []string{"collect"}},
+		{name: "calculator", plugin: calculator, want: []string{"calc", "cleanup"}},
+		{name: "no subscriptions receives all", plugin: all, want: []string{"collect", "calc", "cleanup"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(tt.plugin, nil, nil, nil, nil, nil, nil)
+			if err := m.Init(context.Background(), configs); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			for _, cfg := range configs {
+				if err := m.wrapHandler()(context.Background(), &model.TriggerEvent{Type: model.TriggerTimer, Name: cfg.Name}); err != nil {
+					t.Fatalf("handler(%s) error = %v", cfg.Name, err)
+				}
+			}
+			var got []string
+			for _, event := range tt.plugin.Events() {
+				got = append(got, event.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("dispatched events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// respondingPlugin 测试用插件：OnTrigger 返回固定的响应与错误
+type respondingPlugin struct {
+	recordingPlugin
+	resp *model.TriggerResponse
+	err  error
+}
+
+func (p *respondingPlugin) OnTrigger(ctx context.Context, event *model.TriggerEvent) (*model.TriggerResponse, error) {
+	p.recordingPlugin.OnTrigger(ctx, event)
+	return p.resp, p.err
+}
+
+func TestManagerDispositionMetrics(t *testing.T) {
+	errPlugin := errors.New("plugin failed")
+	tests := []struct {
+		name       string
+		resp       *model.TriggerResponse
+		err        error
+		wantErr    error
+		wantResult string
+	}{
+		{name: "nil response counts as success", wantResult: metrics.ResultSuccess},
+		{name: "ack counts as success", resp: &model.TriggerResponse{Disposition: model.DispositionAck}, wantResult: metrics.ResultSuccess},
+		{name: "skip is not counted as processed", resp: &model.TriggerResponse{Disposition: model.DispositionSkip},
+			wantErr: ErrEventSkipped, wantResult: metrics.ResultSkipped},
+		{name: "retry has its own result", resp: &model.TriggerResponse{Disposition: model.DispositionRetry},
+			wantErr: ErrEventRetry, wantResult: metrics.ResultRetry},
+		{name: "plugin error wins over disposition", resp: &model.TriggerResponse{Disposition: model.DispositionSkip},
+			err: errPlugin, wantErr: errPlugin, wantResult: metrics.ResultError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := metrics.New(nil)
+			m := NewManager(&respondingPlugin{resp: tt.resp, err: tt.err}, nil, nil, nil, nil, nil, nil)
+			m.SetMetrics(mt)
+			if err := m.Init(context.Background(), nil); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+
+			err := m.handler(context.Background(), &model.TriggerEvent{Type: model.TriggerNATS, Name: "orders"})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("handler() error = %v, want %v", err, tt.wantErr)
+			}
+
+			rec := httptest.NewRecorder()
+			mt.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			body := rec.Body.String()
+			want := fmt.Sprintf(`scf_trigger_invocations_total{result=%q,trigger="orders",type="nats"} 1`, tt.wantResult)
+			if !strings.Contains(body, want) {
+				t.Fatalf("metrics missing %s:\n%s", want, grepLines(body, "scf_trigger_invocations_total"))
+			}
+			if n := strings.Count(body, "scf_trigger_invocations_total{"); n != 1 {
+				t.Fatalf("got %d invocation series, want 1:\n%s", n, grepLines(body, "scf_trigger_invocations_total"))
+			}
+		})
+	}
+}
+
+// grepLines 返回 text 中包含 substr 的行
+func grepLines(text, substr string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.Contains(line, substr) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestManagerReloadDuringStop(t *testing.T) {
+	wsConfig := model.TriggerConfig{Name: "ticker", Type: string(model.TriggerWebSocket),
+		Settings: map[string]interface{}{"url": "ws://127.0.0.1:1/feed"}}
+	tests := []struct {
+		name    string
+		reload  func(m *Manager) error
+		wantErr string
+	}{
+		{
+			name:    "reload after stop is rejected",
+			reload:  func(m *Manager) error { return m.Reload(context.Background(), []model.TriggerConfig{wsConfig}) },
+			wantErr: "trigger manager not running",
+		},
+		{
+			// Reload 已通过运行检查、StopAll 在其启动新实例期间取快照
+			name:    "trigger started after StopAll snapshot is stopped",
+			reload:  func(m *Manager) error { return m.reloadOne(context.Background(), nil, &wsConfig) },
+			wantErr: ErrStopping.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(&recordingPlugin{}, nil, nil, nil, nil, nil, nil)
+			if err := m.Init(context.Background(), nil); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if err := m.StartAll(context.Background()); err != nil {
+				t.Fatalf("StartAll() error = %v", err)
+			}
+			if err := m.StopAll(context.Background()); err != nil {
+				t.Fatalf("StopAll() error = %v", err)
+			}
+
+			if err := tt.reload(m); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("reload error = %v, want %q", err, tt.wantErr)
+			}
+			if got := m.snapshot(); len(got) != 0 {
+				t.Fatalf("running triggers after stop = %d, want 0", len(got))
+			}
+		})
+	}
+}
+
+func TestManagerFiltersJobsByFireTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantJobs []string // 期望的 job 周期，nil 表示跳过本次触发
+	}{
+		{
+			// 补偿触发：10:00 的周期在之后才被回放，仍按 10:00 筛选
+			name:     "catch-up replay gets jobs of its fire_time",
+			metadata: map[string]string{"fire_time": "2026-03-02T10:00:00Z", "catch_up": "true"},
+			wantJobs: []string{"1m", "5m", "1h"},
+		},
+		{
+			name:     "fire_time in timer location",
+			metadata: map[string]string{"fire_time": "2026-03-02T18:05:00+08:00"},
+			wantJobs: []string{"1m", "5m"},
+		},
+		{
+			name:     "only intervals due at fire_time",
+			metadata: map[string]string{"fire_time": "2026-03-02T10:03:00Z"},
+			wantJobs: []string{"1m"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := config.NewTaskInstanceStore()
+			store.UpdateTaskInstances([]*model.TaskInstance{
+				{TaskID: "t1", TaskParams: `{"intervals":["1m","5m","1h"]}`},
+			})
+			p := &recordingPlugin{}
+			m := NewManager(p, store, nil, nil, nil, nil, nil)
+			if err := m.Init(context.Background(), []model.TriggerConfig{timerConfig("tick", "* * * * *")}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+
+			event := &model.TriggerEvent{Type: model.TriggerTimer, Name: "tick", Metadata: tt.metadata}
+			if err := m.handler(context.Background(), event); err != nil {
+				t.Fatalf("handler() error = %v", err)
+			}
+			events := p.Events()
+			if len(events) != 1 {
+				t.Fatalf("plugin received %d events, want 1", len(events))
+			}
+			var got []string
+			for _, job := range events[0].Jobs {
+				got = append(got, job.Interval)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantJobs, ",") {
+				t.Fatalf("jobs = %v, want %v", got, tt.wantJobs)
+			}
+		})
+	}
+}
@@ -3,9 +3,13 @@ package trigger
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mooyang-code/scf-framework/cache"
@@ -26,11 +30,32 @@ type NATSConfig struct {
 	AckWait      int
 	MaxDeliver   int
 	FetchMaxWait int
+	Concurrency  int // 并行处理的消息数，> 1 时不保证处理顺序
+	// DriftCheckInterval 消费者配置漂移检查间隔（秒），0 表示不检查
+	DriftCheckInterval int
+	// StartDelay / StartDelayJitter 首次拉取前等待 StartDelay 秒再加 [0, StartDelayJitter) 秒随机抖动，错开冷启动拉取
+	StartDelay       int
+	StartDelayJitter int
+	// ReadyGrace 断连超过该时长（秒）后就绪检查失败，容忍短暂的网络抖动与重连
+	ReadyGrace int
+	// DeliverPolicy 消费者首次创建时的起始投递位置：new（默认）/ all / last / by_start_time / by_start_seq
+	DeliverPolicy string
+	StartTime     time.Time // deliver_policy=by_start_time 时的起始时间（RFC3339）
+	StartSeq      uint64    // deliver_policy=by_start_seq 时的起始 stream 序号
+	// Durable false 时创建临时消费者（忽略 consumer_name，断开后由服务端清理），默认 true
+	Durable bool
+	// Security TLS 与鉴权（见 nats_auth.go）
+	Security NATSSecurityConfig
+	// DeadLetterSubject 最后一次投递仍处理失败时，将原始消息及错误信息发布到该 subject 后 Ack，空表示不启用
+	DeadLetterSubject string
+	// 高可用相关
+	Replicas      int  // 消费者副本数，0 表示继承 stream 副本数
+	MemoryStorage bool // 消费者状态使用内存存储
 	// 缓存相关
-	CacheEnabled  bool
+	CacheEnabled   bool
 	CacheKeyPrefix string
-	CacheMaxItems int
-	CacheTTL      int64 // 秒
+	CacheMaxItems  int
+	CacheTTL       int64 // 秒
 	// 回源相关
 	BackfillEnabled   bool
 	BackfillDatasetID int
@@ -44,10 +69,16 @@ type NATSTrigger struct {
 	conn          *nats.Conn
 	js            jetstream.JetStream
 	consumer      jetstream.Consumer
+	consumerCfg   jetstream.ConsumerConfig // 期望的消费者配置，用于漂移检查与重建
 	handler       TriggerHandler
 	cancel        context.CancelFunc
+	loopDone      chan struct{}
 	storageReader *storage.Reader
 	backfillMu    sync.Mutex
+	cacheMu       sync.Mutex       // 并行处理时串行化 K线缓存的读-改-写
+	limiter       *InflightLimiter // 进程级在途消息额度，nil 表示不限制
+
+	disconnectedAt atomic.Int64 // 最近一次断连的时间（UnixNano），0 表示已连接
 }
 
 // NewNATSTrigger 创建 NATSTrigger
@@ -60,6 +91,11 @@ func (t *NATSTrigger) SetStorageReader(r *storage.Reader) {
 	t.storageReader = r
 }
 
+// SetInflightLimiter 设置所有 NATS 触发器共享的在途消息额度
+func (t *NATSTrigger) SetInflightLimiter(l *InflightLimiter) {
+	t.limiter = l
+}
+
 // Name 返回触发器名称
 func (t *NATSTrigger) Name() string {
 	return t.name
@@ -79,6 +115,12 @@ func (t *NATSTrigger) Init(_ context.Context, cfg model.TriggerConfig) error {
 		return fmt.Errorf("NATS trigger %q missing url setting", t.name)
 	}
 
+	security, err := parseSecuritySettings(t.name, s)
+	if err != nil {
+		return err
+	}
+	t.config.Security = security
+
 	t.config.Stream, _ = s["stream"].(string)
 	t.config.Subject, _ = s["subject"].(string)
 	t.config.ConsumerName, _ = s["consumer_name"].(string)
@@ -87,6 +129,40 @@ func (t *NATSTrigger) Init(_ context.Context, cfg model.TriggerConfig) error {
 	t.config.AckWait = getIntSetting(s, "ack_wait", 30)
 	t.config.MaxDeliver = getIntSetting(s, "max_deliver", 3)
 	t.config.FetchMaxWait = getIntSetting(s, "fetch_max_wait", 5)
+	t.config.Concurrency = getIntSetting(s, "concurrency", 1)
+	if t.config.Concurrency < 1 {
+		t.config.Concurrency = 1
+	}
+	t.config.DriftCheckInterval = getIntSetting(s, "drift_check_interval", 60)
+	if t.config.DriftCheckInterval < 0 {
+		t.config.DriftCheckInterval = 0
+	}
+	t.config.StartDelay = getIntSetting(s, "start_delay", 0)
+	t.config.StartDelayJitter = getIntSetting(s, "start_delay_jitter", 0)
+	if t.config.StartDelay < 0 || t.config.StartDelayJitter < 0 {
+		return fmt.Errorf("NATS trigger %q start_delay and start_delay_jitter must be >= 0", t.name)
+	}
+	t.config.ReadyGrace = getIntSetting(s, "ready_grace", 30)
+	if t.config.ReadyGrace < 0 {
+		t.config.ReadyGrace = 0
+	}
+	if err := t.parseDeliverSettings(s); err != nil {
+		return err
+	}
+	t.config.DeadLetterSubject, _ = s["dead_letter_subject"].(string)
+	if t.config.DeadLetterSubject != "" && t.config.DeadLetterSubject == t.config.Subject {
+		return fmt.Errorf("NATS trigger %q dead_letter_subject must differ from subject", t.name)
+	}
+
+	// 高可用配置
+	t.config.Replicas = getIntSetting(s, "replicas", 0)
+	if t.config.Replicas < 0 || t.config.Replicas > maxConsumerReplicas {
+		return fmt.Errorf("NATS trigger %q invalid replicas %d: must be between 0 and %d",
+			t.name, t.config.Replicas, maxConsumerReplicas)
+	}
+	if v, ok := s["memory_storage"].(bool); ok {
+		t.config.MemoryStorage = v
+	}
 
 	// 缓存配置
 	if v, ok := s["cache_enabled"].(bool); ok {
@@ -115,6 +191,90 @@ func (t *NATSTrigger) Init(_ context.Context, cfg model.TriggerConfig) error {
 	return nil
 }
 
+// parseDeliverSettings 解析 deliver_policy/start_time/start_seq/durable 并校验组合：
+// start_time 仅用于 by_start_time，start_seq 仅用于 by_start_seq
+func (t *NATSTrigger) parseDeliverSettings(s map[string]interface{}) error {
+	t.config.Durable = true
+	if v, ok := s["durable"].(bool); ok {
+		t.config.Durable = v
+	}
+
+	t.config.DeliverPolicy, _ = s["deliver_policy"].(string)
+	if t.config.DeliverPolicy == "" {
+		t.config.DeliverPolicy = "new"
+	}
+	startTime, _ := s["start_time"].(string)
+	startSeq := getIntSetting(s, "start_seq", 0)
+	if startSeq < 0 {
+		return fmt.Errorf("NATS trigger %q start_seq must be >= 0, got %d", t.name, startSeq)
+	}
+
+	switch t.config.DeliverPolicy {
+	case "new", "all", "last":
+		if startTime != "" || startSeq != 0 {
+			return fmt.Errorf("NATS trigger %q start_time/start_seq require deliver_policy by_start_time/by_start_seq, got %q",
+				t.name, t.config.DeliverPolicy)
+		}
+	case "by_start_time":
+		if startSeq != 0 {
+			return fmt.Errorf("NATS trigger %q start_seq is not allowed with deliver_policy by_start_time", t.name)
+		}
+		if startTime == "" {
+			return fmt.Errorf("NATS trigger %q deliver_policy by_start_time requires start_time", t.name)
+		}
+		ts, err := time.Parse(time.RFC3339, startTime)
+		if err != nil {
+			return fmt.Errorf("NATS trigger %q invalid start_time %q: %w", t.name, startTime, err)
+		}
+		t.config.StartTime = ts
+	case "by_start_seq":
+		if startTime != "" {
+			return fmt.Errorf("NATS trigger %q start_time is not allowed with deliver_policy by_start_seq", t.name)
+		}
+		if startSeq == 0 {
+			return fmt.Errorf("NATS trigger %q deliver_policy by_start_seq requires start_seq > 0", t.name)
+		}
+		t.config.StartSeq = uint64(startSeq)
+	default:
+		return fmt.Errorf("NATS trigger %q invalid deliver_policy %q: must be new, all, last, by_start_time or by_start_seq",
+			t.name, t.config.DeliverPolicy)
+	}
+	return nil
+}
+
+// consumerConfig 由 NATSConfig 生成期望的消费者配置
+func (t *NATSTrigger) consumerConfig() jetstream.ConsumerConfig {
+	cfg := jetstream.ConsumerConfig{
+		FilterSubject: t.config.Subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       time.Duration(t.config.AckWait) * time.Second,
+		MaxDeliver:    t.config.MaxDeliver,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+		Replicas:      t.config.Replicas,
+		MemoryStorage: t.config.MemoryStorage,
+	}
+	if t.config.Durable {
+		cfg.Durable = t.config.ConsumerName
+	}
+	switch t.config.DeliverPolicy {
+	case "all":
+		cfg.DeliverPolicy = jetstream.DeliverAllPolicy
+	case "last":
+		cfg.DeliverPolicy = jetstream.DeliverLastPolicy
+	case "by_start_time":
+		startTime := t.config.StartTime
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &startTime
+	case "by_start_seq":
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = t.config.StartSeq
+	}
+	return cfg
+}
+
+// maxConsumerReplicas JetStream 集群允许的最大副本数
+const maxConsumerReplicas = 5
+
 // getIntSetting 从 settings map 中提取 int 值（兼容 int / float64）
 func getIntSetting(s map[string]interface{}, key string, defaultVal int) int {
 	if v, ok := s[key].(int); ok {
@@ -126,24 +286,68 @@ func getIntSetting(s map[string]interface{}, key string, defaultVal int) int {
 	return defaultVal
 }
 
+// parseDurationSetting 从 settings map 中提取时长：字符串按 time.ParseDuration 解析（如 "10s"），
+// 数字视为秒；未配置时返回 0
+func parseDurationSetting(s map[string]interface{}, key string) (time.Duration, error) {
+	switch v := s[key].(type) {
+	case nil:
+		return 0, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, err
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("%s must be >= 0, got %s", key, v)
+		}
+		return d, nil
+	case int:
+		if v < 0 {
+			return 0, fmt.Errorf("%s must be >= 0, got %d", key, v)
+		}
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		if v < 0 {
+			return 0, fmt.Errorf("%s must be >= 0, got %v", key, v)
+		}
+		return time.Duration(v * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("%s must be a duration string or number of seconds, got %T", key, v)
+	}
+}
+
 // Start 连接 NATS，创建 JetStream Pull Consumer，启动 consumeLoop
 func (t *NATSTrigger) Start(ctx context.Context, handler TriggerHandler) error {
 	t.handler = handler
 
-	nc, err := nats.Connect(t.config.URL,
+	securityOpts, err := t.config.Security.connectOptions()
+	if err != nil {
+		return fmt.Errorf("failed to build NATS options for trigger %q: %w", t.name, err)
+	}
+	opts := []nats.Option{
 		nats.RetryOnFailedConnect(true),
 		nats.MaxReconnects(-1),
-		nats.ReconnectWait(2*time.Second),
+		nats.ReconnectWait(2 * time.Second),
+		nats.ConnectHandler(func(_ *nats.Conn) {
+			t.disconnectedAt.Store(0)
+		}),
 		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			t.disconnectedAt.CompareAndSwap(0, time.Now().UnixNano())
 			log.WarnContextf(ctx, "[NATSTrigger] %s disconnected: %v", t.name, err)
 		}),
 		nats.ReconnectHandler(func(_ *nats.Conn) {
+			t.disconnectedAt.Store(0)
 			log.InfoContextf(ctx, "[NATSTrigger] %s reconnected", t.name)
 		}),
-	)
+	}
+	nc, err := nats.Connect(t.config.URL, append(opts, securityOpts...)...)
 	if err != nil {
 		return fmt.Errorf("failed to connect NATS for trigger %q: %w", t.name, err)
 	}
+	if !nc.IsConnected() {
+		// RetryOnFailedConnect：首次连接在后台重试，从此刻起计算断连时长
+		t.disconnectedAt.CompareAndSwap(0, time.Now().UnixNano())
+	}
 	t.conn = nc
 
 	js, err := jetstream.New(nc)
@@ -153,46 +357,126 @@ func (t *NATSTrigger) Start(ctx context.Context, handler TriggerHandler) error {
 	}
 	t.js = js
 
-	consumerCfg := jetstream.ConsumerConfig{
-		Durable:       t.config.ConsumerName,
-		FilterSubject: t.config.Subject,
-		AckPolicy:     jetstream.AckExplicitPolicy,
-		AckWait:       time.Duration(t.config.AckWait) * time.Second,
-		MaxDeliver:    t.config.MaxDeliver,
-		DeliverPolicy: jetstream.DeliverNewPolicy,
+	if err := t.validateReplicas(ctx); err != nil {
+		nc.Close()
+		return err
 	}
 
+	consumerCfg := t.consumerConfig()
+
 	cons, err := js.CreateOrUpdateConsumer(ctx, t.config.Stream, consumerCfg)
 	if err != nil {
 		nc.Close()
 		return fmt.Errorf("failed to create NATS consumer for trigger %q: %w", t.name, err)
 	}
 	t.consumer = cons
+	t.consumerCfg = consumerCfg
 
 	loopCtx, cancel := context.WithCancel(ctx)
 	t.cancel = cancel
+	t.loopDone = make(chan struct{})
 
 	go t.consumeLoop(loopCtx)
 
-	log.InfoContextf(ctx, "[NATSTrigger] %s started: stream=%s, subject=%s, consumer=%s, cache=%v, backfill=%v",
-		t.name, t.config.Stream, t.config.Subject, t.config.ConsumerName,
+	log.InfoContextf(ctx, "[NATSTrigger] %s started: stream=%s, subject=%s, consumer=%s, durable=%v, deliver=%s, concurrency=%d, replicas=%d, memory=%v, cache=%v, backfill=%v",
+		t.name, t.config.Stream, t.config.Subject, cons.CachedInfo().Name, t.config.Durable, t.config.DeliverPolicy,
+		t.config.Concurrency, t.config.Replicas, t.config.MemoryStorage,
 		t.config.CacheEnabled, t.config.BackfillEnabled)
 	return nil
 }
 
-// Stop 停止消费循环并关闭连接
-func (t *NATSTrigger) Stop(_ context.Context) error {
+// validateReplicas 校验消费者副本数不超过 stream 副本数（即可用的集群节点数）
+func (t *NATSTrigger) validateReplicas(ctx context.Context) error {
+	if t.config.Replicas <= 1 {
+		return nil
+	}
+
+	stream, err := t.js.Stream(ctx, t.config.Stream)
+	if err != nil {
+		return fmt.Errorf("failed to get NATS stream %q for trigger %q: %w", t.config.Stream, t.name, err)
+	}
+
+	streamReplicas := stream.CachedInfo().Config.Replicas
+	if streamReplicas < 1 {
+		streamReplicas = 1
+	}
+	if t.config.Replicas > streamReplicas {
+		return fmt.Errorf("NATS trigger %q replicas %d exceeds stream %q replicas %d",
+			t.name, t.config.Replicas, t.config.Stream, streamReplicas)
+	}
+	return nil
+}
+
+// Stop 停止消费循环，等待当前批次处理完成（以 ctx 为截止）后关闭连接
+func (t *NATSTrigger) Stop(ctx context.Context) error {
 	if t.cancel != nil {
 		t.cancel()
 	}
+
+	var err error
+	if t.loopDone != nil {
+		select {
+		case <-t.loopDone:
+		case <-ctx.Done():
+			err = fmt.Errorf("NATS trigger %q consume loop not drained: %w", t.name, ctx.Err())
+		}
+	}
+
 	if t.conn != nil {
 		t.conn.Close()
 	}
+	return err
+}
+
+// CheckHealth 检查 NATS 连接状态
+func (t *NATSTrigger) CheckHealth(_ context.Context) error {
+	if t.conn == nil {
+		return fmt.Errorf("NATS trigger %q not started", t.name)
+	}
+	if !t.conn.IsConnected() {
+		return fmt.Errorf("NATS trigger %q not connected: status=%s", t.name, t.conn.Status())
+	}
 	return nil
 }
 
-// consumeLoop 持续拉取并处理 NATS 消息
+// CheckReady 就绪检查：断连未超过 ReadyGrace 时仍视为就绪，避免短暂重连导致实例被摘除
+func (t *NATSTrigger) CheckReady(ctx context.Context) error {
+	if t.conn == nil || t.conn.IsConnected() {
+		return t.CheckHealth(ctx)
+	}
+	since := t.disconnectedAt.Load()
+	if since == 0 {
+		return nil
+	}
+	down := time.Since(time.Unix(0, since))
+	if down < time.Duration(t.config.ReadyGrace)*time.Second {
+		return nil
+	}
+	return fmt.Errorf("NATS trigger %q disconnected for %v: status=%s", t.name, down.Truncate(time.Second), t.conn.Status())
+}
+
+// consumeLoop 持续拉取并处理 NATS 消息。Concurrency > 1 时最多 N 条消息并行处理，
+// 各自独立 Ack/Nak，消息处理顺序不再保证；退出前等待进行中的消息处理完成
 func (t *NATSTrigger) consumeLoop(ctx context.Context) {
+	defer close(t.loopDone)
+
+	fetchLog := newErrLogLimiter("[NATSTrigger] "+t.name+" fetch", defaultErrLogInterval)
+	driftInterval := time.Duration(t.config.DriftCheckInterval) * time.Second
+	lastDriftCheck := time.Now()
+	sem := make(chan struct{}, t.config.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	if delay := t.startDelay(); delay > 0 {
+		log.InfoContextf(ctx, "[NATSTrigger] %s delaying first fetch by %s", t.name, delay)
+		select {
+		case <-ctx.Done():
+			log.InfoContextf(ctx, "[NATSTrigger] %s consume loop exiting", t.name)
+			return
+		case <-time.After(delay):
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -201,50 +485,304 @@ func (t *NATSTrigger) consumeLoop(ctx context.Context) {
 		default:
 		}
 
-		msgs, err := t.consumer.Fetch(t.config.BatchSize,
+		// 周期性检查消费者配置漂移，仅在拉取间隙执行，与 Fetch 不并发
+		if driftInterval > 0 && time.Since(lastDriftCheck) >= driftInterval {
+			lastDriftCheck = time.Now()
+			t.checkConsumerDrift(ctx)
+		}
+
+		// 先申请进程级在途额度，额度耗尽时在此阻塞，不再拉取
+		budget, err := t.limiter.AcquireUpTo(ctx, t.config.BatchSize)
+		if err != nil {
+			continue
+		}
+
+		// Fetch 立即返回，消息随后写入 Messages()；下方分发循环同时监听 ctx，Stop 时无需等待 FetchMaxWait 到期
+		msgs, err := t.consumer.Fetch(budget,
 			jetstream.FetchMaxWait(time.Duration(t.config.FetchMaxWait)*time.Second),
 		)
 		if err != nil {
-			log.WarnContextf(ctx, "[NATSTrigger] %s fetch failed: %v", t.name, err)
-			time.Sleep(1 * time.Second)
+			t.limiter.Release(budget)
+			fetchLog.failure(ctx, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(1 * time.Second):
+			}
 			continue
 		}
+		fetchLog.success(ctx)
 
-		for msg := range msgs.Messages() {
-			event := &model.TriggerEvent{
-				Type:    model.TriggerNATS,
-				Name:    t.name,
-				Payload: msg.Data(),
-				Metadata: map[string]string{
-					"subject": msg.Subject(),
-				},
-			}
+		received := t.dispatchBatch(ctx, msgs, sem, &wg)
+		// 归还本批次未用到的额度
+		t.limiter.Release(budget - received)
 
-			// 缓存层：自动缓存 K线 + 回源 + 注入完整序列
-			if t.config.CacheEnabled {
-				t.processKlineCache(ctx, event, msg.Subject())
-			}
+		if ctx.Err() == nil && msgs.Error() != nil {
+			log.WarnContextf(ctx, "[NATSTrigger] %s message iteration error: %v", t.name, msgs.Error())
+		}
+	}
+}
 
-			if err := t.handler(ctx, event); err != nil {
-				log.ErrorContextf(ctx, "[NATSTrigger] %s handler error: %v", t.name, err)
-				msg.Nak()
-				continue
-			}
-			msg.Ack()
+// dispatchBatch 分发一批消息，返回已分发（占用在途额度）的消息数。
+// 每条消息分发前检查 ctx：停止后已拉取但未分发的消息既不处理也不 Ack，由 JetStream 在 ack_wait 后重投
+func (t *NATSTrigger) dispatchBatch(ctx context.Context, msgs jetstream.MessageBatch,
+	sem chan struct{}, wg *sync.WaitGroup) int {
+	received := 0
+	for {
+		var msg jetstream.Msg
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return received
+		case msg, ok = <-msgs.Messages():
+		}
+		if !ok {
+			return received
+		}
+		if ctx.Err() != nil {
+			return received
 		}
 
-		if msgs.Error() != nil {
-			log.WarnContextf(ctx, "[NATSTrigger] %s message iteration error: %v", t.name, msgs.Error())
+		if t.config.Concurrency <= 1 {
+			received++
+			t.processMessage(ctx, msg)
+			t.limiter.Release(1)
+			continue
 		}
+
+		select {
+		case <-ctx.Done():
+			return received
+		case sem <- struct{}{}:
+		}
+		received++
+		wg.Add(1)
+		go func(msg jetstream.Msg) {
+			defer func() {
+				<-sem
+				t.limiter.Release(1)
+				wg.Done()
+			}()
+			t.processMessage(ctx, msg)
+		}(msg)
+	}
+}
+
+// startDelay 返回首次拉取前的等待时间：StartDelay 加 [0, StartDelayJitter) 的随机抖动
+func (t *NATSTrigger) startDelay() time.Duration {
+	delay := time.Duration(t.config.StartDelay) * time.Second
+	if jitter := time.Duration(t.config.StartDelayJitter) * time.Second; jitter > 0 {
+		delay += rand.N(jitter)
+	}
+	return delay
+}
+
+// checkConsumerDrift 比较服务端消费者配置与期望配置，出现漂移时重建消费者。
+// 例如运维修改 stream 后消费者仍保留旧的 FilterSubject，拉取不报错但收不到期望的消息
+func (t *NATSTrigger) checkConsumerDrift(ctx context.Context) {
+	info, err := t.consumer.Info(ctx)
+	if err != nil {
+		if !errors.Is(err, jetstream.ErrConsumerNotFound) {
+			log.WarnContextf(ctx, "[NATSTrigger] %s consumer info failed, skip drift check: %v", t.name, err)
+			return
+		}
+		log.WarnContextf(ctx, "[NATSTrigger] %s consumer %q not found on server, recreating", t.name, t.config.ConsumerName)
+	} else {
+		drift := consumerConfigDrift(t.consumerCfg, info.Config)
+		if len(drift) == 0 {
+			return
+		}
+		log.WarnContextf(ctx, "[NATSTrigger] %s consumer %q config drift detected: %s, recreating",
+			t.name, t.config.ConsumerName, strings.Join(drift, "; "))
+	}
+
+	if err := t.recreateConsumer(ctx); err != nil {
+		log.ErrorContextf(ctx, "[NATSTrigger] %s recreate consumer failed: %v", t.name, err)
+		return
 	}
+	log.InfoContextf(ctx, "[NATSTrigger] %s consumer %q recreated", t.name, t.config.ConsumerName)
+}
+
+// recreateConsumer 先尝试原地更新；更新失败或更新后仍有漂移时删除并重新创建
+func (t *NATSTrigger) recreateConsumer(ctx context.Context) error {
+	cons, err := t.js.CreateOrUpdateConsumer(ctx, t.config.Stream, t.consumerCfg)
+	if err == nil {
+		if info, infoErr := cons.Info(ctx); infoErr == nil && len(consumerConfigDrift(t.consumerCfg, info.Config)) == 0 {
+			t.consumer = cons
+			return nil
+		}
+	}
+
+	if t.consumerCfg.Durable != "" {
+		if err := t.js.DeleteConsumer(ctx, t.config.Stream, t.consumerCfg.Durable); err != nil &&
+			!errors.Is(err, jetstream.ErrConsumerNotFound) {
+			return fmt.Errorf("delete consumer %q: %w", t.consumerCfg.Durable, err)
+		}
+	}
+	cons, err = t.js.CreateOrUpdateConsumer(ctx, t.config.Stream, t.consumerCfg)
+	if err != nil {
+		return fmt.Errorf("create consumer %q: %w", t.consumerCfg.Durable, err)
+	}
+	t.consumer = cons
+	return nil
+}
+
+// consumerConfigDrift 返回服务端配置与期望配置不一致的字段描述；Replicas 为 0（继承 stream）时不比较
+func consumerConfigDrift(want, got jetstream.ConsumerConfig) []string {
+	var drift []string
+	if want.FilterSubject != got.FilterSubject {
+		drift = append(drift, fmt.Sprintf("filter_subject %q -> %q", want.FilterSubject, got.FilterSubject))
+	}
+	if len(got.FilterSubjects) > 0 {
+		drift = append(drift, fmt.Sprintf("unexpected filter_subjects %v", got.FilterSubjects))
+	}
+	if want.AckPolicy != got.AckPolicy {
+		drift = append(drift, fmt.Sprintf("ack_policy %s -> %s", want.AckPolicy, got.AckPolicy))
+	}
+	if want.AckWait != got.AckWait {
+		drift = append(drift, fmt.Sprintf("ack_wait %s -> %s", want.AckWait, got.AckWait))
+	}
+	if want.MaxDeliver != got.MaxDeliver {
+		drift = append(drift, fmt.Sprintf("max_deliver %d -> %d", want.MaxDeliver, got.MaxDeliver))
+	}
+	if want.Replicas > 0 && want.Replicas != got.Replicas {
+		drift = append(drift, fmt.Sprintf("replicas %d -> %d", want.Replicas, got.Replicas))
+	}
+	if want.MemoryStorage != got.MemoryStorage {
+		drift = append(drift, fmt.Sprintf("memory_storage %v -> %v", want.MemoryStorage, got.MemoryStorage))
+	}
+	return drift
+}
+
+// processMessage 处理单条消息并根据 handler 结果 Ack/Nak
+func (t *NATSTrigger) processMessage(ctx context.Context, msg jetstream.Msg) {
+	event := &model.TriggerEvent{
+		Type:    model.TriggerNATS,
+		Name:    t.name,
+		Payload: msg.Data(),
+		Metadata: map[string]string{
+			"subject": msg.Subject(),
+		},
+	}
+	copyHeaders(event, msg.Headers())
+	if meta, err := msg.Metadata(); err == nil {
+		event.Metadata["num_delivered"] = strconv.FormatUint(meta.NumDelivered, 10)
+		event.Metadata["stream_seq"] = strconv.FormatUint(meta.Sequence.Stream, 10)
+		event.Metadata["timestamp"] = meta.Timestamp.UTC().Format(time.RFC3339Nano)
+	} else {
+		// 非 JetStream 消息或 reply subject 无法解析时仅缺少投递信息，不影响处理
+		log.WarnContextf(ctx, "[NATSTrigger] %s read message metadata failed: subject=%s, error=%v",
+			t.name, msg.Subject(), err)
+	}
+
+	// 缓存层：自动缓存 K线 + 回源 + 注入完整序列
+	if t.config.CacheEnabled {
+		t.cacheMu.Lock()
+		t.processKlineCache(ctx, event, msg.Subject())
+		t.cacheMu.Unlock()
+	}
+
+	err := t.handler(ctx, event)
+	switch {
+	case err == nil:
+		msg.Ack()
+	case errors.Is(err, ErrEventSkipped):
+		log.DebugContextf(ctx, "[NATSTrigger] %s message skipped by plugin: subject=%s", t.name, msg.Subject())
+		msg.Ack()
+	case errors.Is(err, ErrStopping):
+		msg.Nak()
+	case errors.Is(err, ErrEventRetry):
+		log.InfoContextf(ctx, "[NATSTrigger] %s message retry requested by plugin: subject=%s", t.name, msg.Subject())
+		t.nakOrDeadLetter(ctx, msg, err)
+	default:
+		log.ErrorContextf(ctx, "[NATSTrigger] %s handler error: %v", t.name, err)
+		t.nakOrDeadLetter(ctx, msg, err)
+	}
+}
+
+// 死信消息携带的错误信息头
+const (
+	DeadLetterErrorHeader      = "Scf-Dlq-Error"
+	DeadLetterSubjectHeader    = "Scf-Dlq-Subject"
+	DeadLetterTriggerHeader    = "Scf-Dlq-Trigger"
+	DeadLetterDeliveriesHeader = "Scf-Dlq-Deliveries"
+	DeadLetterStreamHeader     = "Scf-Dlq-Stream"
+	DeadLetterStreamSeqHeader  = "Scf-Dlq-Stream-Seq"
+)
+
+// nakOrDeadLetter 处理失败的消息：非最后一次投递时 Nak 等待重投；
+// 已达 max_deliver 且配置了 dead_letter_subject 时，发布到死信 subject 后 Ack，避免 JetStream 静默丢弃
+func (t *NATSTrigger) nakOrDeadLetter(ctx context.Context, msg jetstream.Msg, cause error) {
+	if t.config.DeadLetterSubject == "" || t.config.MaxDeliver <= 0 {
+		msg.Nak()
+		return
+	}
+	meta, err := msg.Metadata()
+	if err != nil {
+		log.WarnContextf(ctx, "[NATSTrigger] %s read message metadata failed: %v", t.name, err)
+		msg.Nak()
+		return
+	}
+	if meta.NumDelivered < uint64(t.config.MaxDeliver) {
+		msg.Nak()
+		return
+	}
+
+	if err := t.publishDeadLetter(ctx, msg, meta, cause); err != nil {
+		// 发布失败时仍 Nak：消息已达 max_deliver，JetStream 将丢弃，与未配置死信时行为一致
+		log.ErrorContextf(ctx, "[NATSTrigger] %s publish dead letter failed: subject=%s, seq=%d, error=%v",
+			t.name, msg.Subject(), meta.Sequence.Stream, err)
+		msg.Nak()
+		return
+	}
+	log.WarnContextf(ctx, "[NATSTrigger] %s message dead-lettered after %d deliveries: subject=%s, seq=%d, dlq=%s, error=%v",
+		t.name, meta.NumDelivered, msg.Subject(), meta.Sequence.Stream, t.config.DeadLetterSubject, cause)
+	msg.Ack()
+}
+
+// publishDeadLetter 将原始消息（payload 与消息头）附带错误信息头发布到死信 subject。
+// 死信 subject 被 stream 捕获时经 JetStream 发布以确认持久化；否则退化为核心 NATS 发布
+// （此时 JetStream 发布得不到确认，仅有核心订阅者时会一直等到超时）
+func (t *NATSTrigger) publishDeadLetter(ctx context.Context, msg jetstream.Msg, meta *jetstream.MsgMetadata, cause error) error {
+	dl := nats.NewMsg(t.config.DeadLetterSubject)
+	dl.Data = msg.Data()
+	for k, vals := range msg.Headers() {
+		for _, v := range vals {
+			dl.Header.Add(k, v)
+		}
+	}
+	dl.Header.Set(DeadLetterErrorHeader, cause.Error())
+	dl.Header.Set(DeadLetterSubjectHeader, msg.Subject())
+	dl.Header.Set(DeadLetterTriggerHeader, t.name)
+	dl.Header.Set(DeadLetterDeliveriesHeader, strconv.FormatUint(meta.NumDelivered, 10))
+	dl.Header.Set(DeadLetterStreamHeader, meta.Stream)
+	dl.Header.Set(DeadLetterStreamSeqHeader, strconv.FormatUint(meta.Sequence.Stream, 10))
+
+	if _, err := t.js.StreamNameBySubject(ctx, dl.Subject); errors.Is(err, jetstream.ErrStreamNotFound) {
+		return t.conn.PublishMsg(dl)
+	}
+	_, err := t.js.PublishMsg(ctx, dl)
+	if errors.Is(err, jetstream.ErrNoStreamResponse) {
+		return t.conn.PublishMsg(dl)
+	}
+	return err
 }
 
 // klineMessage NATS K线消息的通用结构
 type klineMessage struct {
 	Symbol   string          `json:"symbol"`
 	Interval string          `json:"interval"`
-	Kline    json.RawMessage `json:"kline,omitempty"`   // 单条 K线
-	Klines   json.RawMessage `json:"klines,omitempty"`  // K线数组
+	Kline    json.RawMessage `json:"kline,omitempty"`  // 单条 K线
+	Klines   json.RawMessage `json:"klines,omitempty"` // K线数组
+}
+
+// copyHeaders 将 NATS 消息头以 model.HeaderMetadataPrefix 为前缀写入 event.Metadata，多值以逗号拼接
+func copyHeaders(event *model.TriggerEvent, headers nats.Header) {
+	for k, vals := range headers {
+		if len(vals) == 0 {
+			continue
+		}
+		event.Metadata[model.HeaderMetadataPrefix+k] = strings.Join(vals, ",")
+	}
 }
 
 // processKlineCache 处理 K线缓存逻辑：
@@ -0,0 +1,117 @@
+package trigger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSecurityConfig NATS 连接的 TLS 与鉴权配置，鉴权方式（用户名密码 / token / creds_file / nkey_seed_file）至多配置一种
+type NATSSecurityConfig struct {
+	TLS                bool   // 使用 TLS 连接，配置任一 tls_* 文件时自动开启
+	TLSCAFile          string // 自定义 CA 证书（PEM）
+	TLSCertFile        string // 客户端证书（mTLS），需与 TLSKeyFile 同时配置
+	TLSKeyFile         string // 客户端私钥
+	InsecureSkipVerify bool   // 跳过服务端证书校验，仅用于测试环境
+
+	Username     string
+	Password     string
+	Token        string
+	CredsFile    string // NATS 用户凭证文件（JWT + nkey seed）
+	NKeySeedFile string // nkey seed 文件
+}
+
+// parseSecuritySettings 解析并校验 TLS 与鉴权相关 settings；字符串值支持配置文件的 ${VAR} 环境变量替换
+func parseSecuritySettings(name string, s map[string]interface{}) (NATSSecurityConfig, error) {
+	var c NATSSecurityConfig
+	c.TLS, _ = s["tls"].(bool)
+	c.TLSCAFile, _ = s["tls_ca_file"].(string)
+	c.TLSCertFile, _ = s["tls_cert_file"].(string)
+	c.TLSKeyFile, _ = s["tls_key_file"].(string)
+	c.InsecureSkipVerify, _ = s["tls_insecure_skip_verify"].(bool)
+	c.Username, _ = s["username"].(string)
+	c.Password, _ = s["password"].(string)
+	c.Token, _ = s["token"].(string)
+	c.CredsFile, _ = s["creds_file"].(string)
+	c.NKeySeedFile, _ = s["nkey_seed_file"].(string)
+
+	if c.TLSCAFile != "" || c.TLSCertFile != "" || c.TLSKeyFile != "" || c.InsecureSkipVerify {
+		c.TLS = true
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return c, fmt.Errorf("NATS trigger %q tls_cert_file and tls_key_file must be set together", name)
+	}
+	if (c.Username == "") != (c.Password == "") {
+		return c, fmt.Errorf("NATS trigger %q username and password must be set together", name)
+	}
+
+	var methods []string
+	if c.Username != "" {
+		methods = append(methods, "username/password")
+	}
+	if c.Token != "" {
+		methods = append(methods, "token")
+	}
+	if c.CredsFile != "" {
+		methods = append(methods, "creds_file")
+	}
+	if c.NKeySeedFile != "" {
+		methods = append(methods, "nkey_seed_file")
+	}
+	if len(methods) > 1 {
+		return c, fmt.Errorf("NATS trigger %q auth settings are mutually exclusive, got %s",
+			name, strings.Join(methods, ", "))
+	}
+
+	for key, path := range map[string]string{
+		"tls_ca_file":    c.TLSCAFile,
+		"tls_cert_file":  c.TLSCertFile,
+		"tls_key_file":   c.TLSKeyFile,
+		"creds_file":     c.CredsFile,
+		"nkey_seed_file": c.NKeySeedFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return c, fmt.Errorf("NATS trigger %q %s: %w", name, key, err)
+		}
+	}
+	return c, nil
+}
+
+// connectOptions 将 TLS 与鉴权配置转换为 nats.Option
+func (c NATSSecurityConfig) connectOptions() ([]nats.Option, error) {
+	var opts []nats.Option
+	if c.TLS {
+		opts = append(opts, nats.Secure(&tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: c.InsecureSkipVerify,
+		}))
+		if c.TLSCAFile != "" {
+			opts = append(opts, nats.RootCAs(c.TLSCAFile))
+		}
+		if c.TLSCertFile != "" {
+			opts = append(opts, nats.ClientCert(c.TLSCertFile, c.TLSKeyFile))
+		}
+	}
+
+	switch {
+	case c.Username != "":
+		opts = append(opts, nats.UserInfo(c.Username, c.Password))
+	case c.Token != "":
+		opts = append(opts, nats.Token(c.Token))
+	case c.CredsFile != "":
+		opts = append(opts, nats.UserCredentials(c.CredsFile))
+	case c.NKeySeedFile != "":
+		opt, err := nats.NkeyOptionFromSeed(c.NKeySeedFile)
+		if err != nil {
+			return nil, fmt.Errorf("load nkey seed %s: %w", c.NKeySeedFile, err)
+		}
+		opts = append(opts, opt)
+	}
+	return opts, nil
+}
@@ -0,0 +1,238 @@
+package trigger
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// writeTestCert 生成自签名证书（可同时作为 CA）并写入临时目录，返回证书与私钥路径
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "nats-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestNATSSecurityOptions(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	credsFile := filepath.Join(t.TempDir(), "user.creds")
+	if err := os.WriteFile(credsFile, []byte("placeholder"), 0o600); err != nil {
+		t.Fatalf("write creds: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		check    func(t *testing.T, o *nats.Options)
+		wantErr  string
+	}{
+		{
+			name:     "plain connection",
+			settings: map[string]interface{}{},
+			check: func(t *testing.T, o *nats.Options) {
+				if o.Secure || o.User != "" || o.Token != "" || o.UserJWT != nil {
+					t.Fatalf("options = %+v, want no TLS or auth", o)
+				}
+			},
+		},
+		{
+			name:     "tls with ca and client cert",
+			settings: map[string]interface{}{"tls_ca_file": certFile, "tls_cert_file": certFile, "tls_key_file": keyFile},
+			check: func(t *testing.T, o *nats.Options) {
+				if !o.Secure || o.RootCAsCB == nil || o.TLSCertCB == nil {
+					t.Fatal("tls files did not set root CA and client certificate callbacks")
+				}
+				if _, err := o.RootCAsCB(); err != nil {
+					t.Fatalf("load CA: %v", err)
+				}
+				if _, err := o.TLSCertCB(); err != nil {
+					t.Fatalf("load client certificate: %v", err)
+				}
+				if o.TLSConfig.InsecureSkipVerify {
+					t.Fatal("InsecureSkipVerify enabled without tls_insecure_skip_verify")
+				}
+			},
+		},
+		{
+			name:     "insecure skip verify implies tls",
+			settings: map[string]interface{}{"tls_insecure_skip_verify": true},
+			check: func(t *testing.T, o *nats.Options) {
+				if !o.Secure || !o.TLSConfig.InsecureSkipVerify {
+					t.Fatalf("secure/insecure = %v/%v, want true/true", o.Secure, o.TLSConfig.InsecureSkipVerify)
+				}
+			},
+		},
+		{
+			name:     "username and password",
+			settings: map[string]interface{}{"username": "svc", "password": "secret"},
+			check: func(t *testing.T, o *nats.Options) {
+				if o.User != "svc" || o.Password != "secret" {
+					t.Fatalf("user/password = %q/%q", o.User, o.Password)
+				}
+			},
+		},
+		{
+			name:     "token",
+			settings: map[string]interface{}{"token": "t0k3n"},
+			check: func(t *testing.T, o *nats.Options) {
+				if o.Token != "t0k3n" {
+					t.Fatalf("token = %q", o.Token)
+				}
+			},
+		},
+		{
+			name:     "creds file",
+			settings: map[string]interface{}{"creds_file": credsFile},
+			check: func(t *testing.T, o *nats.Options) {
+				if o.UserJWT == nil || o.SignatureCB == nil {
+					t.Fatal("creds_file did not set user JWT and signature callbacks")
+				}
+			},
+		},
+		{name: "cert without key", settings: map[string]interface{}{"tls_cert_file": certFile}, wantErr: "tls_cert_file and tls_key_file must be set together"},
+		{name: "username without password", settings: map[string]interface{}{"username": "svc"}, wantErr: "username and password must be set together"},
+		{
+			name:     "mutually exclusive auth",
+			settings: map[string]interface{}{"token": "t", "creds_file": credsFile},
+			wantErr:  "mutually exclusive, got token, creds_file",
+		},
+		{name: "missing creds file", settings: map[string]interface{}{"creds_file": "/nonexistent/user.creds"}, wantErr: "creds_file"},
+		{name: "missing ca file", settings: map[string]interface{}{"tls_ca_file": "/nonexistent/ca.pem"}, wantErr: "tls_ca_file"},
+		{
+			name:     "invalid nkey seed file",
+			settings: map[string]interface{}{"nkey_seed_file": credsFile},
+			wantErr:  "load nkey seed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sec, err := parseSecuritySettings("orders", tt.settings)
+			var opts []nats.Option
+			if err == nil {
+				opts, err = sec.connectOptions()
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			o := nats.GetDefaultOptions()
+			for _, opt := range opts {
+				if err := opt(&o); err != nil {
+					t.Fatalf("apply option: %v", err)
+				}
+			}
+			tt.check(t, &o)
+		})
+	}
+}
+
+func TestNATSConnectWithAuth(t *testing.T) {
+	tests := []struct {
+		name      string
+		server    natsserver.Options
+		settings  map[string]interface{}
+		wantReady bool
+	}{
+		{
+			name:      "token accepted",
+			server:    natsserver.Options{Authorization: "t0k3n"},
+			settings:  map[string]interface{}{"token": "t0k3n"},
+			wantReady: true,
+		},
+		{
+			name:      "username and password accepted",
+			server:    natsserver.Options{Username: "svc", Password: "secret"},
+			settings:  map[string]interface{}{"username": "svc", "password": "secret"},
+			wantReady: true,
+		},
+		{
+			name:     "wrong password rejected",
+			server:   natsserver.Options{Username: "svc", Password: "secret"},
+			settings: map[string]interface{}{"username": "svc", "password": "wrong"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.server
+			opts.Host, opts.Port, opts.NoLog, opts.NoSigs = "127.0.0.1", -1, true, true
+			srv, err := natsserver.NewServer(&opts)
+			if err != nil {
+				t.Fatalf("create nats server: %v", err)
+			}
+			go srv.Start()
+			if !srv.ReadyForConnections(5 * time.Second) {
+				t.Fatal("nats server not ready")
+			}
+			t.Cleanup(srv.Shutdown)
+
+			settings := map[string]interface{}{"url": srv.ClientURL(), "stream": "ORDERS", "subject": "orders.>"}
+			for k, v := range tt.settings {
+				settings[k] = v
+			}
+			trig := NewNATSTrigger("orders")
+			if err := trig.Init(context.Background(), model.TriggerConfig{Name: "orders", Type: string(model.TriggerNATS), Settings: settings}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			sec, err := trig.config.Security.connectOptions()
+			if err != nil {
+				t.Fatalf("connectOptions() error = %v", err)
+			}
+			nc, err := nats.Connect(trig.config.URL, append(sec, nats.MaxReconnects(0))...)
+			if nc != nil {
+				defer nc.Close()
+			}
+			if got := err == nil && nc.IsConnected(); got != tt.wantReady {
+				t.Fatalf("connected = %v (err = %v), want %v", got, err, tt.wantReady)
+			}
+		})
+	}
+}
@@ -0,0 +1,527 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// runJetStream 启动内嵌的 JetStream 服务端并创建 stream ORDERS（orders.>），返回连接地址与 JetStream 客户端
+func runJetStream(t *testing.T) (string, *nats.Conn, jetstream.JetStream) {
+	t.Helper()
+	srv, err := natsserver.NewServer(&natsserver.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("create nats server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect nats: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("create jetstream: %v", err)
+	}
+	if _, err := js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}}); err != nil {
+		t.Fatalf("create stream: %v", err)
+	}
+	return srv.ClientURL(), nc, js
+}
+
+func TestNATSDeadLetter(t *testing.T) {
+	errHandler := errors.New("factor ma not found")
+	tests := []struct {
+		name      string
+		dlq       string
+		dlqStream bool // 死信 subject 是否由 stream 捕获（否则走核心 NATS 发布）
+		failures  int  // handler 前 failures 次返回错误，之后成功
+		wantCalls int
+		wantDLQ   bool
+	}{
+		{name: "dead-lettered to stream after max_deliver", dlq: "dlq.orders", dlqStream: true, failures: 100, wantCalls: 3, wantDLQ: true},
+		{name: "dead-lettered via core NATS without stream", dlq: "dlq.orders", failures: 100, wantCalls: 3, wantDLQ: true},
+		{name: "recovered before max_deliver", dlq: "dlq.orders", dlqStream: true, failures: 2, wantCalls: 3},
+		{name: "no dead_letter_subject", failures: 100, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, nc, js := runJetStream(t)
+			ctx := context.Background()
+
+			var dlqMsgs <-chan *nats.Msg
+			if tt.dlq != "" {
+				ch := make(chan *nats.Msg, 4)
+				dlqMsgs = ch
+				if tt.dlqStream {
+					if _, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "DLQ", Subjects: []string{tt.dlq}}); err != nil {
+						t.Fatalf("create DLQ stream: %v", err)
+					}
+				}
+				sub, err := nc.ChanSubscribe(tt.dlq, ch)
+				if err != nil {
+					t.Fatalf("subscribe DLQ: %v", err)
+				}
+				t.Cleanup(func() { sub.Unsubscribe() })
+			}
+
+			trig := NewNATSTrigger("orders")
+			settings := map[string]interface{}{
+				"url": url, "stream": "ORDERS", "subject": "orders.>", "consumer_name": "orders-worker",
+				"max_deliver": 3, "fetch_max_wait": 1, "drift_check_interval": 0,
+			}
+			if tt.dlq != "" {
+				settings["dead_letter_subject"] = tt.dlq
+			}
+			if err := trig.Init(ctx, model.TriggerConfig{Name: "orders", Type: string(model.TriggerNATS), Settings: settings}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			var calls atomic.Int32
+			done := make(chan struct{}, 16)
+			handler := func(ctx context.Context, event *model.TriggerEvent) error {
+				defer func() { done <- struct{}{} }()
+				if int(calls.Add(1)) <= tt.failures {
+					return errHandler
+				}
+				return nil
+			}
+			if err := trig.Start(ctx, handler); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			t.Cleanup(func() { trig.Stop(context.Background()) })
+
+			msg := nats.NewMsg("orders.created")
+			msg.Data = []byte(`{"id":1}`)
+			msg.Header.Set("Order-Source", "web")
+			if _, err := js.PublishMsg(ctx, msg); err != nil {
+				t.Fatalf("publish: %v", err)
+			}
+
+			for i := 0; i < tt.wantCalls; i++ {
+				select {
+				case <-done:
+				case <-time.After(10 * time.Second):
+					t.Fatalf("handler called %d times, want %d", calls.Load(), tt.wantCalls)
+				}
+			}
+			// 等待可能的多余重投与死信发布
+			time.Sleep(300 * time.Millisecond)
+			if got := int(calls.Load()); got != tt.wantCalls {
+				t.Fatalf("handler called %d times, want %d", got, tt.wantCalls)
+			}
+
+			select {
+			case dl := <-dlqMsgs:
+				if !tt.wantDLQ {
+					t.Fatalf("unexpected dead letter: %s", dl.Data)
+				}
+				wantHeaders := map[string]string{
+					"Order-Source":             "web",
+					DeadLetterErrorHeader:      errHandler.Error(),
+					DeadLetterSubjectHeader:    "orders.created",
+					DeadLetterTriggerHeader:    "orders",
+					DeadLetterDeliveriesHeader: "3",
+					DeadLetterStreamHeader:     "ORDERS",
+					DeadLetterStreamSeqHeader:  "1",
+				}
+				if string(dl.Data) != `{"id":1}` {
+					t.Fatalf("dead letter payload = %s, want original", dl.Data)
+				}
+				for k, v := range wantHeaders {
+					if dl.Header.Get(k) != v {
+						t.Fatalf("dead letter header %s = %q, want %q", k, dl.Header.Get(k), v)
+					}
+				}
+			default:
+				if tt.wantDLQ {
+					t.Fatal("message was not published to the dead letter subject")
+				}
+			}
+			if tt.dlqStream {
+				info, err := js.Stream(ctx, "DLQ")
+				if err != nil {
+					t.Fatalf("get DLQ stream: %v", err)
+				}
+				if want := map[bool]uint64{true: 1, false: 0}[tt.wantDLQ]; info.CachedInfo().State.Msgs != want {
+					t.Fatalf("DLQ stream holds %d messages, want %d", info.CachedInfo().State.Msgs, want)
+				}
+			}
+
+			// 死信或成功处理后消息已 Ack（Ack 异步送达服务端），不再有待确认消息
+			if tt.wantDLQ || tt.failures < tt.wantCalls {
+				cons, err := js.Consumer(ctx, "ORDERS", "orders-worker")
+				if err != nil {
+					t.Fatalf("get consumer: %v", err)
+				}
+				deadline := time.Now().Add(2 * time.Second)
+				for {
+					ci, err := cons.Info(ctx)
+					if err != nil {
+						t.Fatalf("consumer info: %v", err)
+					}
+					if ci.NumAckPending == 0 {
+						break
+					}
+					if time.Now().After(deadline) {
+						t.Fatalf("consumer ack pending = %d, want 0", ci.NumAckPending)
+					}
+					time.Sleep(20 * time.Millisecond)
+				}
+			}
+		})
+	}
+}
+
+// metadataErrMsg 读取投递元数据失败的消息（如非 JetStream 消息）
+type metadataErrMsg struct {
+	*FakeNATSMsg
+}
+
+func (m metadataErrMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return nil, errors.New("message is not bound to a JetStream subscription")
+}
+
+func TestNATSEventMetadata(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 8, 30, 0, 123000000, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		name       string
+		meta       *jetstream.MsgMetadata
+		metaErr    bool
+		headers    nats.Header
+		want       map[string]string
+		wantAbsent []string
+	}{
+		{
+			name: "delivery metadata",
+			meta: &jetstream.MsgMetadata{NumDelivered: 3, Sequence: jetstream.SequencePair{Stream: 1024, Consumer: 7}, Timestamp: ts},
+			want: map[string]string{
+				"subject":       "orders.created",
+				"num_delivered": "3",
+				"stream_seq":    "1024",
+				"timestamp":     "2026-03-01T00:30:00.123Z",
+			},
+		},
+		{
+			name:       "metadata error still dispatches",
+			metaErr:    true,
+			want:       map[string]string{"subject": "orders.created"},
+			wantAbsent: []string{"num_delivered", "stream_seq", "timestamp"},
+		},
+		{
+			name:    "headers prefixed and joined",
+			meta:    &jetstream.MsgMetadata{NumDelivered: 1, Timestamp: ts},
+			headers: nats.Header{"Trace-Id": {"abc"}, "Tag": {"a", "b"}, "subject": {"spoofed"}},
+			want: map[string]string{
+				"subject":         "orders.created",
+				"num_delivered":   "1",
+				"header.Trace-Id": "abc",
+				"header.Tag":      "a,b",
+				"header.subject":  "spoofed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trig := newTestNATSTrigger(t, nil)
+			var got *model.TriggerEvent
+			trig.handler = func(ctx context.Context, event *model.TriggerEvent) error {
+				got = event
+				return nil
+			}
+
+			fake := NewFakeNATSMsg("orders.created", []byte(`{}`))
+			fake.MsgMeta = tt.meta
+			if tt.headers != nil {
+				fake.MsgHeaders = tt.headers
+			}
+			var msg jetstream.Msg = fake
+			if tt.metaErr {
+				msg = metadataErrMsg{fake}
+			}
+			trig.processMessage(context.Background(), msg)
+
+			if got == nil {
+				t.Fatal("handler was not called")
+			}
+			for k, v := range tt.want {
+				if got.Metadata[k] != v {
+					t.Fatalf("metadata[%s] = %q, want %q", k, got.Metadata[k], v)
+				}
+			}
+			for _, k := range tt.wantAbsent {
+				if _, ok := got.Metadata[k]; ok {
+					t.Fatalf("metadata[%s] = %q, want absent", k, got.Metadata[k])
+				}
+			}
+			if fake.Decision() != AckAcked {
+				t.Fatalf("decision = %q, want %q", fake.Decision(), AckAcked)
+			}
+		})
+	}
+}
+
+func TestNATSConsumerConfig(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	base := jetstream.ConsumerConfig{
+		Durable:       "orders-worker",
+		FilterSubject: "orders.>",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       30 * time.Second,
+		MaxDeliver:    3,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+	}
+	with := func(fn func(c *jetstream.ConsumerConfig)) jetstream.ConsumerConfig {
+		c := base
+		fn(&c)
+		return c
+	}
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		want     jetstream.ConsumerConfig
+		wantErr  string
+	}{
+		{name: "defaults keep durable deliver new", want: base},
+		{name: "deliver all", settings: map[string]interface{}{"deliver_policy": "all"},
+			want: with(func(c *jetstream.ConsumerConfig) { c.DeliverPolicy = jetstream.DeliverAllPolicy })},
+		{name: "deliver last", settings: map[string]interface{}{"deliver_policy": "last"},
+			want: with(func(c *jetstream.ConsumerConfig) { c.DeliverPolicy = jetstream.DeliverLastPolicy })},
+		{
+			name:     "by start time",
+			settings: map[string]interface{}{"deliver_policy": "by_start_time", "start_time": "2026-01-02T03:04:05Z"},
+			want: with(func(c *jetstream.ConsumerConfig) {
+				c.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+				c.OptStartTime = &start
+			}),
+		},
+		{
+			name:     "by start seq",
+			settings: map[string]interface{}{"deliver_policy": "by_start_seq", "start_seq": 42},
+			want: with(func(c *jetstream.ConsumerConfig) {
+				c.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+				c.OptStartSeq = 42
+			}),
+		},
+		{name: "ephemeral consumer ignores consumer_name", settings: map[string]interface{}{"durable": false, "deliver_policy": "all"},
+			want: with(func(c *jetstream.ConsumerConfig) {
+				c.Durable = ""
+				c.DeliverPolicy = jetstream.DeliverAllPolicy
+			})},
+		{name: "unknown policy", settings: map[string]interface{}{"deliver_policy": "oldest"}, wantErr: `invalid deliver_policy "oldest"`},
+		{name: "start_time without by_start_time", settings: map[string]interface{}{"start_time": "2026-01-02T03:04:05Z"},
+			wantErr: "require deliver_policy by_start_time/by_start_seq"},
+		{name: "by_start_time without start_time", settings: map[string]interface{}{"deliver_policy": "by_start_time"},
+			wantErr: "requires start_time"},
+		{name: "invalid start_time", settings: map[string]interface{}{"deliver_policy": "by_start_time", "start_time": "yesterday"},
+			wantErr: `invalid start_time "yesterday"`},
+		{name: "start_seq with by_start_time", settings: map[string]interface{}{"deliver_policy": "by_start_time", "start_time": "2026-01-02T03:04:05Z", "start_seq": 1},
+			wantErr: "start_seq is not allowed"},
+		{name: "by_start_seq without start_seq", settings: map[string]interface{}{"deliver_policy": "by_start_seq"},
+			wantErr: "requires start_seq > 0"},
+		{name: "start_time with by_start_seq", settings: map[string]interface{}{"deliver_policy": "by_start_seq", "start_seq": 1, "start_time": "2026-01-02T03:04:05Z"},
+			wantErr: "start_time is not allowed"},
+		{name: "negative start_seq", settings: map[string]interface{}{"deliver_policy": "by_start_seq", "start_seq": -1},
+			wantErr: "start_seq must be >= 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := map[string]interface{}{"url": "nats://127.0.0.1:4222", "stream": "ORDERS", "subject": "orders.>", "consumer_name": "orders-worker"}
+			for k, v := range tt.settings {
+				s[k] = v
+			}
+			trig := NewNATSTrigger("orders")
+			err := trig.Init(context.Background(), model.TriggerConfig{Name: "orders", Type: string(model.TriggerNATS), Settings: s})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Init() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if got := trig.consumerConfig(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("consumerConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNATSDeliverPolicyReplaysBacklog(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		want     []string
+	}{
+		{name: "new skips backlog", settings: map[string]interface{}{}, want: []string{"live"}},
+		{name: "all replays backlog", settings: map[string]interface{}{"deliver_policy": "all"}, want: []string{"1", "2", "3", "live"}},
+		{name: "last starts at last message", settings: map[string]interface{}{"deliver_policy": "last"}, want: []string{"3", "live"}},
+		{name: "by start seq", settings: map[string]interface{}{"deliver_policy": "by_start_seq", "start_seq": 2}, want: []string{"2", "3", "live"}},
+		{name: "ephemeral all", settings: map[string]interface{}{"deliver_policy": "all", "durable": false}, want: []string{"1", "2", "3", "live"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, _, js := runJetStream(t)
+			ctx := context.Background()
+			for _, data := range []string{"1", "2", "3"} {
+				if _, err := js.Publish(ctx, "orders.created", []byte(data)); err != nil {
+					t.Fatalf("publish backlog: %v", err)
+				}
+			}
+
+			settings := map[string]interface{}{
+				"url": url, "stream": "ORDERS", "subject": "orders.>", "consumer_name": "orders-worker",
+				"fetch_max_wait": 1, "drift_check_interval": 0,
+			}
+			for k, v := range tt.settings {
+				settings[k] = v
+			}
+			trig := NewNATSTrigger("orders")
+			if err := trig.Init(ctx, model.TriggerConfig{Name: "orders", Type: string(model.TriggerNATS), Settings: settings}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			received := make(chan string, 8)
+			if err := trig.Start(ctx, func(ctx context.Context, event *model.TriggerEvent) error {
+				received <- string(event.Payload)
+				return nil
+			}); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			t.Cleanup(func() { trig.Stop(context.Background()) })
+			if _, err := js.Publish(ctx, "orders.created", []byte("live")); err != nil {
+				t.Fatalf("publish live: %v", err)
+			}
+
+			var got []string
+			for len(got) < len(tt.want) {
+				select {
+				case p := <-received:
+					got = append(got, p)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("received %v, want %v", got, tt.want)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("received %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNATSStopIsPrompt(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		backlog  int  // Start 前发布的消息数
+		block    bool // handler 阻塞直到 Stop 被调用
+		prepare  func(t *testing.T, js jetstream.JetStream)
+		wantMax  int // Stop 后最多处理的消息数
+	}{
+		{name: "idle long fetch", settings: map[string]interface{}{"fetch_max_wait": 30}},
+		{name: "start delay", settings: map[string]interface{}{"start_delay": 30}},
+		{
+			name:     "fetch error backoff",
+			settings: map[string]interface{}{"fetch_max_wait": 30},
+			prepare: func(t *testing.T, js jetstream.JetStream) {
+				if err := js.DeleteStream(context.Background(), "ORDERS"); err != nil {
+					t.Fatalf("delete stream: %v", err)
+				}
+			},
+		},
+		{
+			name:     "fetched batch not dispatched after stop",
+			settings: map[string]interface{}{"fetch_max_wait": 30, "batch_size": 5, "deliver_policy": "all"},
+			backlog:  5,
+			block:    true,
+			wantMax:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, _, js := runJetStream(t)
+			ctx := context.Background()
+			for i := 0; i < tt.backlog; i++ {
+				if _, err := js.Publish(ctx, "orders.created", []byte(`{}`)); err != nil {
+					t.Fatalf("publish: %v", err)
+				}
+			}
+
+			settings := map[string]interface{}{
+				"url": url, "stream": "ORDERS", "subject": "orders.>", "consumer_name": "orders-worker", "drift_check_interval": 0,
+			}
+			for k, v := range tt.settings {
+				settings[k] = v
+			}
+			trig := NewNATSTrigger("orders")
+			if err := trig.Init(ctx, model.TriggerConfig{Name: "orders", Type: string(model.TriggerNATS), Settings: settings}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			var handled atomic.Int32
+			started := make(chan struct{}, 1)
+			stopping := make(chan struct{})
+			if err := trig.Start(ctx, func(ctx context.Context, event *model.TriggerEvent) error {
+				handled.Add(1)
+				if tt.block {
+					started <- struct{}{}
+					<-stopping
+				}
+				return nil
+			}); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			if tt.prepare != nil {
+				tt.prepare(t, js)
+			}
+			if tt.block {
+				select {
+				case <-started:
+				case <-time.After(5 * time.Second):
+					t.Fatal("handler was not called")
+				}
+			} else {
+				// 让消费循环进入 Fetch / 退避 / 启动延迟
+				time.Sleep(200 * time.Millisecond)
+			}
+
+			stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			begin := time.Now()
+			stopErr := make(chan error, 1)
+			go func() { stopErr <- trig.Stop(stopCtx) }()
+			if tt.block {
+				time.Sleep(50 * time.Millisecond)
+				close(stopping)
+			}
+			if err := <-stopErr; err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+			if elapsed := time.Since(begin); elapsed > time.Second {
+				t.Fatalf("Stop() took %s, want < 1s", elapsed)
+			}
+			if got := int(handled.Load()); got > tt.wantMax {
+				t.Fatalf("handled %d messages, want at most %d", got, tt.wantMax)
+			}
+		})
+	}
+}
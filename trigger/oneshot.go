@@ -0,0 +1,104 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// OneShotScheduler 一次性延迟任务调度器，由框架统一管理，停止时取消所有未执行任务
+type OneShotScheduler struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]*time.Timer
+	wg      sync.WaitGroup
+}
+
+// NewOneShotScheduler 创建一次性任务调度器，ctx 取消或调用 Stop 后不再执行新任务
+func NewOneShotScheduler(ctx context.Context) *OneShotScheduler {
+	c, cancel := context.WithCancel(ctx)
+	return &OneShotScheduler{
+		ctx:     c,
+		cancel:  cancel,
+		pending: make(map[uint64]*time.Timer),
+	}
+}
+
+// Schedule 在 delay 之后执行一次 fn；调度器已停止时直接丢弃
+func (s *OneShotScheduler) Schedule(delay time.Duration, fn func(ctx context.Context)) {
+	if fn == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		log.WarnContextf(s.ctx, "[OneShotScheduler] scheduler stopped, drop one-shot task")
+		return
+	}
+
+	s.nextID++
+	id := s.nextID
+	s.wg.Add(1)
+	s.pending[id] = time.AfterFunc(delay, func() {
+		defer s.wg.Done()
+
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.run(fn)
+	})
+}
+
+// run 执行单个任务；fn 运行在 time.AfterFunc 的 goroutine 中，panic 必须在此恢复，否则进程退出
+func (s *OneShotScheduler) run(fn func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ErrorContextf(s.ctx, "[OneShotScheduler] one-shot task panic: %v", r)
+		}
+	}()
+	fn(s.ctx)
+}
+
+// Pending 返回尚未执行的一次性任务数量
+func (s *OneShotScheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Stop 取消所有未执行的任务，并等待正在执行的任务返回；ctx 到期时不再等待并返回错误
+// （正在执行的任务收到的 ctx 已取消，由任务自行退出）
+func (s *OneShotScheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.cancel()
+	for id, t := range s.pending {
+		if t.Stop() {
+			s.wg.Done()
+		}
+		delete(s.pending, id)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for running one-shot tasks: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,107 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOneShotSchedulerPending(t *testing.T) {
+	tests := []struct {
+		name        string
+		delays      []time.Duration
+		wait        time.Duration // 调度后等待的时间
+		wantPending int
+		wantRan     int32
+	}{
+		{name: "nothing scheduled"},
+		{name: "future tasks are pending", delays: []time.Duration{time.Hour, time.Hour, time.Hour}, wantPending: 3},
+		{name: "executed tasks leave pending", delays: []time.Duration{0, 0, time.Hour}, wait: 100 * time.Millisecond, wantPending: 1, wantRan: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOneShotScheduler(context.Background())
+			var ran atomic.Int32
+			for _, d := range tt.delays {
+				s.Schedule(d, func(ctx context.Context) { ran.Add(1) })
+			}
+			time.Sleep(tt.wait)
+			if got := s.Pending(); got != tt.wantPending {
+				t.Fatalf("Pending() = %d, want %d", got, tt.wantPending)
+			}
+			if err := s.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+			if got := s.Pending(); got != 0 {
+				t.Fatalf("Pending() after Stop = %d, want 0", got)
+			}
+			// 停止后调度的任务直接丢弃
+			s.Schedule(0, func(ctx context.Context) { ran.Add(1) })
+			time.Sleep(20 * time.Millisecond)
+			if got := ran.Load(); got != tt.wantRan {
+				t.Fatalf("ran %d tasks, want %d", got, tt.wantRan)
+			}
+		})
+	}
+}
+
+func TestOneShotSchedulerStop(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    func(ctx context.Context, started chan<- struct{})
+		timeout time.Duration
+		wantErr error
+	}{
+		{
+			name: "running task sees cancellation and Stop waits for it",
+			task: func(ctx context.Context, started chan<- struct{}) {
+				close(started)
+				<-ctx.Done()
+			},
+			timeout: 5 * time.Second,
+		},
+		{
+			name: "hung task bounded by ctx",
+			task: func(ctx context.Context, started chan<- struct{}) {
+				close(started)
+				time.Sleep(2 * time.Second)
+			},
+			timeout: 50 * time.Millisecond,
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "panicking task does not crash the process",
+			task: func(ctx context.Context, started chan<- struct{}) {
+				close(started)
+				panic("boom")
+			},
+			timeout: 5 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOneShotScheduler(context.Background())
+			started := make(chan struct{})
+			s.Schedule(0, func(ctx context.Context) { tt.task(ctx, started) })
+			var cancelled atomic.Bool
+			s.Schedule(time.Hour, func(ctx context.Context) { cancelled.Store(true) })
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			begin := time.Now()
+			err := s.Stop(ctx)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Stop() error = %v, want %v", err, tt.wantErr)
+			}
+			if took := time.Since(begin); took > time.Second {
+				t.Fatalf("Stop() took %s, want under 1s", took)
+			}
+			if s.Pending() != 0 || cancelled.Load() {
+				t.Fatalf("pending task not cancelled: Pending() = %d, ran = %v", s.Pending(), cancelled.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+	"github.com/nats-io/nats.go"
+	"trpc.group/trpc-go/trpc-go"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// EventSink 事件旁路输出接口，事件处理完成后由框架异步调用，result 为处理结果（nil 表示成功）
+type EventSink interface {
+	Emit(ctx context.Context, event *model.TriggerEvent, result error)
+}
+
+// defaultSinkBufferSize 异步旁路输出的默认缓冲大小
+const defaultSinkBufferSize = 1024
+
+// sinkItem 待输出的事件
+type sinkItem struct {
+	ctx    context.Context
+	event  *model.TriggerEvent
+	result error
+}
+
+// asyncSink 带缓冲的异步 EventSink 包装，缓冲满时丢弃，避免影响主处理路径
+type asyncSink struct {
+	sink     EventSink
+	ch       chan sinkItem
+	done     chan struct{} // 关闭后不再接收新事件，run 排空缓冲后退出
+	exited   chan struct{} // run 已退出
+	drainCtx context.Context
+	stopOnce sync.Once
+}
+
+// newAsyncSink 创建异步 sink 并启动后台输出协程
+func newAsyncSink(sink EventSink, bufferSize int) *asyncSink {
+	if bufferSize <= 0 {
+		bufferSize = defaultSinkBufferSize
+	}
+	s := &asyncSink{
+		sink:   sink,
+		ch:     make(chan sinkItem, bufferSize),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// emit 非阻塞投递，缓冲满或已停止时丢弃
+func (s *asyncSink) emit(ctx context.Context, event *model.TriggerEvent, result error) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	select {
+	case s.ch <- sinkItem{ctx: trpc.CloneContext(ctx), event: event, result: result}:
+	default:
+		log.WarnContextf(ctx, "[EventSink] buffer full, drop event: trigger=%s", event.Name)
+	}
+}
+
+// run 后台消费缓冲并调用实际 sink；停止后继续输出缓冲中的事件，直到缓冲为空或 drainCtx 到期
+func (s *asyncSink) run() {
+	defer close(s.exited)
+	for {
+		// 优先响应停止，已停止时由 drain 按期限输出剩余事件
+		select {
+		case <-s.done:
+			s.drain()
+			return
+		default:
+		}
+		select {
+		case <-s.done:
+			s.drain()
+			return
+		case item := <-s.ch:
+			s.deliver(item)
+		}
+	}
+}
+
+// drain 输出停止时缓冲中剩余的事件，drainCtx 到期后丢弃其余事件
+func (s *asyncSink) drain() {
+	for {
+		if err := s.drainCtx.Err(); err != nil {
+			if n := len(s.ch); n > 0 {
+				log.WarnContextf(s.drainCtx, "[EventSink] drain deadline exceeded, drop %d buffered events", n)
+			}
+			return
+		}
+		select {
+		case item := <-s.ch:
+			s.deliver(item)
+		default:
+			return
+		}
+	}
+}
+
+// deliver 调用实际 sink，sink panic 只丢弃该事件，不影响后台协程与进程
+func (s *asyncSink) deliver(item sinkItem) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ErrorContextf(item.ctx, "[EventSink] sink panic, drop event: trigger=%s, panic=%v", item.event.Name, r)
+		}
+	}()
+	s.sink.Emit(item.ctx, item.event, item.result)
+}
+
+// stop 停止接收新事件，并等待缓冲中的事件输出完毕，最多等到 ctx 到期
+func (s *asyncSink) stop(ctx context.Context) {
+	s.stopOnce.Do(func() {
+		s.drainCtx = ctx
+		close(s.done)
+	})
+	select {
+	case <-s.exited:
+	case <-ctx.Done():
+	}
+}
+
+// ========== NATSEventSink ==========
+
+// sinkRecord NATSEventSink 发布的消息体
+type sinkRecord struct {
+	Event     *model.TriggerEvent `json:"event"`
+	Error     string              `json:"error,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+}
+
+// NATSEventSink 将事件及处理结果发布到 NATS subject
+type NATSEventSink struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATSEventSink 连接 NATS 并创建 NATSEventSink
+func NewNATSEventSink(url, subject string) (*NATSEventSink, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("NATS event sink missing subject")
+	}
+	nc, err := nats.Connect(url,
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2*time.Second),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect NATS for event sink: %w", err)
+	}
+	return &NATSEventSink{conn: nc, subject: subject}, nil
+}
+
+// Emit 发布事件及处理结果
+func (s *NATSEventSink) Emit(ctx context.Context, event *model.TriggerEvent, result error) {
+	record := sinkRecord{
+		Event:     event,
+		Timestamp: time.Now(),
+	}
+	if result != nil {
+		record.Error = result.Error()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.WarnContextf(ctx, "[NATSEventSink] failed to marshal event %s: %v", event.Name, err)
+		return
+	}
+	if err := s.conn.Publish(s.subject, data); err != nil {
+		log.WarnContextf(ctx, "[NATSEventSink] failed to publish event %s: %v", event.Name, err)
+	}
+}
+
+// Close 关闭 NATS 连接
+func (s *NATSEventSink) Close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
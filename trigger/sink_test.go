@@ -0,0 +1,108 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// gatedSink 测试用 EventSink：每次 Emit 先通知 started，再等待 gate 放行（gate 为 nil 时不等待）
+type gatedSink struct {
+	gate    chan struct{}
+	started chan struct{}
+	panicOn string // Emit 该触发器名称的事件时 panic
+
+	mu      sync.Mutex
+	emitted []string
+}
+
+func (s *gatedSink) Emit(ctx context.Context, event *model.TriggerEvent, result error) {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	if s.gate != nil {
+		<-s.gate
+	}
+	if event.Name == s.panicOn {
+		panic("sink failure")
+	}
+	s.mu.Lock()
+	s.emitted = append(s.emitted, event.Name)
+	s.mu.Unlock()
+}
+
+func (s *gatedSink) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.emitted...)
+}
+
+func TestAsyncSink(t *testing.T) {
+	tests := []struct {
+		name        string
+		bufferSize  int
+		events      int
+		blockFirst  bool          // 第一个事件阻塞 Emit，直到 stop 之前（或 stop 超时之后）放行
+		releaseLate bool          // stop 超时之后才放行
+		stopTimeout time.Duration // stop 的排空期限
+		panicOn     string
+		want        int // 最终输出的事件数
+	}{
+		{name: "drop when buffer is full", bufferSize: 2, events: 6, blockFirst: true, stopTimeout: 5 * time.Second, want: 3},
+		{name: "stop drains buffered events", bufferSize: 8, events: 5, blockFirst: true, stopTimeout: 5 * time.Second, want: 5},
+		{name: "stop gives up at deadline", bufferSize: 8, events: 5, blockFirst: true, releaseLate: true, stopTimeout: 50 * time.Millisecond, want: 1},
+		{name: "panicking sink drops only that event", bufferSize: 8, events: 3, stopTimeout: 5 * time.Second, panicOn: "e1", want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &gatedSink{started: make(chan struct{}, 1), panicOn: tt.panicOn}
+			if tt.blockFirst {
+				sink.gate = make(chan struct{})
+			}
+			s := newAsyncSink(sink, tt.bufferSize)
+			ctx := context.Background()
+
+			s.emit(ctx, &model.TriggerEvent{Name: "e0"}, nil)
+			if tt.blockFirst {
+				<-sink.started // e0 已被取出并阻塞在 Emit 中，其余事件进入缓冲
+			}
+			for i := 1; i < tt.events; i++ {
+				s.emit(ctx, &model.TriggerEvent{Name: fmt.Sprintf("e%d", i)}, nil)
+			}
+
+			var release sync.Once
+			open := func() {
+				if sink.gate != nil {
+					release.Do(func() { close(sink.gate) })
+				}
+			}
+			defer open()
+			if !tt.releaseLate {
+				open()
+			}
+
+			stopCtx, cancel := context.WithTimeout(ctx, tt.stopTimeout)
+			defer cancel()
+			begin := time.Now()
+			s.stop(stopCtx)
+			if took := time.Since(begin); took > tt.stopTimeout+time.Second {
+				t.Fatalf("stop() took %s, want within %s", took, tt.stopTimeout)
+			}
+			if tt.releaseLate {
+				open()
+				<-s.exited
+			}
+
+			// 停止后的事件直接丢弃
+			s.emit(ctx, &model.TriggerEvent{Name: "late"}, nil)
+			if got := sink.names(); len(got) != tt.want {
+				t.Fatalf("emitted %v, want %d events", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,14 @@ package trigger
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	_ "time/tzdata" // 内嵌时区数据库，SCF 运行环境可能缺少 /usr/share/zoneinfo
 
 	"github.com/gorhill/cronexpr"
 	"github.com/mooyang-code/scf-framework/model"
@@ -25,7 +30,23 @@ type timerEntry struct {
 	name        string
 	cronExpr    *cronexpr.Expression
 	granularity Granularity
+	location    *time.Location // cron 表达式求值的时区
+	jitter      time.Duration  // 触发抖动上限，0 表示不延迟
 	handler     TriggerHandler
+	lastFired   time.Time // 上次触发对应的 cron 时刻，同一时刻最多触发一次
+
+	skipIfRunning bool        // 上一次 handler 尚未返回时跳过本次触发
+	running       atomic.Bool // handler 执行中（仅 skipIfRunning 时维护）
+	catchUp       bool        // 补触发自 lastFired 以来错过的周期
+	catchUpMax    int         // 单次 Tick 最多触发的周期数（含当前周期）
+}
+
+// firing 一次待触发的条目与其匹配时刻
+type firing struct {
+	entry    *timerEntry
+	fireTime time.Time
+	delay    time.Duration // 相对本次 Tick 开始时刻的抖动延迟
+	catchUp  bool          // 补触发的错过周期（fireTime 早于最近一个匹配时刻）
 }
 
 // TimerTrigger 基于 TRPC Timer 的定时触发器
@@ -33,23 +54,69 @@ type TimerTrigger struct {
 	entries  []*timerEntry
 	mu       sync.RWMutex
 	lastTick map[Granularity]time.Time // 每种粒度上次 Tick 的时间
+	now      func() time.Time          // 时钟，测试中可替换
 }
 
 // NewTimerTrigger 创建 TimerTrigger
 func NewTimerTrigger() *TimerTrigger {
 	return &TimerTrigger{
 		lastTick: make(map[Granularity]time.Time),
+		now:      time.Now,
 	}
 }
 
-// AddCron 解析 cron 表达式，推断粒度，添加定时器条目
+// AddCron 解析 cron 表达式，推断粒度，添加定时器条目；运行中调用安全，下一次 Tick 起生效
 func (t *TimerTrigger) AddCron(name, cron string, handler TriggerHandler) error {
+	return t.AddCronWithGranularity(name, cron, "", handler)
+}
+
+// AddCronWithGranularity 同 AddCron，granularity 非空时覆盖自动推断的粒度
+func (t *TimerTrigger) AddCronWithGranularity(name, cron string, granularity Granularity, handler TriggerHandler) error {
+	return t.AddCronWithOptions(name, cron, CronOptions{Granularity: granularity}, handler)
+}
+
+// CronOptions 定时器条目的可选参数
+type CronOptions struct {
+	Granularity Granularity    // 非空时覆盖自动推断的粒度
+	Location    *time.Location // cron 表达式求值的时区，nil 为 UTC（不受服务器本地时区影响）
+	Jitter      time.Duration  // 触发后随机延迟 [0, Jitter) 再调用 handler，错开多节点同时触发；不超过到下一次触发的间隔
+	// SkipIfRunning 同一条目的上一次 handler 尚未返回时跳过本次触发（执行时间可能超过触发周期时使用）
+	SkipIfRunning bool
+	// CatchUp 实例暂停/被限流导致错过 Tick 时，下一次 Tick 按上次触发时刻为每个错过的周期各触发一次，
+	// 事件 metadata 的 fire_time 为原定触发时刻；最多补 CatchUpMax 个周期（含当前周期，<= 0 时为 DefaultCatchUpMax），超出的最早周期被丢弃
+	CatchUp    bool
+	CatchUpMax int
+}
+
+// DefaultCatchUpMax 单次 Tick 默认最多触发的周期数
+const DefaultCatchUpMax = 10
+
+// AddCronWithOptions 同 AddCron，按 opts 设置粒度、时区与触发抖动
+func (t *TimerTrigger) AddCronWithOptions(name, cron string, opts CronOptions, handler TriggerHandler) error {
+	granularity, loc := opts.Granularity, opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if opts.Jitter < 0 {
+		return fmt.Errorf("invalid jitter %s: must be >= 0", opts.Jitter)
+	}
+	catchUpMax := opts.CatchUpMax
+	if catchUpMax <= 0 {
+		catchUpMax = DefaultCatchUpMax
+	}
+	cron = normalizeCron(cron)
 	expr, err := cronexpr.Parse(cron)
 	if err != nil {
 		return err
 	}
 
-	granularity := inferGranularity(cron)
+	switch granularity {
+	case "":
+		granularity = inferGranularity(cron)
+	case GranularitySecond, GranularityMinute, GranularityHour:
+	default:
+		return fmt.Errorf("invalid granularity %q: must be second, minute or hour", granularity)
+	}
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -58,18 +125,39 @@ func (t *TimerTrigger) AddCron(name, cron string, handler TriggerHandler) error
 		name:        name,
 		cronExpr:    expr,
 		granularity: granularity,
+		location:    loc,
+		jitter:      opts.Jitter,
 		handler:     handler,
+
+		skipIfRunning: opts.SkipIfRunning,
+		catchUp:       opts.CatchUp,
+		catchUpMax:    catchUpMax,
 	})
 	return nil
 }
 
-// Tick 遍历匹配此粒度的所有条目，检查 cron 在 (lastTick, now] 窗口内是否有匹配，触发 handler
-func (t *TimerTrigger) Tick(ctx context.Context, granularity Granularity) error {
+// RemoveCron 移除指定名称的定时器条目，返回是否存在；运行中调用安全，下一次 Tick 起生效
+func (t *TimerTrigger) RemoveCron(name string) bool {
 	t.mu.Lock()
-	entries := make([]*timerEntry, len(t.entries))
-	copy(entries, t.entries)
+	defer t.mu.Unlock()
 
-	now := time.Now()
+	kept := t.entries[:0:0]
+	for _, entry := range t.entries {
+		if entry.name != name {
+			kept = append(kept, entry)
+		}
+	}
+	removed := len(kept) != len(t.entries)
+	t.entries = kept
+	return removed
+}
+
+// Tick 遍历匹配此粒度的所有条目，检查 cron 在 (lastTick, now] 窗口内是否有匹配，触发 handler；
+// 各条目并发执行，等待全部完成；任一 handler 失败时返回汇总后的错误（跳过与停止中不计为失败）
+func (t *TimerTrigger) Tick(ctx context.Context, granularity Granularity) error {
+	tickStart := time.Now() // 真实时钟，抖动延迟以此为基准；cron 匹配使用 t.now
+	t.mu.Lock()
+	now := t.now()
 
 	// 获取上次 Tick 时间，首次调用时用 now 减去对应粒度的间隔作为窗口起点
 	windowStart, ok := t.lastTick[granularity]
@@ -86,36 +174,183 @@ func (t *TimerTrigger) Tick(ctx context.Context, granularity Granularity) error
 		}
 	}
 	t.lastTick[granularity] = now
-	t.mu.Unlock()
 
-	for _, entry := range entries {
+	// 持锁筛选并记录 lastFired：同一粒度的 Tick 并发或迟到时，同一 cron 时刻不会重复触发
+	var fires []firing
+	for _, entry := range t.entries {
 		if entry.granularity != granularity {
 			continue
 		}
 
-		// 检查从 windowStart 到 now 之间是否有 cron 匹配时刻
-		// Next(windowStart) 返回 windowStart 之后的第一个匹配时刻
-		nextTime := entry.cronExpr.Next(windowStart)
-		if nextTime.After(now) {
+		if entry.catchUp && !entry.lastFired.IsZero() {
+			fires = append(fires, entry.catchUpFires(ctx, now)...)
+			continue
+		}
+
+		// 检查从 windowStart（或上次触发时刻，取较晚者）到 now 之间是否有 cron 匹配时刻
+		// Next(from) 返回 from 之后的第一个匹配时刻，按 from 所在时区求值
+		from := windowStart
+		if entry.lastFired.After(from) {
+			from = entry.lastFired
+		}
+		nextTime := entry.cronExpr.Next(from.In(entry.location))
+		if nextTime.IsZero() || nextTime.After(now) {
 			continue // 窗口内无匹配
 		}
+		entry.lastFired = nextTime
+		fires = append(fires, firing{entry: entry, fireTime: nextTime, delay: entry.jitterDelay(nextTime)})
+	}
+	t.mu.Unlock()
+
+	// 各条目在独立 goroutine 中调度，抖动延迟以 Tick 开始时刻为基准，互不阻塞；
+	// 同一条目的补触发按时间顺序在同一 goroutine 中执行。单个条目失败不影响其余条目，
+	// 等待全部完成后汇总错误返回给 TRPC timer
+	var (
+		wg    sync.WaitGroup
+		errMu sync.Mutex
+		errs  []error
+	)
+	for _, group := range groupFiresByEntry(fires) {
+		wg.Add(1)
+		go func(group []firing) {
+			defer wg.Done()
+			for _, f := range group {
+				if err := t.fire(ctx, granularity, f, tickStart); err != nil {
+					errMu.Lock()
+					errs = append(errs, err)
+					errMu.Unlock()
+				}
+			}
+		}(group)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
 
-		event := &model.TriggerEvent{
-			Type: model.TriggerTimer,
-			Name: entry.name,
-			Metadata: map[string]string{
-				"granularity": string(granularity),
-				"fire_time":   nextTime.Format(time.RFC3339),
-			},
+// groupFiresByEntry 按条目分组，保持条目首次出现的顺序与组内的时间顺序
+func groupFiresByEntry(fires []firing) [][]firing {
+	index := make(map[*timerEntry]int)
+	var groups [][]firing
+	for _, f := range fires {
+		i, ok := index[f.entry]
+		if !ok {
+			i = len(groups)
+			index[f.entry] = i
+			groups = append(groups, nil)
 		}
+		groups[i] = append(groups[i], f)
+	}
+	return groups
+}
 
-		if err := entry.handler(ctx, event); err != nil {
-			log.ErrorContextf(ctx, "[TimerTrigger] handler error for %q: %v", entry.name, err)
+// fire 等待抖动延迟（自 tickStart 起计）后调用条目 handler；跳过与停止中返回 nil
+func (t *TimerTrigger) fire(ctx context.Context, granularity Granularity, f firing, tickStart time.Time) error {
+	if wait := time.Until(tickStart.Add(f.delay)); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.WarnContextf(ctx, "[TimerTrigger] %q skipped: context done during jitter delay %s", f.entry.name, f.delay)
+			return fmt.Errorf("timer %q: jitter delay: %w", f.entry.name, ctx.Err())
+		case <-timer.C:
 		}
 	}
+
+	event := &model.TriggerEvent{
+		Type: model.TriggerTimer,
+		Name: f.entry.name,
+		Metadata: map[string]string{
+			"granularity": string(granularity),
+			"fire_time":   f.fireTime.Format(time.RFC3339),
+		},
+	}
+	if f.delay > 0 {
+		event.Metadata["jitter_delay"] = f.delay.String()
+	}
+	if f.catchUp {
+		event.Metadata["catch_up"] = "true"
+	}
+
+	ran, err := f.entry.invoke(ctx, event)
+	if !ran {
+		log.WarnContextf(ctx, "[TimerTrigger] %q skipped, still running: fire_time=%s",
+			f.entry.name, f.fireTime.Format(time.RFC3339))
+		return nil
+	}
+	if errors.Is(err, ErrEventSkipped) || errors.Is(err, ErrStopping) {
+		return nil
+	}
+	if err != nil {
+		log.ErrorContextf(ctx, "[TimerTrigger] handler error for %q: %v", f.entry.name, err)
+		return fmt.Errorf("timer %q: %w", f.entry.name, err)
+	}
 	return nil
 }
 
+// catchUpFires 返回 (lastFired, now] 内所有匹配时刻对应的触发（最多 catchUpMax 个，保留最近的），并更新 lastFired；
+// 调用方持有 t.mu
+func (e *timerEntry) catchUpFires(ctx context.Context, now time.Time) []firing {
+	var times []time.Time
+	dropped := 0
+	for next := e.cronExpr.Next(e.lastFired.In(e.location)); !next.IsZero() && !next.After(now); next = e.cronExpr.Next(next) {
+		times = append(times, next)
+		if len(times) > e.catchUpMax {
+			times = times[1:]
+			dropped++
+		}
+	}
+	if len(times) == 0 {
+		return nil
+	}
+	if dropped > 0 {
+		log.WarnContextf(ctx, "[TimerTrigger] %q missed %d periods beyond catch_up_max=%d, dropped: last_fired=%s",
+			e.name, dropped, e.catchUpMax, e.lastFired.Format(time.RFC3339))
+	}
+	if len(times) > 1 {
+		log.InfoContextf(ctx, "[TimerTrigger] %q catching up %d missed periods since %s",
+			e.name, len(times)-1, e.lastFired.Format(time.RFC3339))
+	}
+
+	e.lastFired = times[len(times)-1]
+	fires := make([]firing, 0, len(times))
+	for i, ft := range times {
+		f := firing{entry: e, fireTime: ft, catchUp: i < len(times)-1}
+		if !f.catchUp {
+			f.delay = e.jitterDelay(ft)
+		}
+		fires = append(fires, f)
+	}
+	return fires
+}
+
+// invoke 调用 handler；skipIfRunning 时以 running 标记保证同一条目同时只有一次执行，
+// 已有执行中时不调用并返回 ran=false
+func (e *timerEntry) invoke(ctx context.Context, event *model.TriggerEvent) (ran bool, err error) {
+	if e.skipIfRunning {
+		if !e.running.CompareAndSwap(false, true) {
+			return false, nil
+		}
+		defer e.running.Store(false)
+	}
+	return true, e.handler(ctx, event)
+}
+
+// jitterDelay 返回本次触发的随机延迟 [0, min(jitter, period))，period 为 fireTime 到下一次 cron 匹配时刻的间隔，
+// 保证延迟不越过下一周期。随机源为进程级随机种子，各节点的延迟相互独立
+func (e *timerEntry) jitterDelay(fireTime time.Time) time.Duration {
+	if e.jitter <= 0 {
+		return 0
+	}
+	limit := e.jitter
+	if next := e.cronExpr.Next(fireTime.In(e.location)); !next.IsZero() {
+		limit = min(limit, next.Sub(fireTime))
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
+
 // HasEntries 返回是否有任何定时器条目
 func (t *TimerTrigger) HasEntries() bool {
 	t.mu.RLock()
@@ -123,6 +358,17 @@ func (t *TimerTrigger) HasEntries() bool {
 	return len(t.entries) > 0
 }
 
+// Names 返回所有定时器条目名称（按注册顺序）
+func (t *TimerTrigger) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	names := make([]string, 0, len(t.entries))
+	for _, entry := range t.entries {
+		names = append(names, entry.name)
+	}
+	return names
+}
+
 // HasGranularity 返回是否有指定粒度的条目
 func (t *TimerTrigger) HasGranularity(g Granularity) bool {
 	t.mu.RLock()
@@ -135,10 +381,23 @@ func (t *TimerTrigger) HasGranularity(g Granularity) bool {
 	return false
 }
 
-// inferGranularity 从 cron 表达式推断粒度
-// 秒位含 */ 或 , 或 - → second（真正的秒级调度）
-// 秒位为固定数字（如 "0"、"30"）→ 视为分钟级（只是偏移）
-// 秒位为固定值且分位含 */ 或 , 或 - 或非 "0" → minute
+// normalizeCron 将 cron 规范为 7 段（秒 分 时 日 月 周 年），使秒位含义与 inferGranularity 一致：
+// 5 段（分 时 日 月 周）补秒位 0 与年位 *；6 段视为（秒 分 时 日 月 周）补年位 *
+func normalizeCron(cron string) string {
+	parts := strings.Fields(cron)
+	switch len(parts) {
+	case 5:
+		return "0 " + strings.Join(parts, " ") + " *"
+	case 6:
+		return strings.Join(parts, " ") + " *"
+	default:
+		return cron
+	}
+}
+
+// inferGranularity 从（已规范为 7 段的）cron 表达式推断粒度，使条目在能命中其触发时刻的 Tick 上检查：
+// 秒位不是固定的 "0"（含 */、逗号、范围或非零固定值如 "30"）→ second
+// 秒位为 "0" 且分位不是固定的 "0" → minute
 // 否则 → hour
 func inferGranularity(cron string) Granularity {
 	parts := strings.Fields(cron)
@@ -149,14 +408,9 @@ func inferGranularity(cron string) Granularity {
 	secField := parts[0]
 	minField := parts[1]
 
-	// 秒位含 */、逗号、范围 → 真正的秒级调度
-	if strings.ContainsAny(secField, "*/,-") {
+	if secField != "0" {
 		return GranularitySecond
 	}
-	// 秒位是固定数字（含 "0"），按分位判断
-	if minField != "0" && !strings.ContainsAny(minField, "*/,-") {
-		return GranularityMinute
-	}
 	if minField != "0" {
 		return GranularityMinute
 	}
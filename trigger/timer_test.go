@@ -0,0 +1,596 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// fakeClock 可手动设置的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	c.mu.Unlock()
+}
+
+// newTestTimer 创建使用 fakeClock 的 TimerTrigger，时钟初始为 start
+func newTestTimer(start time.Time) (*TimerTrigger, *fakeClock) {
+	clock := &fakeClock{now: start}
+	t := NewTimerTrigger()
+	t.now = clock.Now
+	return t, clock
+}
+
+// fireRecorder 记录定时器 handler 收到的事件
+type fireRecorder struct {
+	mu     sync.Mutex
+	events []*model.TriggerEvent
+}
+
+func (r *fireRecorder) handle(ctx context.Context, event *model.TriggerEvent) error {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *fireRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+func TestTimerAddCronAfterTick(t *testing.T) {
+	base := time.Date(2026, 1, 5, 10, 0, 30, 0, time.UTC)
+	tests := []struct {
+		name      string
+		cron      string
+		remove    bool
+		nextTick  time.Time
+		wantFires int
+	}{
+		{name: "every minute fires on next minute tick", cron: "* * * * *", nextTick: base.Add(30 * time.Second), wantFires: 1},
+		{name: "not yet due", cron: "5 * * * *", nextTick: base.Add(30 * time.Second), wantFires: 0},
+		{name: "due later in the window", cron: "5 * * * *", nextTick: base.Add(4*time.Minute + 30*time.Second), wantFires: 1},
+		{name: "second level cron", cron: "*/15 * * * * *", nextTick: base.Add(15 * time.Second), wantFires: 1},
+		{name: "removed before next tick", cron: "* * * * *", remove: true, nextTick: base.Add(30 * time.Second), wantFires: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer, clock := newTestTimer(base)
+			granularity := inferGranularity(normalizeCron(tt.cron))
+			if err := timer.Tick(context.Background(), granularity); err != nil {
+				t.Fatalf("first Tick() error = %v", err)
+			}
+
+			rec := &fireRecorder{}
+			if err := timer.AddCron("dynamic", tt.cron, rec.handle); err != nil {
+				t.Fatalf("AddCron() error = %v", err)
+			}
+			if tt.remove && !timer.RemoveCron("dynamic") {
+				t.Fatalf("RemoveCron() = false, want true")
+			}
+
+			clock.Set(tt.nextTick)
+			if err := timer.Tick(context.Background(), granularity); err != nil {
+				t.Fatalf("second Tick() error = %v", err)
+			}
+			if got := rec.count(); got != tt.wantFires {
+				t.Fatalf("handler fired %d times, want %d", got, tt.wantFires)
+			}
+		})
+	}
+}
+
+func TestManagerAddRemoveTimer(t *testing.T) {
+	tests := []struct {
+		name       string
+		op         func(ctx context.Context, m *Manager) (bool, error)
+		wantOK     bool
+		wantErr    bool
+		wantTimers string
+	}{
+		{
+			name: "add dynamic timer",
+			op: func(ctx context.Context, m *Manager) (bool, error) {
+				return true, m.AddTimer(ctx, "dynamic", "*/5 * * * *")
+			},
+			wantOK: true, wantTimers: "static,dynamic",
+		},
+		{
+			name: "replace dynamic timer",
+			op: func(ctx context.Context, m *Manager) (bool, error) {
+				if err := m.AddTimer(ctx, "dynamic", "*/5 * * * *"); err != nil {
+					return false, err
+				}
+				return true, m.AddTimer(ctx, "dynamic", "*/10 * * * *")
+			},
+			wantOK: true, wantTimers: "static,dynamic",
+		},
+		{
+			name: "static timer cannot be replaced",
+			op: func(ctx context.Context, m *Manager) (bool, error) {
+				return true, m.AddTimer(ctx, "static", "*/5 * * * *")
+			},
+			wantOK: true, wantErr: true, wantTimers: "static",
+		},
+		{
+			name: "invalid cron",
+			op: func(ctx context.Context, m *Manager) (bool, error) {
+				return true, m.AddTimer(ctx, "dynamic", "not a cron")
+			},
+			wantOK: true, wantErr: true, wantTimers: "static",
+		},
+		{
+			name: "remove dynamic timer",
+			op: func(ctx context.Context, m *Manager) (bool, error) {
+				if err := m.AddTimer(ctx, "dynamic", "*/5 * * * *"); err != nil {
+					return false, err
+				}
+				return m.RemoveTimer(ctx, "dynamic"), nil
+			},
+			wantOK: true, wantTimers: "static",
+		},
+		{
+			name: "static timer is not removed",
+			op: func(ctx context.Context, m *Manager) (bool, error) {
+				return m.RemoveTimer(ctx, "static"), nil
+			},
+			wantOK: false, wantTimers: "static",
+		},
+		{
+			name: "remove unknown timer",
+			op: func(ctx context.Context, m *Manager) (bool, error) {
+				return m.RemoveTimer(ctx, "missing"), nil
+			},
+			wantOK: false, wantTimers: "static",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := NewManager(&recordingPlugin{}, nil, nil, nil, nil, nil, nil)
+			if err := m.Init(ctx, []model.TriggerConfig{timerConfig("static", "* * * * *")}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+
+			ok, err := tt.op(ctx, m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("op error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("op ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got := strings.Join(timerNames(m.Timer()), ","); got != tt.wantTimers {
+				t.Fatalf("timers = %q, want %q", got, tt.wantTimers)
+			}
+		})
+	}
+
+	m := NewManager(&recordingPlugin{}, nil, nil, nil, nil, nil, nil)
+	if err := m.AddTimer(context.Background(), "dynamic", "* * * * *"); err == nil {
+		t.Fatalf("AddTimer() before Init: want error")
+	}
+}
+
+func TestInferGranularity(t *testing.T) {
+	tests := []struct {
+		cron string
+		want Granularity
+	}{
+		{cron: "0 * * * * *", want: GranularityMinute},
+		{cron: "30 * * * * *", want: GranularitySecond},
+		{cron: "*/15 * * * * *", want: GranularitySecond},
+		{cron: "0 0 * * * *", want: GranularityHour},
+		{cron: "0,30 * * * * *", want: GranularitySecond},
+		{cron: "0 */5 * * * *", want: GranularityMinute},
+		{cron: "* * * * *", want: GranularityMinute},
+		{cron: "0 * * * *", want: GranularityHour},
+		{cron: "0 0 * * * * *", want: GranularityHour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cron, func(t *testing.T) {
+			if got := inferGranularity(normalizeCron(tt.cron)); got != tt.want {
+				t.Fatalf("inferGranularity(%q) = %s, want %s", tt.cron, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixedSecondCronFiresOnSecondTick(t *testing.T) {
+	base := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		cron        string
+		granularity Granularity // 显式覆盖，空表示自动推断
+		tick        Granularity
+		tickAt      time.Duration // 第二次 Tick 相对 base 的偏移（首次 Tick 在 base）
+		wantFires   int
+	}{
+		{name: "second 30 on second tick", cron: "30 * * * * *", tick: GranularitySecond, tickAt: 30 * time.Second, wantFires: 1},
+		{name: "second 30 not on second 29", cron: "30 * * * * *", tick: GranularitySecond, tickAt: 29 * time.Second, wantFires: 0},
+		{name: "second 30 ignored by minute tick", cron: "30 * * * * *", tick: GranularityMinute, tickAt: time.Minute, wantFires: 0},
+		{name: "explicit minute granularity", cron: "0 * * * * *", granularity: GranularityMinute, tick: GranularityMinute,
+			tickAt: time.Minute, wantFires: 1},
+		{name: "explicit second granularity", cron: "0 * * * * *", granularity: GranularitySecond, tick: GranularitySecond,
+			tickAt: time.Minute, wantFires: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer, clock := newTestTimer(base)
+			rec := &fireRecorder{}
+			if err := timer.AddCronWithGranularity("fixed", tt.cron, tt.granularity, rec.handle); err != nil {
+				t.Fatalf("AddCronWithGranularity() error = %v", err)
+			}
+			if err := timer.Tick(context.Background(), tt.tick); err != nil {
+				t.Fatalf("first Tick() error = %v", err)
+			}
+			// 只统计第二次 Tick 的触发（首次 Tick 的窗口可能包含 base 本身）
+			before := rec.count()
+			clock.Set(base.Add(tt.tickAt))
+			if err := timer.Tick(context.Background(), tt.tick); err != nil {
+				t.Fatalf("second Tick() error = %v", err)
+			}
+			if got := rec.count() - before; got != tt.wantFires {
+				t.Fatalf("handler fired %d times, want %d", got, tt.wantFires)
+			}
+		})
+	}
+
+	timer := NewTimerTrigger()
+	if err := timer.AddCronWithGranularity("bad", "* * * * *", "daily", nil); err == nil {
+		t.Fatalf("AddCronWithGranularity() with invalid granularity: want error")
+	}
+}
+
+func TestTickFiresOncePerPeriod(t *testing.T) {
+	base := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		cron        string
+		granularity Granularity
+		ticks       []time.Duration // 各次 Tick 相对 base 的偏移
+		wantFires   int
+	}{
+		{name: "second cron ticked twice within the same second", cron: "* * * * * *", granularity: GranularitySecond,
+			ticks: []time.Duration{200 * time.Millisecond, 700 * time.Millisecond}, wantFires: 1},
+		{name: "same instant ticked twice", cron: "* * * * * *", granularity: GranularitySecond,
+			ticks: []time.Duration{0, 0}, wantFires: 1},
+		{name: "minute cron with a late tick", cron: "* * * * *", granularity: GranularityMinute,
+			ticks: []time.Duration{100 * time.Millisecond, 900 * time.Millisecond}, wantFires: 1},
+		{name: "next second fires again", cron: "* * * * * *", granularity: GranularitySecond,
+			ticks: []time.Duration{200 * time.Millisecond, 1200 * time.Millisecond}, wantFires: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer, clock := newTestTimer(base)
+			rec := &fireRecorder{}
+			if err := timer.AddCron("dedup", tt.cron, rec.handle); err != nil {
+				t.Fatalf("AddCron() error = %v", err)
+			}
+			for _, offset := range tt.ticks {
+				clock.Set(base.Add(offset))
+				if err := timer.Tick(context.Background(), tt.granularity); err != nil {
+					t.Fatalf("Tick() error = %v", err)
+				}
+			}
+			if got := rec.count(); got != tt.wantFires {
+				t.Fatalf("handler fired %d times, want %d", got, tt.wantFires)
+			}
+		})
+	}
+
+	// 秒级服务与注册的调度器在同一秒内并发 Tick
+	timer, _ := newTestTimer(base.Add(300 * time.Millisecond))
+	rec := &fireRecorder{}
+	if err := timer.AddCron("concurrent", "* * * * * *", rec.handle); err != nil {
+		t.Fatalf("AddCron() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			timer.Tick(context.Background(), GranularitySecond)
+		}()
+	}
+	wg.Wait()
+	if got := rec.count(); got != 1 {
+		t.Fatalf("concurrent ticks fired handler %d times, want 1", got)
+	}
+}
+
+func TestTimerTimezoneAcrossDST(t *testing.T) {
+	utc := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("parse %s: %v", s, err)
+		}
+		return ts
+	}
+	tests := []struct {
+		name     string
+		timezone string
+		cron     string
+		from, to string   // 按小时 Tick 的 UTC 区间 [from, to]
+		want     []string // 期望触发时刻（UTC）
+		wantErr  string
+	}{
+		{
+			name: "unset timezone evaluates in UTC",
+			cron: "0 0 9 * * *", from: "2026-03-07T00:00:00Z", to: "2026-03-09T23:00:00Z",
+			want: []string{"2026-03-07T09:00:00Z", "2026-03-08T09:00:00Z", "2026-03-09T09:00:00Z"},
+		},
+		{
+			name: "spring forward keeps local 09:00", timezone: "America/New_York",
+			cron: "0 0 9 * * *", from: "2026-03-07T00:00:00Z", to: "2026-03-09T23:00:00Z",
+			want: []string{"2026-03-07T14:00:00Z", "2026-03-08T13:00:00Z", "2026-03-09T13:00:00Z"},
+		},
+		{
+			name: "fall back keeps local 09:00", timezone: "America/New_York",
+			cron: "0 0 9 * * *", from: "2025-11-01T00:00:00Z", to: "2025-11-03T23:00:00Z",
+			want: []string{"2025-11-01T13:00:00Z", "2025-11-02T14:00:00Z", "2025-11-03T14:00:00Z"},
+		},
+		{
+			name: "zone without DST", timezone: "Asia/Shanghai",
+			cron: "0 0 9 * * *", from: "2026-03-07T00:00:00Z", to: "2026-03-08T23:00:00Z",
+			want: []string{"2026-03-07T01:00:00Z", "2026-03-08T01:00:00Z"},
+		},
+		{name: "invalid timezone", timezone: "Mars/Olympus", cron: "0 0 9 * * *", wantErr: `invalid timezone "Mars/Olympus"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &recordingPlugin{}
+			m := NewManager(plugin, nil, nil, nil, nil, nil, nil)
+			cfg := timerConfig("daily", tt.cron)
+			if tt.timezone != "" {
+				cfg.Settings["timezone"] = tt.timezone
+			}
+			err := m.Init(context.Background(), []model.TriggerConfig{cfg})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Init() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			clock := &fakeClock{}
+			m.Timer().now = clock.Now
+
+			for now, end := utc(tt.from), utc(tt.to); !now.After(end); now = now.Add(time.Hour) {
+				clock.Set(now)
+				if err := m.Timer().Tick(context.Background(), GranularityHour); err != nil {
+					t.Fatalf("Tick(%s) error = %v", now, err)
+				}
+			}
+
+			var got []string
+			for _, ev := range plugin.Events() {
+				ft, err := time.Parse(time.RFC3339, ev.Metadata["fire_time"])
+				if err != nil {
+					t.Fatalf("fire_time %q: %v", ev.Metadata["fire_time"], err)
+				}
+				got = append(got, ft.UTC().Format(time.RFC3339))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("fire times = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimerJitterBounds(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	tests := []struct {
+		name     string
+		cron     string
+		location *time.Location
+		jitter   time.Duration
+		fireTime string
+		want     time.Duration // 延迟上限（不含）
+	}{
+		{name: "no jitter", cron: "0 * * * * *", fireTime: "2026-03-07T10:00:00Z"},
+		{name: "jitter below period", cron: "0 * * * * *", jitter: 10 * time.Second, fireTime: "2026-03-07T10:00:00Z", want: 10 * time.Second},
+		{name: "jitter capped at period", cron: "* * * * * *", jitter: 5 * time.Second, fireTime: "2026-03-07T10:00:00Z", want: time.Second},
+		{
+			name: "period shortened by DST", cron: "0 0 9 * * *", location: newYork, jitter: 48 * time.Hour,
+			fireTime: "2026-03-07T14:00:00Z", want: 23 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer, _ := newTestTimer(time.Time{})
+			opts := CronOptions{Location: tt.location, Jitter: tt.jitter}
+			if err := timer.AddCronWithOptions("job", tt.cron, opts, (&fireRecorder{}).handle); err != nil {
+				t.Fatalf("AddCronWithOptions() error = %v", err)
+			}
+			fireTime, err := time.Parse(time.RFC3339, tt.fireTime)
+			if err != nil {
+				t.Fatalf("parse fire time: %v", err)
+			}
+			var maxSeen time.Duration
+			for i := 0; i < 1000; i++ {
+				d := timer.entries[0].jitterDelay(fireTime)
+				if d < 0 || (tt.want == 0 && d != 0) || (tt.want > 0 && d >= tt.want) {
+					t.Fatalf("jitterDelay() = %s, want in [0, %s)", d, tt.want)
+				}
+				maxSeen = max(maxSeen, d)
+			}
+			// 1000 次采样应覆盖区间上半段，排除延迟恒为 0 或被过度截断
+			if tt.want > 0 && maxSeen < tt.want/2 {
+				t.Fatalf("max jitterDelay() = %s over 1000 samples, want close to %s", maxSeen, tt.want)
+			}
+		})
+	}
+}
+
+func TestTickSchedulesEntriesIndependently(t *testing.T) {
+	// 时钟远晚于真实时间：抖动延迟须以 Tick 开始的真实时刻为基准，而非 fake clock
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		jitter time.Duration
+	}{
+		{name: "without jitter"},
+		{name: "with jitter", jitter: 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer, _ := newTestTimer(start)
+			var (
+				mu      sync.Mutex
+				elapsed = make(map[string]time.Duration)
+				delays  = make(map[string]time.Duration)
+			)
+			fastDone := make(chan struct{})
+			tickStart := time.Now()
+			record := func(event *model.TriggerEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				elapsed[event.Name] = time.Since(tickStart)
+				if v, ok := event.Metadata["jitter_delay"]; ok {
+					d, err := time.ParseDuration(v)
+					if err != nil {
+						t.Errorf("jitter_delay %q: %v", v, err)
+					}
+					delays[event.Name] = d
+				}
+			}
+			// slow 阻塞到 fast 执行完成：若条目串行执行且 slow 先运行，fast 永远不会被调用
+			slow := func(ctx context.Context, event *model.TriggerEvent) error {
+				record(event)
+				select {
+				case <-fastDone:
+					return nil
+				case <-time.After(2 * time.Second):
+					return errors.New("fast entry blocked behind slow entry")
+				}
+			}
+			fast := func(ctx context.Context, event *model.TriggerEvent) error {
+				record(event)
+				close(fastDone)
+				return nil
+			}
+			failing := func(ctx context.Context, event *model.TriggerEvent) error {
+				return errors.New("boom")
+			}
+			opts := CronOptions{Granularity: GranularitySecond, Jitter: tt.jitter}
+			for name, h := range map[string]TriggerHandler{"slow": slow, "fast": fast, "failing": failing} {
+				if err := timer.AddCronWithOptions(name, "* * * * * *", opts, h); err != nil {
+					t.Fatalf("AddCronWithOptions(%s) error = %v", name, err)
+				}
+			}
+			tickStart = time.Now()
+			err := timer.Tick(context.Background(), GranularitySecond)
+			took := time.Since(tickStart)
+			if err == nil || !strings.Contains(err.Error(), `timer "failing": boom`) || strings.Contains(err.Error(), "blocked") {
+				t.Fatalf("Tick() error = %v, want only the failing entry's error", err)
+			}
+			if took > time.Second {
+				t.Fatalf("Tick() took %s, want under 1s", took)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, name := range []string{"slow", "fast"} {
+				d := delays[name]
+				if d < 0 || d > tt.jitter {
+					t.Fatalf("%s jitter_delay = %s, want in [0, %s]", name, d, tt.jitter)
+				}
+				if elapsed[name] < d {
+					t.Fatalf("%s ran %s after tick start, before its jitter delay %s", name, elapsed[name], d)
+				}
+			}
+		})
+	}
+}
+
+func TestTimerSkipIfRunning(t *testing.T) {
+	start := time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		skipIfRunning bool
+		handlerErr    error
+		wantOverlap   int // 首次执行阻塞期间，下一次 Tick 调用 handler 的次数
+	}{
+		{name: "overlap allowed by default", wantOverlap: 1},
+		{name: "skipped while running", skipIfRunning: true},
+		{name: "failed run releases guard", skipIfRunning: true, handlerErr: errors.New("boom")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer, clock := newTestTimer(start)
+			var calls atomic.Int32
+			release := make(chan struct{})
+			started := make(chan struct{}, 3)
+			slow := func(ctx context.Context, event *model.TriggerEvent) error {
+				n := calls.Add(1)
+				started <- struct{}{}
+				if n == 1 {
+					<-release
+				}
+				return tt.handlerErr
+			}
+			opts := CronOptions{Granularity: GranularitySecond, SkipIfRunning: tt.skipIfRunning}
+			if err := timer.AddCronWithOptions("collect", "* * * * * *", opts, slow); err != nil {
+				t.Fatalf("AddCronWithOptions() error = %v", err)
+			}
+
+			// 第一次 Tick 的 handler 阻塞到 release
+			first := make(chan error, 1)
+			go func() { first <- timer.Tick(context.Background(), GranularitySecond) }()
+			<-started
+
+			clock.Set(start.Add(time.Second))
+			done := make(chan error, 1)
+			go func() { done <- timer.Tick(context.Background(), GranularitySecond) }()
+			select {
+			case err := <-done:
+				if err != nil && tt.handlerErr == nil {
+					t.Fatalf("overlapping Tick() error = %v", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("overlapping Tick() blocked behind the running handler")
+			}
+			if got := int(calls.Load()) - 1; got != tt.wantOverlap {
+				t.Fatalf("handler invoked %d times while running, want %d", got, tt.wantOverlap)
+			}
+
+			close(release)
+			if err := <-first; (err != nil) != (tt.handlerErr != nil) {
+				t.Fatalf("first Tick() error = %v, want %v", err, tt.handlerErr)
+			}
+
+			// 上一次执行返回后（无论成功失败），下一周期正常触发
+			before := calls.Load()
+			clock.Set(start.Add(2 * time.Second))
+			if err := timer.Tick(context.Background(), GranularitySecond); (err != nil) != (tt.handlerErr != nil) {
+				t.Fatalf("Tick() after release error = %v", err)
+			}
+			if calls.Load() != before+1 {
+				t.Fatalf("handler invoked %d times after release, want 1", calls.Load()-before)
+			}
+		})
+	}
+}
@@ -2,10 +2,20 @@ package trigger
 
 import (
 	"context"
+	"errors"
 
 	"github.com/mooyang-code/scf-framework/model"
 )
 
+// ErrEventSkipped 插件声明忽略该事件（DispositionSkip）：消息确认但不计为已处理
+var ErrEventSkipped = errors.New("event skipped by plugin")
+
+// ErrEventRetry 插件要求重投递该事件（DispositionRetry）
+var ErrEventRetry = errors.New("event retry requested by plugin")
+
+// ErrStopping 触发器管理器正在停止，不再接收新的触发事件
+var ErrStopping = errors.New("trigger manager is stopping")
+
 // TriggerHandler 触发事件处理函数
 type TriggerHandler func(ctx context.Context, event *model.TriggerEvent) error
 
@@ -17,3 +27,14 @@ type Trigger interface {
 	Start(ctx context.Context, handler TriggerHandler) error
 	Stop(ctx context.Context) error
 }
+
+// HealthChecker 可选接口，触发器可实现此接口上报连接健康状态
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// ReadinessChecker 可选接口，触发器可实现此接口参与就绪检查（如容忍短暂断连）；
+// 未实现时以 HealthChecker 的结果为准
+type ReadinessChecker interface {
+	CheckReady(ctx context.Context) error
+}
@@ -0,0 +1,286 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mooyang-code/scf-framework/model"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// WebSocketConfig WebSocket 推送触发器配置
+type WebSocketConfig struct {
+	URL          string
+	Headers      http.Header // 握手请求头（如鉴权）
+	Subscribe    []byte      // 每次连接（含重连）成功后发送的订阅消息，为空时不发送
+	ReconnectMin int         // 重连退避初始间隔（秒）
+	ReconnectMax int         // 重连退避最大间隔（秒）
+	PingInterval int         // 心跳 Ping 间隔（秒），0 表示不发送；超过 2 个间隔未收到数据或 Pong 视为断连
+	ReadyGrace   int         // 断连超过该时长（秒）后就绪检查失败
+}
+
+// WebSocketTrigger 连接交易所等推送源的 WebSocket，每个入站数据帧触发一次事件；断连后按指数退避自动重连
+type WebSocketTrigger struct {
+	name     string
+	config   WebSocketConfig
+	handler  TriggerHandler
+	cancel   context.CancelFunc
+	loopDone chan struct{}
+
+	disconnectedAt atomic.Int64 // 最近一次断连的时间（UnixNano），0 表示已连接
+}
+
+// NewWebSocketTrigger 创建 WebSocketTrigger
+func NewWebSocketTrigger(name string) *WebSocketTrigger {
+	return &WebSocketTrigger{name: name}
+}
+
+// Name 返回触发器名称
+func (t *WebSocketTrigger) Name() string {
+	return t.name
+}
+
+// Type 返回触发器类型
+func (t *WebSocketTrigger) Type() model.TriggerType {
+	return model.TriggerWebSocket
+}
+
+// Init 从 TriggerConfig.Settings 解析 WebSocketConfig
+func (t *WebSocketTrigger) Init(_ context.Context, cfg model.TriggerConfig) error {
+	s := cfg.Settings
+
+	t.config.URL, _ = s["url"].(string)
+	if !strings.HasPrefix(t.config.URL, "ws://") && !strings.HasPrefix(t.config.URL, "wss://") {
+		return fmt.Errorf("websocket trigger %q url must start with ws:// or wss://, got %q", t.name, t.config.URL)
+	}
+
+	t.config.Headers = http.Header{}
+	if v, ok := s["headers"].(map[string]interface{}); ok {
+		for k, val := range v {
+			if str, ok := val.(string); ok {
+				t.config.Headers.Set(k, str)
+			}
+		}
+	}
+
+	// subscribe 为字符串时原样发送，为对象/数组时编码为 JSON 发送
+	switch v := s["subscribe"].(type) {
+	case nil:
+	case string:
+		t.config.Subscribe = []byte(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("websocket trigger %q invalid subscribe setting: %w", t.name, err)
+		}
+		t.config.Subscribe = data
+	}
+
+	t.config.ReconnectMin = getIntSetting(s, "reconnect_min", 1)
+	t.config.ReconnectMax = getIntSetting(s, "reconnect_max", 30)
+	if t.config.ReconnectMin <= 0 || t.config.ReconnectMax < t.config.ReconnectMin {
+		return fmt.Errorf("websocket trigger %q requires 0 < reconnect_min <= reconnect_max, got %d/%d",
+			t.name, t.config.ReconnectMin, t.config.ReconnectMax)
+	}
+	t.config.PingInterval = getIntSetting(s, "ping_interval", 30)
+	if t.config.PingInterval < 0 {
+		t.config.PingInterval = 0
+	}
+	t.config.ReadyGrace = getIntSetting(s, "ready_grace", 30)
+	if t.config.ReadyGrace < 0 {
+		t.config.ReadyGrace = 0
+	}
+	return nil
+}
+
+// Start 启动连接循环，首次连接在后台进行，连接失败不阻塞启动
+func (t *WebSocketTrigger) Start(ctx context.Context, handler TriggerHandler) error {
+	t.handler = handler
+	t.disconnectedAt.Store(time.Now().UnixNano())
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	t.cancel = cancel
+	t.loopDone = make(chan struct{})
+
+	go t.connectLoop(loopCtx)
+
+	log.InfoContextf(ctx, "[WebSocketTrigger] %s started: url=%s, subscribe=%v, ping_interval=%ds",
+		t.name, t.config.URL, len(t.config.Subscribe) > 0, t.config.PingInterval)
+	return nil
+}
+
+// Stop 关闭连接并等待连接循环退出（以 ctx 为截止）
+func (t *WebSocketTrigger) Stop(ctx context.Context) error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	if t.loopDone != nil {
+		select {
+		case <-t.loopDone:
+		case <-ctx.Done():
+			return fmt.Errorf("websocket trigger %q loop not drained: %w", t.name, ctx.Err())
+		}
+	}
+	return nil
+}
+
+// CheckHealth 检查连接状态
+func (t *WebSocketTrigger) CheckHealth(_ context.Context) error {
+	if t.loopDone == nil {
+		return fmt.Errorf("websocket trigger %q not started", t.name)
+	}
+	if since := t.disconnectedAt.Load(); since != 0 {
+		return fmt.Errorf("websocket trigger %q disconnected for %s", t.name,
+			time.Since(time.Unix(0, since)).Truncate(time.Second))
+	}
+	return nil
+}
+
+// CheckReady 就绪检查：断连未超过 ready_grace 时仍视为就绪，容忍短暂重连
+func (t *WebSocketTrigger) CheckReady(ctx context.Context) error {
+	err := t.CheckHealth(ctx)
+	if err == nil || t.loopDone == nil {
+		return err
+	}
+	if since := t.disconnectedAt.Load(); since != 0 &&
+		time.Since(time.Unix(0, since)) < time.Duration(t.config.ReadyGrace)*time.Second {
+		return nil
+	}
+	return err
+}
+
+// connectLoop 建立连接并读取消息，断连后按指数退避（附带抖动）重连，连接保持超过 reconnect_max 后退避重置
+func (t *WebSocketTrigger) connectLoop(ctx context.Context) {
+	defer close(t.loopDone)
+
+	connLog := newErrLogLimiter("[WebSocketTrigger] "+t.name+" connection", defaultErrLogInterval)
+	minBackoff := time.Duration(t.config.ReconnectMin) * time.Second
+	maxBackoff := time.Duration(t.config.ReconnectMax) * time.Second
+	backoff := minBackoff
+	for {
+		connectedAt := time.Now()
+		err := t.serveConn(ctx, connLog)
+		t.disconnectedAt.CompareAndSwap(0, time.Now().UnixNano())
+		if ctx.Err() != nil || errors.Is(err, ErrStopping) {
+			log.InfoContextf(ctx, "[WebSocketTrigger] %s loop exiting", t.name)
+			return
+		}
+		connLog.failure(ctx, err)
+
+		if time.Since(connectedAt) >= maxBackoff {
+			backoff = minBackoff
+		}
+		wait := backoff + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			log.InfoContextf(ctx, "[WebSocketTrigger] %s loop exiting", t.name)
+			return
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// serveConn 建立一次连接并持续读取，直到连接出错或 ctx 取消
+func (t *WebSocketTrigger) serveConn(ctx context.Context, connLog *errLogLimiter) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, t.config.URL, t.config.Headers)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", t.config.URL, err)
+	}
+	defer conn.Close()
+
+	if len(t.config.Subscribe) > 0 {
+		if err := conn.WriteMessage(websocket.TextMessage, t.config.Subscribe); err != nil {
+			return fmt.Errorf("send subscribe: %w", err)
+		}
+	}
+	t.disconnectedAt.Store(0)
+	connLog.success(ctx)
+	log.InfoContextf(ctx, "[WebSocketTrigger] %s connected: url=%s", t.name, t.config.URL)
+
+	connDone := make(chan struct{})
+	defer close(connDone)
+	go t.keepAlive(ctx, conn, connDone)
+
+	readTimeout := 2 * time.Duration(t.config.PingInterval) * time.Second
+	if readTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(readTimeout))
+		})
+	}
+
+	for {
+		msgType, data, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		if readTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+		if err := t.dispatch(ctx, msgType, data); err != nil {
+			return err
+		}
+	}
+}
+
+// keepAlive 周期发送 Ping；ctx 取消时发送 Close 帧并关闭连接，使阻塞中的 ReadMessage 返回
+func (t *WebSocketTrigger) keepAlive(ctx context.Context, conn *websocket.Conn, connDone <-chan struct{}) {
+	var tick <-chan time.Time
+	if t.config.PingInterval > 0 {
+		ticker := time.NewTicker(time.Duration(t.config.PingInterval) * time.Second)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-connDone:
+			return
+		case <-ctx.Done():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			conn.Close()
+			return
+		case <-tick:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				log.WarnContextf(ctx, "[WebSocketTrigger] %s ping failed: %v", t.name, err)
+			}
+		}
+	}
+}
+
+// dispatch 将一个数据帧转换为 TriggerEvent 交给 handler；推送源无重投语义，handler 失败仅记录日志
+func (t *WebSocketTrigger) dispatch(ctx context.Context, msgType int, data []byte) error {
+	frameType := "text"
+	if msgType == websocket.BinaryMessage {
+		frameType = "binary"
+	}
+	event := &model.TriggerEvent{
+		Type:    model.TriggerWebSocket,
+		Name:    t.name,
+		Payload: data,
+		Metadata: map[string]string{
+			"url":         t.config.URL,
+			"frame_type":  frameType,
+			"received_at": time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	}
+
+	err := t.handler(ctx, event)
+	switch {
+	case err == nil, errors.Is(err, ErrEventSkipped):
+	case errors.Is(err, ErrStopping):
+		return err
+	default:
+		log.ErrorContextf(ctx, "[WebSocketTrigger] %s handler error: %v", t.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package trigger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/mooyang-code/scf-framework/model"
+)
+
+// wsTestServer 记录每次握手，前 rejects 次握手返回 503，之后升级连接并交给 serve 处理
+type wsTestServer struct {
+	*httptest.Server
+	rejects int
+	serve   func(conn *websocket.Conn)
+
+	mu       sync.Mutex
+	attempts []time.Time
+}
+
+func newWSTestServer(rejects int, serve func(conn *websocket.Conn)) *wsTestServer {
+	s := &wsTestServer{rejects: rejects, serve: serve}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.attempts = append(s.attempts, time.Now())
+		n := len(s.attempts)
+		s.mu.Unlock()
+		if n <= s.rejects {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn)
+	}))
+	return s
+}
+
+func (s *wsTestServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func (s *wsTestServer) attemptTimes() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.attempts...)
+}
+
+// initWebSocketTrigger 以 settings 初始化 WebSocketTrigger
+func initWebSocketTrigger(t *testing.T, settings map[string]interface{}) *WebSocketTrigger {
+	t.Helper()
+	trig := NewWebSocketTrigger("ticker")
+	cfg := model.TriggerConfig{Name: "ticker", Type: string(model.TriggerWebSocket), Settings: settings}
+	if err := trig.Init(context.Background(), cfg); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	return trig
+}
+
+func TestWebSocketTriggerReconnect(t *testing.T) {
+	subscribes := make(chan string, 4)
+	// 每次连接：读取订阅消息，推送一帧后断开
+	srv := newWSTestServer(0, func(conn *websocket.Conn) {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		subscribes <- string(msg)
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"price":1}`))
+	})
+	defer srv.Close()
+
+	trig := initWebSocketTrigger(t, map[string]interface{}{
+		"url":           srv.wsURL(),
+		"subscribe":     map[string]interface{}{"op": "subscribe", "args": []interface{}{"BTC-USDT"}},
+		"reconnect_min": 1,
+		"reconnect_max": 1,
+		"ping_interval": 0,
+	})
+	events := make(chan *model.TriggerEvent, 4)
+	if err := trig.Start(context.Background(), func(ctx context.Context, event *model.TriggerEvent) error {
+		events <- event
+		return nil
+	}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer trig.Stop(context.Background())
+
+	for i := 0; i < 2; i++ {
+		select {
+		case sub := <-subscribes:
+			if sub != `{"args":["BTC-USDT"],"op":"subscribe"}` {
+				t.Fatalf("connection %d subscribe = %s", i+1, sub)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("connection %d not established", i+1)
+		}
+		select {
+		case ev := <-events:
+			if string(ev.Payload) != `{"price":1}` || ev.Metadata["frame_type"] != "text" || ev.Metadata["url"] != srv.wsURL() {
+				t.Fatalf("event = %+v, want pushed frame", ev)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event on connection %d", i+1)
+		}
+	}
+}
+
+func TestWebSocketTriggerBackoff(t *testing.T) {
+	srv := newWSTestServer(2, func(conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer srv.Close()
+
+	trig := initWebSocketTrigger(t, map[string]interface{}{
+		"url":           srv.wsURL(),
+		"reconnect_min": 1,
+		"reconnect_max": 2,
+		"ping_interval": 0,
+	})
+	trig.Start(context.Background(), func(ctx context.Context, event *model.TriggerEvent) error { return nil })
+	defer trig.Stop(context.Background())
+
+	deadline := time.Now().Add(10 * time.Second)
+	for trig.CheckHealth(context.Background()) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("trigger did not connect after rejected handshakes")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// 退避：1s 起步（附带至多 50% 抖动），之后翻倍为 2s（即 reconnect_max）
+	attempts := srv.attemptTimes()
+	if len(attempts) != 3 {
+		t.Fatalf("dial attempts = %d, want 3", len(attempts))
+	}
+	wantGaps := []struct{ min, max time.Duration }{
+		{time.Second, 1600 * time.Millisecond},
+		{2 * time.Second, 3100 * time.Millisecond},
+	}
+	for i, want := range wantGaps {
+		if gap := attempts[i+1].Sub(attempts[i]); gap < want.min || gap > want.max {
+			t.Fatalf("backoff before attempt %d = %s, want [%s, %s]", i+2, gap, want.min, want.max)
+		}
+	}
+}
+
+func TestWebSocketTriggerCheckReady(t *testing.T) {
+	closeConn := make(chan struct{})
+	srv := newWSTestServer(0, func(conn *websocket.Conn) {
+		<-closeConn
+	})
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		readyGrace int
+		wantReady  bool // 断连后（宽限期内）的就绪结果
+	}{
+		{name: "disconnect within grace stays ready", readyGrace: 30, wantReady: true},
+		{name: "zero grace fails immediately", readyGrace: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trig := initWebSocketTrigger(t, map[string]interface{}{
+				"url":           srv.wsURL(),
+				"reconnect_min": 5,
+				"reconnect_max": 5,
+				"ping_interval": 0,
+				"ready_grace":   tt.readyGrace,
+			})
+			if err := trig.CheckReady(context.Background()); err == nil {
+				t.Fatal("CheckReady() before Start = nil, want not started")
+			}
+			trig.Start(context.Background(), func(ctx context.Context, event *model.TriggerEvent) error { return nil })
+			defer trig.Stop(context.Background())
+
+			deadline := time.Now().Add(5 * time.Second)
+			for trig.CheckHealth(context.Background()) != nil {
+				if time.Now().After(deadline) {
+					t.Fatal("trigger did not connect")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if err := trig.CheckReady(context.Background()); err != nil {
+				t.Fatalf("CheckReady() while connected = %v", err)
+			}
+
+			// 服务端断开连接，触发器进入 5s 退避
+			closeConn <- struct{}{}
+			for trig.CheckHealth(context.Background()) == nil {
+				if time.Now().After(deadline) {
+					t.Fatal("disconnect not detected")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if err := trig.CheckReady(context.Background()); (err == nil) != tt.wantReady {
+				t.Fatalf("CheckReady() after disconnect = %v, want ready %v", err, tt.wantReady)
+			}
+
+			// 停止在退避等待中立即返回
+			begin := time.Now()
+			if err := trig.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+			if took := time.Since(begin); took > time.Second {
+				t.Fatalf("Stop() took %s, want prompt return during backoff", took)
+			}
+		})
+	}
+}
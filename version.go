@@ -0,0 +1,111 @@
+package scf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// ExitCodeVersionMismatch 心跳发现本地版本与服务端不一致、排空后退出时使用的退出码，
+// 编排系统据此拉起新版本
+const ExitCodeVersionMismatch = 3
+
+// ErrVersionMismatch 处理方式为 shutdown 时，版本不一致触发优雅退出后 Run 返回的错误
+var ErrVersionMismatch = errors.New("package version mismatch")
+
+// ExitCode 将 Run 的返回值映射为进程退出码：nil 为 0，ErrVersionMismatch 为 ExitCodeVersionMismatch，其他错误为 1。
+// main 中使用 os.Exit(scf.ExitCode(err)) 退出，由调用方决定进程何时结束
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrVersionMismatch):
+		return ExitCodeVersionMismatch
+	default:
+		return 1
+	}
+}
+
+// VersionMismatchPolicy 心跳发现本地版本与服务端不一致时的处理方式
+type VersionMismatchPolicy string
+
+// 版本不一致处理方式
+const (
+	VersionMismatchShutdown VersionMismatchPolicy = "shutdown" // 排空后 Run 返回 ErrVersionMismatch（默认）
+	VersionMismatchWarn     VersionMismatchPolicy = "warn"     // 仅告警，继续运行（预发/灰度环境）
+	VersionMismatchCustom   VersionMismatchPolicy = "custom"   // 仅调用 WithVersionMismatchHandler 设置的回调，框架不做其他处理
+)
+
+// VersionMismatch 版本不一致事件，同一服务端版本只产生一次
+type VersionMismatch struct {
+	NodeID        string
+	LocalVersion  string
+	ServerVersion string
+	Policy        VersionMismatchPolicy // 本次生效的处理方式
+	DetectedAt    time.Time
+}
+
+// VersionMismatchHandler 版本不一致回调，在框架执行处理方式（如退出）之前同步调用
+type VersionMismatchHandler func(ctx context.Context, ev VersionMismatch)
+
+// versionMismatchPolicy 返回生效的处理方式：WithVersionMismatchPolicy > heartbeat.on_version_mismatch > shutdown
+func (a *App) versionMismatchPolicy() VersionMismatchPolicy {
+	if a.opts.versionMismatchPolicy != "" {
+		return a.opts.versionMismatchPolicy
+	}
+	if cfg := a.Config(); cfg != nil && cfg.Heartbeat.OnVersionMismatch != "" {
+		return VersionMismatchPolicy(cfg.Heartbeat.OnVersionMismatch)
+	}
+	return VersionMismatchShutdown
+}
+
+// handleVersionMismatch 记录结构化日志并调用用户回调，再按处理方式排空退出或继续运行。
+// shutdown 时仅记录事件并调用 Shutdown，由 Run 在 Serve 返回后返回 ErrVersionMismatch，不在心跳 goroutine 中退出进程
+func (a *App) handleVersionMismatch(ctx context.Context, local, remote string) {
+	ev := VersionMismatch{
+		NodeID:        a.runtime.GetNodeID(),
+		LocalVersion:  local,
+		ServerVersion: remote,
+		Policy:        a.versionMismatchPolicy(),
+		DetectedAt:    time.Now(),
+	}
+	ctx = log.WithContextFields(ctx,
+		"event", "version_mismatch",
+		"node_id", ev.NodeID,
+		"local_version", ev.LocalVersion,
+		"server_version", ev.ServerVersion,
+		"policy", string(ev.Policy))
+
+	if a.opts.versionMismatchHandler != nil {
+		a.opts.versionMismatchHandler(ctx, ev)
+	}
+
+	switch ev.Policy {
+	case VersionMismatchShutdown:
+		log.WarnContextf(ctx, "version mismatch (local=%s, server=%s), draining before shutdown", local, remote)
+		a.versionMismatch.Store(&ev)
+		if err := a.Shutdown(ctx); err != nil {
+			log.ErrorContextf(ctx, "graceful shutdown incomplete: %v", err)
+		}
+	case VersionMismatchCustom:
+		if a.opts.versionMismatchHandler == nil {
+			log.WarnContextf(ctx, "version mismatch (local=%s, server=%s), policy custom without handler, keep running",
+				local, remote)
+		}
+	default:
+		log.WarnContextf(ctx, "version mismatch (local=%s, server=%s), policy %s, keep running",
+			local, remote, ev.Policy)
+	}
+}
+
+// versionMismatchErr 已因版本不一致触发退出时返回包装 ErrVersionMismatch 的错误，否则返回 nil
+func (a *App) versionMismatchErr() error {
+	ev := a.versionMismatch.Load()
+	if ev == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: local=%s, server=%s", ErrVersionMismatch, ev.LocalVersion, ev.ServerVersion)
+}
@@ -0,0 +1,118 @@
+package scf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "clean exit", want: 0},
+		{name: "version mismatch", err: ErrVersionMismatch, want: ExitCodeVersionMismatch},
+		{name: "wrapped version mismatch", err: fmt.Errorf("run: %w", ErrVersionMismatch), want: ExitCodeVersionMismatch},
+		{name: "other error", err: errors.New("failed to load config"), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Fatalf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionMismatchPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      VersionMismatchPolicy
+		withHandler bool
+		wantStopped bool // Run 因版本不一致返回
+	}{
+		{name: "shutdown drains and Run returns ErrVersionMismatch", policy: VersionMismatchShutdown, withHandler: true, wantStopped: true},
+		{name: "shutdown is the default", wantStopped: true},
+		{name: "warn keeps running", policy: VersionMismatchWarn, withHandler: true},
+		{name: "custom only calls handler", policy: VersionMismatchCustom, withHandler: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []VersionMismatch
+			var opts []Option
+			if tt.policy != "" {
+				opts = append(opts, WithVersionMismatchPolicy(tt.policy))
+			}
+			if tt.withHandler {
+				opts = append(opts, WithVersionMismatchHandler(func(ctx context.Context, ev VersionMismatch) {
+					events = append(events, ev)
+				}))
+			}
+			steps := &stepLog{}
+			svc := &fakeService{log: steps, serving: make(chan struct{})}
+			a, errc := startApp(t, &closerPlugin{log: steps}, svc, opts...)
+			select {
+			case <-svc.serving:
+			case err := <-errc:
+				t.Fatalf("Run() error = %v", err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("server did not start serving")
+			}
+
+			// 在独立 goroutine 中调用，与心跳 goroutine 的调用方式一致；返回即说明未阻塞或退出进程
+			handled := make(chan struct{})
+			go func() {
+				a.handleVersionMismatch(context.Background(), "v1", "v2")
+				close(handled)
+			}()
+			select {
+			case <-handled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("handleVersionMismatch() did not return")
+			}
+
+			wantPolicy := tt.policy
+			if wantPolicy == "" {
+				wantPolicy = VersionMismatchShutdown
+			}
+			if tt.withHandler {
+				if len(events) != 1 || events[0].LocalVersion != "v1" || events[0].ServerVersion != "v2" || events[0].Policy != wantPolicy {
+					t.Fatalf("handler events = %+v, want one v1→v2 event with policy %s", events, wantPolicy)
+				}
+			}
+
+			if !tt.wantStopped {
+				select {
+				case err := <-errc:
+					t.Fatalf("Run() returned %v under policy %s, want keep running", err, wantPolicy)
+				case <-time.After(50 * time.Millisecond):
+				}
+				if err := a.Shutdown(context.Background()); err != nil {
+					t.Fatalf("Shutdown() error = %v", err)
+				}
+			}
+			select {
+			case err := <-errc:
+				if got := errors.Is(err, ErrVersionMismatch); got != tt.wantStopped {
+					t.Fatalf("Run() error = %v, want ErrVersionMismatch %v", err, tt.wantStopped)
+				}
+				if tt.wantStopped && ExitCode(err) != ExitCodeVersionMismatch {
+					t.Fatalf("ExitCode(%v) = %d, want %d", err, ExitCode(err), ExitCodeVersionMismatch)
+				}
+				if !tt.wantStopped && err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run() did not return")
+			}
+			// 排空（插件 Close）先于 Server 关闭
+			if got := steps.get(); len(got) != 2 || got[0] != "plugin closed" || got[1] != "service closed" {
+				t.Fatalf("shutdown steps = %v, want [plugin closed service closed]", got)
+			}
+		})
+	}
+}